@@ -0,0 +1,87 @@
+package benchmark
+
+import (
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+// BenchmarkHitRatioScan replays a trace mixing a small hot set with a long scan of keys that
+// are never read again, and reports the hit ratio with and without the TinyLFU admission policy.
+func BenchmarkHitRatioScan(b *testing.B) {
+	const (
+		capacity = 100
+		hotKeys  = 80
+	)
+
+	policies := map[string]func() fcache.AdmissionPolicy{
+		"LRU":     func() fcache.AdmissionPolicy { return nil },
+		"TinyLFU": func() fcache.AdmissionPolicy { return fcache.NewTinyLFU(capacity) },
+	}
+
+	for name, policy := range policies {
+		b.Run(name, func(b *testing.B) {
+			misses := 0
+			fn := func(key int) (int, error) {
+				misses++
+				return key, nil
+			}
+			cached := fcache.NewCachedFunctionComparable(fn, &fcache.Config{
+				Capacity:        capacity,
+				AdmissionPolicy: policy(),
+			}, nil)
+
+			scan := 1_000_000
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				// Every access to the hot set is followed by two one-off scan keys
+				_, _ = cached(i % hotKeys)
+				_, _ = cached(scan)
+				_, _ = cached(scan + 1)
+				scan += 2
+			}
+			b.ReportMetric(1-float64(misses)/float64(3*b.N), "hit-ratio")
+		})
+	}
+}
+
+// BenchmarkHitRatioScanBurst replays a trace that reads a hot set repeatedly and is interrupted by
+// bursts of one-off scan keys, twice the capacity long, and reports the hit ratio of plain LRU and
+// the segmented LRU eviction policy. Each scan flushes the hot set out of an LRU cache, while SLRU
+// keeps it in the protected segment.
+func BenchmarkHitRatioScanBurst(b *testing.B) {
+	const (
+		capacity  = 100
+		hotKeys   = 60
+		hotRounds = 4 // reads of each hot key between two scans
+		scanLen   = 2 * capacity
+	)
+
+	policies := []fcache.EvictionPolicy{fcache.EvictionLRU, fcache.EvictionSLRU}
+	for _, policy := range policies {
+		b.Run(policy.String(), func(b *testing.B) {
+			misses := 0
+			fn := func(key int) (int, error) {
+				misses++
+				return key, nil
+			}
+			cached := fcache.NewCachedFunctionComparable(fn, &fcache.Config{
+				Capacity:       capacity,
+				EvictionPolicy: policy,
+			}, nil)
+
+			const cycle = hotKeys*hotRounds + scanLen
+			scan := 1_000_000
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if pos := i % cycle; pos < hotKeys*hotRounds {
+					_, _ = cached(pos % hotKeys)
+				} else {
+					_, _ = cached(scan)
+					scan++
+				}
+			}
+			b.ReportMetric(1-float64(misses)/float64(b.N), "hit-ratio")
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package benchmark
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache/internal/core"
+)
+
+// newExpiringStorage builds a storage of the given size and returns it with a TTL that the first
+// expired entries have outlived and the rest have not. With apply set, the TTL is also applied
+// to the storage; otherwise the storage keeps its one-hour TTL, so Range still visits every entry.
+func newExpiringStorage(entries, expired int, apply bool) (*core.Storage[string, int], time.Duration) {
+	s := core.NewStorage[string, int](core.Config{
+		TTL:                      time.Hour,
+		Capacity:                 entries,
+		DisableBackgroundCleanup: true,
+	})
+	for k := 0; k < expired; k++ {
+		s.Set(strconv.Itoa(k), k)
+	}
+	time.Sleep(20 * time.Millisecond)
+	boundary := time.Now()
+	for k := expired; k < entries; k++ {
+		s.Set(strconv.Itoa(k), k)
+	}
+	// Entries set before the boundary are at least 20ms older than those set after it,
+	// so a TTL 10ms past the boundary expires exactly the first group.
+	ttl := time.Since(boundary) + 10*time.Millisecond
+	if apply {
+		s.SetTTL(ttl)
+	}
+	return s, ttl
+}
+
+// BenchmarkCleanupExpired measures one cleanup sweep of a 100k-entry storage in which 1% of the
+// entries are expired. Building the storage is excluded from the reported cleanup-ns/op.
+func BenchmarkCleanupExpired(b *testing.B) {
+	const (
+		entries = 100_000
+		expired = entries / 100
+	)
+
+	var total time.Duration
+	for i := 0; i < b.N; i++ {
+		s, _ := newExpiringStorage(entries, expired, true)
+
+		start := time.Now()
+		removed := s.PurgeExpired()
+		total += time.Since(start)
+		if removed != expired {
+			b.Fatalf("PurgeExpired removed %d; want %d", removed, expired)
+		}
+	}
+	b.ReportMetric(float64(total.Nanoseconds())/float64(b.N), "cleanup-ns/op")
+}
+
+// BenchmarkCleanupSweepVsScan contrasts the cleanup sweep, which walks the timestamp-ordered list
+// and stops at the first live entry, with a full scan that compares every entry's age to the TTL,
+// as a cleanup without ordered timestamps would have to. The heap case covers per-entry TTLs
+// (Config.TTLFunc), which expire out of insertion order and are popped from a deadline heap instead.
+// 1% of the entries are expired at each size.
+//
+// It stands in for the proposed per-second TTL buckets, which were not implemented: both sweeps
+// already visit only expired entries, which is what the buckets would buy, without their loss of precision.
+func BenchmarkCleanupSweepVsScan(b *testing.B) {
+	for _, entries := range []int{10_000, 100_000} {
+		expired := entries / 100
+
+		b.Run("sweep/"+strconv.Itoa(entries), func(b *testing.B) {
+			var total time.Duration
+			for i := 0; i < b.N; i++ {
+				s, _ := newExpiringStorage(entries, expired, true)
+
+				start := time.Now()
+				removed := s.PurgeExpired()
+				total += time.Since(start)
+				if removed != expired {
+					b.Fatalf("PurgeExpired removed %d; want %d", removed, expired)
+				}
+			}
+			b.ReportMetric(float64(total.Nanoseconds())/float64(b.N), "cleanup-ns/op")
+		})
+
+		b.Run("scan/"+strconv.Itoa(entries), func(b *testing.B) {
+			var total time.Duration
+			for i := 0; i < b.N; i++ {
+				s, ttl := newExpiringStorage(entries, expired, false)
+
+				start := time.Now()
+				var stale []string
+				s.Range(func(key string, _ int, age time.Duration) bool {
+					if age > ttl {
+						stale = append(stale, key)
+					}
+					return true
+				})
+				for _, key := range stale {
+					s.Delete(key)
+				}
+				total += time.Since(start)
+				if len(stale) != expired {
+					b.Fatalf("scan removed %d; want %d", len(stale), expired)
+				}
+			}
+			b.ReportMetric(float64(total.Nanoseconds())/float64(b.N), "cleanup-ns/op")
+		})
+
+		b.Run("heap/"+strconv.Itoa(entries), func(b *testing.B) {
+			var total time.Duration
+			for i := 0; i < b.N; i++ {
+				c := newPerEntryTTLCache(entries)
+
+				start := time.Now()
+				removed := c.PurgeExpired()
+				total += time.Since(start)
+				if removed != expired {
+					b.Fatalf("PurgeExpired removed %d; want %d", removed, expired)
+				}
+			}
+			b.ReportMetric(float64(total.Nanoseconds())/float64(b.N), "cleanup-ns/op")
+		})
+	}
+}
+
+// newPerEntryTTLCache builds a cache of the given size with per-entry TTLs in which every hundredth
+// entry, spread over the whole insertion order, is expired and the rest are not.
+func newPerEntryTTLCache(entries int) *core.Cache[int, string, int] {
+	c := core.NewCache(func(k int) (int, error) {
+		return k, nil
+	}, &core.Config{
+		TTL: time.Hour,
+		TTLFunc: func(v int) time.Duration {
+			if v%100 == 0 {
+				return time.Millisecond
+			}
+			return 0 // the global TTL
+		},
+		TTLPrecedence:            core.TTLPerEntry,
+		Capacity:                 entries,
+		DisableBackgroundCleanup: true,
+	}, nil)
+	for k := 0; k < entries; k++ {
+		c.Call(k)
+	}
+	time.Sleep(5 * time.Millisecond)
+	return c
+}
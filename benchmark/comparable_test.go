@@ -0,0 +1,42 @@
+package benchmark
+
+import (
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+func BenchmarkCachedComparableCold(b *testing.B) {
+	const delay = 10
+	cached := fcache.NewCachedFunctionComparable(slowFunc, nil, nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		// Use a new key each time to simulate "cold" cache access (no hits)
+		key := delay + i
+		_, err := cached(key)
+		if err != nil {
+			b.Fatalf("err: %v", err)
+		}
+	}
+}
+
+func BenchmarkCachedComparableWarm(b *testing.B) {
+	const delay = 10
+	cached := fcache.NewCachedFunctionComparable(slowFunc, nil, nil)
+	// Pre-warm the cache with a single entry
+	_, _ = cached(delay)
+	// A hit on a small integer key must not allocate
+	if allocs := testing.AllocsPerRun(10, func() { _, _ = cached(delay) }); allocs != 0 {
+		b.Fatalf("warm hit allocates %v times; want 0", allocs)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Always use the same key to simulate warm (cache hit) access
+		_, err := cached(delay)
+		if err != nil {
+			b.Fatalf("err: %v", err)
+		}
+	}
+}
@@ -21,3 +21,34 @@ func BenchmarkCachedParallel(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkCachedParallelMetrics measures parallel warm hits on distinct keys with the Metrics
+// counters enabled and disabled. The hit counter is updated by every CPU; it sits alone on its
+// cache line, so the difference is the cost of the shared increment itself.
+func BenchmarkCachedParallelMetrics(b *testing.B) {
+	const keys = 1024
+	for _, disabled := range []bool{false, true} {
+		name := "enabled"
+		if disabled {
+			name = "disabled"
+		}
+		b.Run(name, func(b *testing.B) {
+			cached := fcache.NewCachedFunctionComparable(func(key int) (int, error) {
+				return key, nil
+			}, &fcache.Config{Capacity: keys, DisableMetrics: disabled}, nil)
+			for k := 0; k < keys; k++ {
+				_, _ = cached(k)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				k := 0
+				for pb.Next() {
+					_, _ = cached(k % keys)
+					k++
+				}
+			})
+		})
+	}
+}
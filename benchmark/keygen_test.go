@@ -0,0 +1,40 @@
+package benchmark
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/osmike/fcache/internal/lib/keygen"
+)
+
+// BenchmarkBuildKeyLarge builds the key of a large slice argument (about 7 MB of JSON) by marshalling
+// it and hashing the result, as keys used to be built, and by streaming the JSON encoding into the hash.
+// Compare the B/op columns: marshalling allocates a copy of the whole encoding on every call.
+func BenchmarkBuildKeyLarge(b *testing.B) {
+	arg := make([]int, 1<<20)
+	for i := range arg {
+		arg[i] = i
+	}
+
+	b.Run("marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			data, err := json.Marshal(arg)
+			if err != nil {
+				b.Fatal(err)
+			}
+			sum := sha256.Sum256(data)
+			_ = hex.EncodeToString(sum[:])
+		}
+	})
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := keygen.BuildKey(arg); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -11,6 +11,10 @@ func BenchmarkCachedWarm(b *testing.B) {
 	cached := fcache.NewCachedFunction(slowFunc, nil, nil)
 	// Pre-warm the cache with a single entry
 	_, _ = cached(delay)
+	// A hit on a small integer key must not allocate
+	if allocs := testing.AllocsPerRun(10, func() { _, _ = cached(delay) }); allocs != 0 {
+		b.Fatalf("warm hit allocates %v times; want 0", allocs)
+	}
 
 	b.ReportAllocs()
 	b.ResetTimer() // reset the timer to exclude setup time
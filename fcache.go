@@ -32,10 +32,63 @@
 package fcache
 
 import (
+	"context"
+	"io"
+	"time"
+
 	"github.com/osmike/fcache/internal/core"
+	"github.com/osmike/fcache/internal/lib/errs"
 	"github.com/osmike/fcache/internal/lib/hooks"
+	"github.com/osmike/fcache/internal/lib/keygen"
+)
+
+// Sentinel errors returned (wrapped) by cached functions. Use errors.Is to match them.
+var (
+	// ErrPanic is returned if a panic occurs in the cached function.
+	ErrPanic = core.ErrPanic
+
+	// ErrExecutionTimeout is returned if the cached function runs longer than Config.ExecutionTimeout.
+	ErrExecutionTimeout = core.ErrExecutionTimeout
+
+	// ErrCircuitOpen is returned for cache misses while the circuit breaker is open.
+	ErrCircuitOpen = core.ErrCircuitOpen
+
+	// ErrKeyGeneration is returned if the argument cannot be cached because no key can be built from it,
+	// as opposed to an error of the function itself. It wraps the underlying ErrBuildKey error.
+	ErrKeyGeneration = core.ErrKeyGeneration
+
+	// ErrCacheFull is returned, together with the computed value, if the result could not be stored
+	// because the cache is full and Config.OnFull is FullReject or FullBlock.
+	ErrCacheFull = core.ErrCacheFull
+
+	// ErrConcurrencyLimit is returned if Config.MaxConcurrentExecutions functions are already running
+	// and Config.ConcurrencyFailFast is set.
+	ErrConcurrencyLimit = core.ErrConcurrencyLimit
+
+	// ErrWarmCapacity is reported by Handle.WarmAndWait for arguments left cold because warming them
+	// would exceed the capacity of the cache.
+	ErrWarmCapacity = core.ErrWarmCapacity
+
+	// ErrInvalidConfig is returned by Config.Validate for contradictory settings; the constructors panic with it.
+	ErrInvalidConfig = core.ErrInvalidConfig
+
+	// ErrBuildKey is returned if a cache key cannot be built from the function argument.
+	ErrBuildKey = keygen.ErrBuildKey
+
+	// ErrMarshallJSON indicates that the function argument could not be marshalled to JSON for key generation.
+	ErrMarshallJSON = keygen.ErrMarshallJSON
+
+	// ErrHookQueueFull is reported to LogError when an async hook is dropped because its queue is full.
+	ErrHookQueueFull = hooks.ErrHookQueueFull
+
+	// ErrHookTimeout is reported to LogError when a hook runs longer than Config.HookTimeout.
+	ErrHookTimeout = hooks.ErrHookTimeout
 )
 
+// Error is the structured error type used by fcache.
+// Use errors.As to access the sentinel error and its context fields (e.g. the panic value).
+type Error = errs.Error
+
 // CachedFunc is a generic function type that can be wrapped with caching.
 // K is the input parameter type, V is the result type.
 type CachedFunc[K any, V any] = core.CachedFunc[K, V]
@@ -43,9 +96,118 @@ type CachedFunc[K any, V any] = core.CachedFunc[K, V]
 // Config defines cache configuration options such as TTL and capacity.
 type Config = core.Config
 
+// KeyHasher customizes how keys too long to be used as is are hashed, via Config.KeyHasher:
+// New returns the hash function (default: sha256.New) and Encode formats the digest (default: hex).
+type KeyHasher = keygen.Hasher
+
 // Hooks provides optional hooks for cache events (e.g., on hit, miss, eviction).
 type Hooks = hooks.Hooks
 
+// EvictionPolicy selects which entry is evicted to make room in a full cache, via Config.EvictionPolicy.
+type EvictionPolicy = core.EvictionPolicy
+
+// Eviction policies.
+const (
+	EvictionLRU    = core.EvictionLRU    // Evict the least recently used entry (default).
+	EvictionFIFO   = core.EvictionFIFO   // Evict the entry inserted first; hits don't reorder entries.
+	EvictionRandom = core.EvictionRandom // Evict an arbitrary entry.
+	EvictionSLRU   = core.EvictionSLRU   // Segmented LRU: evict entries hit at most once before the hot set.
+)
+
+// FullPolicy selects what storing a new key into a full cache does, via Config.OnFull.
+type FullPolicy = core.FullPolicy
+
+// Full-cache policies.
+const (
+	FullEvict  = core.FullEvict  // Evict an entry to make room (default).
+	FullReject = core.FullReject // Don't store the result; return it with ErrCacheFull.
+	FullBlock  = core.FullBlock  // Wait up to Config.OnFullTimeout for space, then behave like FullReject.
+)
+
+// TTLPrecedence selects how the TTL of an entry from Config.TTLFunc combines with the global TTL,
+// via Config.TTLPrecedence.
+type TTLPrecedence = core.TTLPrecedence
+
+// TTL precedences.
+const (
+	TTLMin      = core.TTLMin      // Expire after the shorter of the entry's TTL and the global TTL (default).
+	TTLPerEntry = core.TTLPerEntry // Expire after the entry's TTL, even past the global TTL.
+	TTLGlobal   = core.TTLGlobal   // Expire after the global TTL, ignoring TTLFunc.
+)
+
+// UnboundedCapacity, as Config.Capacity or Handle.SetCapacity, removes the limit on the number of entries,
+// e.g. to let Config.MaxBytes alone bound the cache.
+const UnboundedCapacity = core.UnboundedCapacity
+
+// AdmissionPolicy decides whether a new entry may displace the eviction candidate when the cache is full.
+type AdmissionPolicy = core.AdmissionPolicy
+
+// Metrics is a point-in-time snapshot of cache counters, returned by Handle.Metrics.
+type Metrics = core.Metrics
+
+// StorageStat is a snapshot of the cache entries, returned by Handle.Stats.
+type StorageStat[V any] = core.StorageStat[V]
+
+// StorageItem is a cache entry in a StorageStat snapshot: its value, stored error, tags and timestamp.
+type StorageItem[V any] = core.StorageItem[V]
+
+// DebugState reports the background goroutines of a cache, returned by Handle.DebugState for tests.
+type DebugState = core.DebugState
+
+// Clock is the time source of the storage, set via Config.Clock; inject a fake to test TTLs deterministically.
+type Clock = core.Clock
+
+// Ticker delivers periodic ticks from a Clock, like time.Ticker; the background cleanup runs on one.
+type Ticker = core.Ticker
+
+// RetryPolicy configures retries of failed calls with exponential backoff, via Config.Retry.
+type RetryPolicy = core.RetryPolicy
+
+// PressureEvent is passed to the OnPressure hook when capacity evictions outpace hits.
+type PressureEvent = hooks.PressureEvent
+
+// InflightJoinEvent is passed to the OnInflightJoin hook when a call joins a computation in flight.
+type InflightJoinEvent = hooks.InflightJoinEvent
+
+// JSONLogger writes cache events as structured JSON lines through hooks. Create it with NewJSONLogger.
+type JSONLogger = hooks.JSONLogger
+
+// NewJSONLogger returns a logger writing cache events to w as JSON lines, with the event type, key and
+// error, and the sentinel and fields of structured fcache errors. Its Hooks method wires it for errors,
+// removals and pressure; its other methods are single hooks to add more events:
+//
+//	logger := fcache.NewJSONLogger(os.Stderr)
+//	h := logger.Hooks()
+//	h.OnEvict = logger.Event("evict")
+//	cached := fcache.NewCachedFunction(fetch, nil, h)
+//
+// A line looks like {"time":"...","event":"error","error":"...","type":"circuit breaker is open","fields":{"key":"42"}}.
+func NewJSONLogger(w io.Writer) *JSONLogger {
+	return hooks.NewJSONLogger(w)
+}
+
+// HookContext carries the cache key, argument, and result of a cache event to context hooks.
+type HookContext = hooks.HookContext
+
+// RemoveReason tells the OnRemove hook why an entry left the cache, via HookContext.Reason.
+type RemoveReason = hooks.RemoveReason
+
+// Removal reasons reported to the OnRemove hook.
+const (
+	ReasonCapacity = hooks.ReasonCapacity // Evicted to make room (capacity, MaxBytes or memory reclaim).
+	ReasonTTL      = hooks.ReasonTTL      // Expired.
+	ReasonManual   = hooks.ReasonManual   // Deleted explicitly, e.g. by InvalidateFunc or InvalidateTag.
+	ReasonClear    = hooks.ReasonClear    // Removed by Clear.
+)
+
+// Handle is a cached function together with methods to manage its cache at runtime.
+// Use Handle.Call as the cached function.
+type Handle[K any, V any] = core.Cache[K, string, V]
+
+// ComparableHandle is a Handle for a function with a comparable argument type,
+// whose storage is keyed directly by the argument value.
+type ComparableHandle[K comparable, V any] = core.Cache[K, K, V]
+
 // NewCachedFunction wraps a function with a concurrent-safe caching layer.
 //
 //   - fn: The function to cache. Must be of type func(K) (V, error).
@@ -62,3 +224,340 @@ type Hooks = hooks.Hooks
 func NewCachedFunction[K any, V any](fn CachedFunc[K, V], opts *Config, hooks *hooks.Hooks) CachedFunc[K, V] {
 	return core.NewCachedFunction(fn, opts, hooks)
 }
+
+// NewCachedFunctionWithContext is like NewCachedFunction, but closes the cache once ctx is cancelled:
+// its entries are dropped and its cleanup goroutine stops. The returned function keeps working afterwards,
+// calling fn without caching.
+//
+// Example:
+//
+//	func handleJob(ctx context.Context, job Job) {
+//		lookup := fcache.NewCachedFunctionWithContext(ctx, lookupRate, nil, nil)
+//		// ... the cache is released when the job's context ends
+//	}
+func NewCachedFunctionWithContext[K any, V any](ctx context.Context, fn CachedFunc[K, V], opts *Config, hooks *hooks.Hooks) CachedFunc[K, V] {
+	return core.NewCachedFunctionWithContext(ctx, fn, opts, hooks)
+}
+
+// Option configures a cache built by New.
+type Option = core.Option
+
+// New wraps a function with a concurrent-safe caching layer configured by functional options.
+//
+// It is an alternative to NewCachedFunction that only mentions the settings that differ from
+// the defaults. Options are applied in order; later options override earlier ones.
+//
+// Example:
+//
+//	cachedFetch := fcache.New(fetchDataFromRemote,
+//		fcache.WithTTL(time.Minute),
+//		fcache.WithCapacity(500),
+//		fcache.WithHooks(&fcache.Hooks{LogError: logErr}),
+//	)
+func New[K any, V any](fn CachedFunc[K, V], opts ...Option) CachedFunc[K, V] {
+	return core.New(fn, opts...)
+}
+
+// WithConfig sets every configuration field from cfg; use it for options without a dedicated With function.
+func WithConfig(cfg *Config) Option {
+	return core.WithConfig(cfg)
+}
+
+// WithTTL sets the time-to-live of cache entries.
+func WithTTL(ttl time.Duration) Option {
+	return core.WithTTL(ttl)
+}
+
+// WithCapacity sets the maximum number of cache entries.
+func WithCapacity(capacity int) Option {
+	return core.WithCapacity(capacity)
+}
+
+// WithCleanupInterval sets the interval of the background cleanup of expired entries.
+func WithCleanupInterval(interval time.Duration) Option {
+	return core.WithCleanupInterval(interval)
+}
+
+// WithNamespace sets a prefix isolating the cache's keyspace.
+func WithNamespace(namespace string) Option {
+	return core.WithNamespace(namespace)
+}
+
+// WithKeyFields keys struct arguments by the named fields only, ignoring volatile fields such as
+// timestamps or request IDs (Config.KeyFields). Building the cache panics with ErrInvalidConfig if the
+// argument type has no such exported field.
+func WithKeyFields(fields ...string) Option {
+	return core.WithKeyFields(fields...)
+}
+
+// WithHooks sets the lifecycle hooks.
+func WithHooks(h *Hooks) Option {
+	return core.WithHooks(h)
+}
+
+// WithEviction sets a callback run with the key and value of every entry evicted to make room
+// for a new one (Hooks.OnEvict).
+func WithEviction(fn func(hc HookContext) error) Option {
+	return core.WithEviction(fn)
+}
+
+// NewHandle wraps a function with a concurrent-safe caching layer and returns a Handle.
+//
+// Arguments are the same as for NewCachedFunction. Use the returned handle's Call method
+// as the cached function, and its other methods (e.g. PurgeExpired) to manage the cache.
+//
+// Example:
+//
+//	h := fcache.NewHandle(fetchDataFromRemote, &fcache.Config{DisableBackgroundCleanup: true}, nil)
+//	result, err := h.Call(2000)
+//	h.PurgeExpired()
+func NewHandle[K any, V any](fn CachedFunc[K, V], opts *Config, hooks *hooks.Hooks) *Handle[K, V] {
+	return core.NewCache(fn, opts, hooks)
+}
+
+// Store is a standalone TTL and LRU cache of values keyed by string, for values computed elsewhere:
+// the storage behind every cache, with Get, Set, Delete, Stats and the same eviction, expiry and
+// cleanup lifecycle, but no function, deduplication or hooks. Create it with NewStore.
+type Store[V any] struct {
+	s *core.Storage[string, V]
+}
+
+// NewStore returns an empty Store configured by the storage settings of opts (TTL, capacity, cleanup,
+// eviction); other settings are ignored. Pass nil for the defaults: a 5 minute TTL and 1000 entries.
+// Call Close when done with it, to drop the entries and stop the cleanup goroutine.
+//
+// Example:
+//
+//	sessions := fcache.NewStore[Session](&fcache.Config{TTL: 30 * time.Minute, SlidingTTL: true})
+//	sessions.Set(id, session)
+//	s, ok := sessions.Get(id)
+func NewStore[V any](opts *Config) *Store[V] {
+	return &Store[V]{s: core.NewStore[string, V](opts)}
+}
+
+// Get returns the value stored for key and whether it is valid. A hit updates the LRU order and,
+// with SlidingTTL, restarts the entry's TTL.
+func (s *Store[V]) Get(key string) (V, bool) {
+	return s.s.Get(key)
+}
+
+// Set stores value for key, evicting an entry if the store is full.
+func (s *Store[V]) Set(key string, value V) {
+	s.s.Set(key, value)
+}
+
+// Swap stores value for key and returns the value it replaced and whether there was one.
+// Under OnFull policies other than FullEvict it returns ErrCacheFull if there is no room.
+func (s *Store[V]) Swap(key string, value V) (V, bool, error) {
+	return s.s.Swap(key, value)
+}
+
+// Delete removes the entry for key, if any.
+func (s *Store[V]) Delete(key string) {
+	s.s.Delete(key)
+}
+
+// Contains reports whether a valid entry is stored for key, without affecting the LRU order.
+func (s *Store[V]) Contains(key string) bool {
+	return s.s.Contains(key)
+}
+
+// TTLRemaining returns how long the entry for key stays valid and whether it is valid.
+// For an expired entry it returns the negative time since expiry, and for an absent key 0.
+func (s *Store[V]) TTLRemaining(key string) (time.Duration, bool) {
+	return s.s.TTLRemaining(key)
+}
+
+// Len returns the number of entries, including expired entries not yet removed.
+func (s *Store[V]) Len() int {
+	return s.s.Len()
+}
+
+// Range calls f for each valid entry, from most to least recently used, with its key, value and age,
+// until f returns false. f must not write to the store.
+func (s *Store[V]) Range(f func(key string, val V, age time.Duration) bool) {
+	s.s.Range(f)
+}
+
+// Stats returns a snapshot of the valid entries, from most to least recently used.
+func (s *Store[V]) Stats() StorageStat[V] {
+	return s.s.Stats()
+}
+
+// Capacity returns the maximum number of entries.
+func (s *Store[V]) Capacity() int {
+	return s.s.Capacity()
+}
+
+// SetCapacity changes the maximum number of entries, evicting entries down to it.
+func (s *Store[V]) SetCapacity(capacity int) {
+	s.s.SetCapacity(capacity)
+}
+
+// TTL returns the time-to-live of the entries.
+func (s *Store[V]) TTL() time.Duration {
+	return s.s.TTL()
+}
+
+// SetTTL changes the time-to-live of the entries; it applies retroactively.
+func (s *Store[V]) SetTTL(ttl time.Duration) {
+	s.s.SetTTL(ttl)
+}
+
+// PurgeExpired removes all expired entries now and returns the number removed.
+func (s *Store[V]) PurgeExpired() int {
+	return s.s.PurgeExpired()
+}
+
+// RunCleanup runs the background cleanup sweep now and returns how many expired entries it removed
+// and how many entries remain.
+func (s *Store[V]) RunCleanup() (removed, remaining int) {
+	return s.s.RunCleanup()
+}
+
+// Clear removes all entries and returns the number removed.
+func (s *Store[V]) Clear() int {
+	return s.s.Clear()
+}
+
+// Close removes all entries and stops the cleanup goroutine, returning the number of entries removed.
+// Later writes store nothing.
+func (s *Store[V]) Close() int {
+	return s.s.Close()
+}
+
+// Cache is the interface of a cached function and its most common management methods.
+//
+// Both Handle and ComparableHandle implement it. Store a Cache in a struct field instead of the concrete
+// handle type to swap the cached function for a mock in tests. Use NewCachedFunction for the plain function.
+type Cache[K any, V any] interface {
+	// Call is the cached function.
+	Call(arg K) (V, error)
+	// Invalidate removes the cached entry for arg, so the next call recomputes it.
+	Invalidate(arg K) error
+	// Contains reports whether a valid entry is cached for arg.
+	Contains(arg K) bool
+	// Clear removes all entries and returns how many were removed.
+	Clear() int
+}
+
+var (
+	_ Cache[int, int] = (*Handle[int, int])(nil)
+	_ Cache[int, int] = (*ComparableHandle[int, int])(nil)
+)
+
+// NewCache is like NewHandle, but returns the handle as a Cache interface.
+//
+// Example:
+//
+//	type Service struct {
+//		users fcache.Cache[int, User] // a mock in tests
+//	}
+//
+//	svc := &Service{users: fcache.NewCache(loadUser, nil, nil)}
+func NewCache[K any, V any](fn CachedFunc[K, V], opts *Config, hooks *hooks.Hooks) Cache[K, V] {
+	return core.NewCache(fn, opts, hooks)
+}
+
+// NewCachedFunctionComparable wraps a function whose argument type is comparable.
+//
+// It behaves like NewCachedFunction, but the comparable constraint catches non-cacheable argument
+// types (slices, maps, funcs) at compile time, and the argument value itself is used as the
+// cache key, skipping JSON encoding and hashing. Use NewCachedFunction for other argument types.
+//
+// Example:
+//
+//	cachedFetch := fcache.NewCachedFunctionComparable(fetchDataFromRemote, nil, nil)
+func NewCachedFunctionComparable[K comparable, V any](fn CachedFunc[K, V], opts *Config, hooks *hooks.Hooks) CachedFunc[K, V] {
+	return core.NewCachedFunctionComparable(fn, opts, hooks)
+}
+
+// NewHandleComparable is like NewHandle for functions whose argument type is comparable.
+// See NewCachedFunctionComparable.
+func NewHandleComparable[K comparable, V any](fn CachedFunc[K, V], opts *Config, hooks *hooks.Hooks) *ComparableHandle[K, V] {
+	return core.NewComparableCache(fn, opts, hooks)
+}
+
+// NewTinyLFU returns a TinyLFU admission policy sized for a cache of the given capacity.
+//
+// It estimates access frequencies with a count-min sketch and only admits a new key into a full
+// cache if it is used more often than the entry it would evict. This protects hot entries from
+// scan-heavy workloads that flood the cache with keys that are never read again.
+// Each cache needs its own policy instance.
+//
+// Example:
+//
+//	cfg := &fcache.Config{Capacity: 1000, AdmissionPolicy: fcache.NewTinyLFU(1000)}
+func NewTinyLFU(capacity int) AdmissionPolicy {
+	return core.NewTinyLFU(capacity)
+}
+
+// WrapMethod wraps a method with the same caching layer as NewCachedFunction, binding it to recv.
+// Pass the method as a method expression, so no closure over the receiver is needed.
+// Each call creates a separate cache, so wrap a method once per receiver.
+//
+// Example:
+//
+//	fetch := fcache.WrapMethod(svc, (*Service).Fetch, nil, nil) // calls svc.Fetch(id)
+//	data, err := fetch(42)
+func WrapMethod[R any, K any, V any](recv R, method func(R, K) (V, error), opts *Config, hooks *hooks.Hooks) CachedFunc[K, V] {
+	return core.WrapMethod(recv, method, opts, hooks)
+}
+
+// Wrap2 wraps a function returning two values and an error with the same caching layer as NewCachedFunction.
+//
+// Both results are cached together under the argument's key and returned with the original
+// three-value signature. CloneFunc, ShouldCache, TagFunc and Compress are not supported for such functions.
+//
+// Example:
+//
+//	cachedLoad := fcache.Wrap2(loadUserAndAccount, nil, nil) // func(int) (User, Account, error)
+//	user, account, err := cachedLoad(42)
+func Wrap2[K any, V1 any, V2 any](fn func(K) (V1, V2, error), opts *Config, hooks *hooks.Hooks) func(K) (V1, V2, error) {
+	return core.Wrap2(fn, opts, hooks)
+}
+
+// WrapReader caches a function returning a stream, such as an HTTP response body.
+//
+// The stream is read into memory (up to maxBytes) and closed, the bytes are cached, and every caller
+// receives a fresh reader over them. Streams longer than maxBytes are passed through uncached.
+// The ShouldCache option is not supported for such functions.
+//
+// Example:
+//
+//	cachedGet := fcache.WrapReader(func(url string) (io.ReadCloser, error) {
+//		resp, err := http.Get(url)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return resp.Body, nil
+//	}, 1<<20, nil, nil)
+func WrapReader[K any](fn func(K) (io.ReadCloser, error), maxBytes int64, opts *Config, hooks *hooks.Hooks) func(K) (io.ReadCloser, error) {
+	return core.WrapReader(fn, maxBytes, opts, hooks)
+}
+
+// CheckKeyCollision builds the cache keys of sample arguments and reports the keys shared by
+// arguments that are not equal, mapped to those arguments. Run it in tests against representative
+// arguments to catch distinct arguments that would silently share a cache entry.
+//
+// Example:
+//
+//	collisions, err := fcache.CheckKeyCollision(samples...)
+//	if err != nil || len(collisions) > 0 {
+//		t.Errorf("key collisions: %v, %v", collisions, err)
+//	}
+func CheckKeyCollision[K any](args ...K) (map[string][]K, error) {
+	return core.CheckKeyCollision(args...)
+}
+
+// DiffKeys compares two key snapshots taken by Handle.SnapshotKeys and returns the keys that
+// were added and removed between them, sorted.
+//
+// Example:
+//
+//	before := handle.SnapshotKeys()
+//	// ... incident window ...
+//	added, removed := fcache.DiffKeys(before, handle.SnapshotKeys())
+func DiffKeys(before, after []string) (added, removed []string) {
+	return core.DiffKeys(before, after)
+}
@@ -0,0 +1,183 @@
+// Package httpcache provides an http.RoundTripper that caches GET responses with fcache.
+//
+// It lives in its own package so that the core fcache package does not depend on net/http.
+package httpcache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/osmike/fcache"
+)
+
+// requestKey identifies a cacheable request: its URL and the values of the configured key headers.
+type requestKey struct {
+	url     string
+	headers string
+}
+
+// cachedResponse is a fully buffered response that can be replayed to any number of callers.
+type cachedResponse struct {
+	status     string
+	statusCode int
+	proto      string
+	protoMajor int
+	protoMinor int
+	header     http.Header
+	body       []byte
+	noStore    bool
+}
+
+// cacheableStatus lists the status codes whose responses are cached.
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusGone:                 true,
+}
+
+// Transport is an http.RoundTripper caching the responses to GET requests.
+//
+// Responses are keyed by the request URL and the values of the key headers given to NewTransport.
+// Requests with other methods, and requests or responses carrying Cache-Control: no-store, bypass
+// the cache; responses with non-cacheable status codes are returned but not cached. Concurrent
+// requests for the same key are deduplicated into a single round trip.
+//
+// The shared round trip is not tied to the context of any one request: a caller whose context is done
+// returns its context's error at once, while the round trip goes on for the other callers and the
+// cache, even if every caller gave up.
+type Transport struct {
+	next       http.RoundTripper
+	keyHeaders []string
+	cache      *fcache.ComparableHandle[requestKey, *cachedResponse]
+}
+
+// NewTransport returns a Transport sending requests through next (http.DefaultTransport if nil).
+//
+// opts and hooks configure the underlying cache as for fcache.NewCachedFunction, e.g. the TTL of
+// cached responses. The ShouldCache option is not supported, since it is used internally.
+// keyHeaders lists the request headers, such as Accept or Authorization, that select between
+// different responses for the same URL.
+func NewTransport(next http.RoundTripper, opts *fcache.Config, hooks *fcache.Hooks, keyHeaders ...string) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	var cfg fcache.Config
+	if opts != nil {
+		cfg = *opts
+	}
+	cfg.ShouldCache = func(_ requestKey, resp *cachedResponse, err error) bool {
+		return err == nil && !resp.noStore && cacheableStatus[resp.statusCode]
+	}
+
+	t := &Transport{next: next, keyHeaders: keyHeaders}
+	// Responses are produced per request through Do, so the wrapped function never runs
+	t.cache = fcache.NewHandleComparable(func(requestKey) (*cachedResponse, error) {
+		return nil, nil
+	}, &cfg, hooks)
+	return t
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || noStore(req.Header) {
+		return t.next.RoundTrip(req)
+	}
+
+	// the round trip may be shared with other callers, so the first caller cancelling must not end it
+	shared := req.WithContext(context.WithoutCancel(req.Context()))
+	key := t.key(req)
+	done := make(chan fetchResult, 1) // buffered, so the fetch never blocks on a caller that gave up
+	go func() {
+		resp, err := t.cache.Do(key, func() (*cachedResponse, error) {
+			return t.fetch(shared)
+		})
+		done <- fetchResult{resp, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return r.resp.response(req), nil
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+// fetchResult is the outcome of a possibly shared round trip.
+type fetchResult struct {
+	resp *cachedResponse
+	err  error
+}
+
+// key builds the cache key of req.
+func (t *Transport) key(req *http.Request) requestKey {
+	var b strings.Builder
+	for _, name := range t.keyHeaders {
+		b.WriteString(http.CanonicalHeaderKey(name))
+		b.WriteByte(':')
+		b.WriteString(strings.Join(req.Header.Values(name), ","))
+		b.WriteByte('\n')
+	}
+	return requestKey{url: req.URL.String(), headers: b.String()}
+}
+
+// fetch performs the round trip for req and buffers the response.
+func (t *Transport) fetch(req *http.Request) (*cachedResponse, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedResponse{
+		status:     resp.Status,
+		statusCode: resp.StatusCode,
+		proto:      resp.Proto,
+		protoMajor: resp.ProtoMajor,
+		protoMinor: resp.ProtoMinor,
+		header:     resp.Header,
+		body:       body,
+		noStore:    noStore(resp.Header),
+	}, nil
+}
+
+// response builds a fresh *http.Response for req from the buffered response.
+func (r *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        r.status,
+		StatusCode:    r.statusCode,
+		Proto:         r.proto,
+		ProtoMajor:    r.protoMajor,
+		ProtoMinor:    r.protoMinor,
+		Header:        r.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}
+}
+
+// noStore reports whether the Cache-Control header forbids storing the message.
+func noStore(h http.Header) bool {
+	for _, v := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+				return true
+			}
+		}
+	}
+	return false
+}
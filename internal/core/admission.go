@@ -0,0 +1,101 @@
+package core
+
+import "sync"
+
+// AdmissionPolicy decides whether a new entry may displace the eviction candidate when the cache is full.
+//
+// Keys are identified by a 64-bit hash. Record is called for every lookup, hit or miss, so the
+// policy can estimate access frequencies. Admit is called on insertion of a new key into a full
+// cache; returning false rejects the new entry and keeps the current one. The value is still
+// returned to the caller, it is just not cached.
+type AdmissionPolicy interface {
+	Record(hash uint64)                  // Registers an access to the key with the given hash.
+	Admit(candidate, victim uint64) bool // Reports whether candidate should replace victim.
+}
+
+// tinyLFU is a TinyLFU-style admission policy backed by a count-min frequency sketch.
+//
+// It keeps four rows of small saturating counters (max 15) and halves all counters once the
+// number of recorded accesses reaches ten times the sketch width, so old popularity fades.
+// A candidate is admitted only if its estimated frequency is higher than the victim's, which
+// keeps one-hit-wonders from a scan out of a cache full of frequently used entries.
+type tinyLFU struct {
+	mu        sync.Mutex
+	rows      [4][]uint8
+	mask      uint64
+	additions int
+	resetAt   int
+}
+
+// NewTinyLFU returns a TinyLFU admission policy sized for a cache of the given capacity.
+// Each cache needs its own policy instance.
+func NewTinyLFU(capacity int) AdmissionPolicy {
+	width := 64 // small sketches collide too often to tell hot keys from one-off ones
+	for width < capacity {
+		width <<= 1
+	}
+	t := &tinyLFU{
+		mask:    uint64(width - 1),
+		resetAt: 10 * width,
+	}
+	for i := range t.rows {
+		t.rows[i] = make([]uint8, width)
+	}
+	return t
+}
+
+// Record increments the frequency counters for hash and ages the sketch when due.
+func (t *tinyLFU) Record(hash uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.rows {
+		idx := t.index(hash, i)
+		if t.rows[i][idx] < 15 {
+			t.rows[i][idx]++
+		}
+	}
+	t.additions++
+	if t.additions >= t.resetAt {
+		t.reset()
+	}
+}
+
+// Admit reports whether the candidate is estimated to be accessed more often than the victim.
+func (t *tinyLFU) Admit(candidate, victim uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.estimate(candidate) > t.estimate(victim)
+}
+
+// estimate returns the minimum counter for hash across all rows.
+func (t *tinyLFU) estimate(hash uint64) uint8 {
+	est := uint8(15)
+	for i := range t.rows {
+		if c := t.rows[i][t.index(hash, i)]; c < est {
+			est = c
+		}
+	}
+	return est
+}
+
+// rowSeeds are odd multipliers giving each sketch row an independent index function.
+var rowSeeds = [4]uint64{0x9e3779b97f4a7c15, 0xbf58476d1ce4e5b9, 0x94d049bb133111eb, 0xd6e8feb86659fd93}
+
+// index returns the counter position of hash in row i.
+//
+// Each row multiplies the hash by its own seed and takes the high bits, so two keys sharing
+// a counter in one row rarely share it in the others. (Double hashing over the low bits made
+// keys that collided in one row collide in all of them.)
+func (t *tinyLFU) index(hash uint64, i int) uint64 {
+	return (hash * rowSeeds[i] >> 32) & t.mask
+}
+
+// reset halves all counters.
+func (t *tinyLFU) reset() {
+	for i := range t.rows {
+		for j := range t.rows[i] {
+			t.rows[i][j] >>= 1
+		}
+	}
+	t.additions /= 2
+}
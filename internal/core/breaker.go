@@ -0,0 +1,110 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for cache misses while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Circuit breaker states, as reported in Metrics.BreakerState.
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// circuitBreaker stops calls to a failing function for a cool-down period.
+//
+// It opens after threshold consecutive failures. Once the cool-down has elapsed it is half-open:
+// a single probe call is let through, which closes the breaker on success or reopens it on failure.
+// The breaker is shared by all keys, since consecutive failures usually mean the backend is down.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+
+	state    string    // breakerClosed, breakerOpen or breakerHalfOpen
+	failures int       // consecutive failures while closed
+	openedAt time.Time // when the breaker last opened
+	probing  bool      // a half-open probe call is in progress
+	trips    uint64    // number of times the breaker opened
+}
+
+// newCircuitBreaker creates a closed breaker.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, state: breakerClosed}
+}
+
+// allow reports whether a call may execute the function now.
+// In the half-open state only one probe is allowed until its result is recorded.
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerOpen && now.Sub(b.openedAt) >= b.cooldown {
+		b.state = breakerHalfOpen
+	}
+	switch b.state {
+	case breakerClosed:
+		return true
+	case breakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return false
+	}
+}
+
+// record reports the result of an allowed call.
+func (b *circuitBreaker) record(now time.Time, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerHalfOpen {
+		b.probing = false
+		if failed {
+			b.trip(now)
+		} else {
+			b.state = breakerClosed
+			b.failures = 0
+		}
+		return
+	}
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == breakerClosed && b.failures >= b.threshold {
+		b.trip(now)
+	}
+}
+
+// cancel releases the half-open probe of an allowed call that never reached the function, such as
+// one rejected by the concurrency limit, so the next call can probe. The state is unchanged.
+func (b *circuitBreaker) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerHalfOpen {
+		b.probing = false
+	}
+}
+
+// trip opens the breaker. The caller must hold mu.
+func (b *circuitBreaker) trip(now time.Time) {
+	b.state = breakerOpen
+	b.openedAt = now
+	b.failures = 0
+	b.trips++
+}
+
+// snapshot returns the current state and the number of trips.
+func (b *circuitBreaker) snapshot() (string, uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state, b.trips
+}
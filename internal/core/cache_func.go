@@ -30,7 +30,10 @@ package core
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/osmike/fcache/internal/lib/errs"
@@ -40,14 +43,35 @@ import (
 
 // Default settings for cache TTL and maximum size.
 const (
-	defaultTTL             = 5 * time.Minute
-	defaultMaxSize         = 1000
-	defaultCleanupInterval = 1 * time.Minute // Default interval for periodic cleanup
+	defaultTTL              = 5 * time.Minute
+	defaultMaxSize          = 1000
+	defaultCleanupInterval  = 1 * time.Minute  // Default interval for periodic cleanup, unless the TTL is shorter
+	defaultCleanupBatchSize = 1024             // Default number of deletions per cleanup lock acquisition
+	minCleanupInterval      = time.Millisecond // Shortest cleanup interval derived from the TTL
+
+	defaultAsyncHookWorkers   = 4   // Default number of async hook workers
+	defaultAsyncHookQueueSize = 256 // Default queue size per async hook worker
+
+	defaultPressureWindow    = 10 * time.Second // Default eviction pressure observation window
+	defaultPressureThreshold = 1.0              // Default ratio of evictions to hits that signals pressure
+
+	defaultBreakerCooldown = 30 * time.Second // Default time the circuit breaker stays open
+
+	defaultMemoryCheckInterval   = 1 * time.Second // Default interval between heap usage checks
+	defaultMaxBytesCheckInterval = 1 * time.Second // Default interval between byte budget sweeps
+	defaultOnFullTimeout         = 1 * time.Second // Default wait for space in a full cache under FullBlock
 )
 
 // ErrPanic is returned if a panic occurs in the cached function.
 var ErrPanic = errors.New("panic occurred in cached function")
 
+// ErrKeyGeneration is returned if no cache key can be built from the argument, e.g. because it
+// contains a func or channel. It wraps the underlying key error (such as ErrBuildKey).
+var ErrKeyGeneration = errors.New("cannot generate cache key for argument")
+
+// ErrExecutionTimeout is returned if the cached function does not return within Config.ExecutionTimeout.
+var ErrExecutionTimeout = errors.New("cached function execution timed out")
+
 // CachedFunc wraps a user-provided function with caching behavior.
 //
 // K is the input parameter type (must be serializable to a cache key).
@@ -56,35 +80,52 @@ var ErrPanic = errors.New("panic occurred in cached function")
 // The function must have the signature: func(arg K) (V, error)
 type CachedFunc[K any, V any] func(arg K) (V, error)
 
-// Config configures the cache behavior.
-//
-//   - TTL: Time-to-live for each cache entry (default: 5 minutes).
-//   - Capacity: Maximum number of cache entries (default: 1000).
-//   - CleanupInterval: Interval for periodic cleanup of expired entries (default: 1 minute).
-type Config struct {
-	TTL             time.Duration // Time-to-live for each cache entry.
-	Capacity        int           // Maximum number of cache entries.
-	CleanupInterval time.Duration // Interval for periodic cleanup (if implemented).
-}
-
 // inflightCall deduplicates concurrent calls for the same key.
 // It holds the result and error, and a wait group for synchronization.
 type inflightCall[V any] struct {
-	wg  sync.WaitGroup // Waits for the function execution to complete
-	val V              // Result value
-	err error          // Result error
+	wg      sync.WaitGroup // Waits for the function execution to complete
+	val     V              // Result value
+	err     error          // Result error
+	waiters int            // Calls that joined the leader, guarded by Cache.mu
 }
 
-// cache is the internal structure that manages the cache state and logic.
+// Cache manages the cache state and logic behind a cached function.
 //
 // It holds the user function, cache storage, in-flight deduplication map, configuration, and hooks.
-type cache[K any, V any] struct {
-	mu       sync.Mutex                  // Protects inflight and cache state
-	fn       CachedFunc[K, V]            // User-provided function to cache
-	store    *Storage[V]                 // Underlying storage for cached values
-	inflight map[string]*inflightCall[V] // Tracks in-flight requests for deduplication
-	cfg      *Config                     // Cache configuration
-	hooks    *hooks.Hooks                // Hooks for lifecycle events
+// Call is the cached function itself; the remaining methods control the cache at runtime.
+//
+// SK is the storage key type: string for keys built by keygen, or K itself for comparable-key caches.
+type Cache[K any, SK comparable, V any] struct {
+	mu          sync.Mutex                  // Protects inflight and cache state
+	fn          CachedFunc[K, V]            // User-provided function to cache
+	store       *Storage[SK, V]             // Underlying storage for cached values
+	inflight    map[SK]*inflightCall[V]     // Tracks in-flight requests for deduplication
+	cfg         *Config                     // Cache configuration
+	hooks       *hooks.Hooks                // Hooks for lifecycle events
+	clone       func(V) V                   // Optional copy of values handed to callers (Config.CloneFunc)
+	shouldCache func(K, V, error) bool      // Optional filter for results worth storing (Config.ShouldCache)
+	equal       func(V, V) bool             // Optional equality extending unchanged entries (Config.Equal)
+	bypassFn    func(K) bool                // Optional selector of arguments that skip the lookup (Config.BypassFunc)
+	tagFn       func(K, V) []string         // Optional tags of stored results (Config.TagFunc)
+	beforeEvict func(string, V) error       // Optional veto of capacity evictions (Config.BeforeEvict)
+	indexFn     func(V) string              // Optional secondary index attribute of results (Config.IndexFunc)
+	ttlFn       func(V) time.Duration       // Optional per-entry TTL of results (Config.TTLFunc)
+	copyHits    bool                        // Also copy values served from the store (Config.CopyOnGet)
+	async       *hooks.AsyncRunner          // Async hook workers (nil: hooks run inline)
+	hookTimeout time.Duration               // How long a hook may run before it is abandoned (0: no limit)
+	softTTL     time.Duration               // Age after which a hit triggers a background refresh (0: never)
+	bypass      atomic.Bool                 // Pass-through mode: skip the store, keep dedup (read from root)
+	keyFn       func(K) (SK, error)         // Builds the storage key for an argument, including the namespace
+	baseKeyFn   func(K) (SK, error)         // Builds the storage key without a namespace
+	prefix      string                      // Key prefix of the namespace ("" without a namespace)
+	codec       *compressor[V]              // Compresses stored values (nil: Config.Compress off)
+	metrics     metrics                     // Live counters exposed via Metrics
+	pressure    *pressureDetector           // Eviction pressure detector (nil: no OnPressure hook)
+	breaker     *circuitBreaker             // Circuit breaker (nil: Config.BreakerThreshold unset)
+	budget      *costBudget                 // Rate limit of MaxBytes checks on writes (nil: Config.MaxBytes unset)
+	limiter     *execLimiter                // Bounds simultaneous executions (nil: Config.MaxConcurrentExecutions unset)
+	root        *Cache[K, SK, V]            // Cache that owns the storage (itself, unless a Scoped view)
+	scopes      map[string]*Cache[K, SK, V] // Scoped views by namespace, guarded by mu
 }
 
 // NewCachedFunction returns a CachedFunc that wraps fn with caching logic.
@@ -94,137 +135,1068 @@ type cache[K any, V any] struct {
 //
 //   - fn: The function to cache. Must be of type func(K) (V, error).
 //   - opts: Optional cache configuration (TTL, capacity, cleanup interval). Pass nil for defaults.
+//     It is copied and never modified, so one Config may be shared by several caches.
 //   - h: Optional hooks for cache events. Pass nil if not needed.
 //
 // Returns a function with the same signature as fn, but with caching applied.
+//
+// It is equivalent to New(fn, WithConfig(opts), WithHooks(h)).
 func NewCachedFunction[K any, V any](fn CachedFunc[K, V], opts *Config, h *hooks.Hooks) CachedFunc[K, V] {
+	return New(fn, WithConfig(opts), WithHooks(h))
+}
+
+// NewCachedFunctionComparable is like NewCachedFunction for functions with a comparable argument type.
+// See NewComparableCache.
+func NewCachedFunctionComparable[K comparable, V any](fn CachedFunc[K, V], opts *Config, h *hooks.Hooks) CachedFunc[K, V] {
+	return NewComparableCache(fn, opts, h).Call
+}
+
+// NewCache wraps fn with caching logic and returns the Cache handle.
+//
+// Arguments and defaults are the same as for NewCachedFunction.
+// Use Call as the cached function and the other methods to manage the cache.
+func NewCache[K any, V any](fn CachedFunc[K, V], opts *Config, h *hooks.Hooks) *Cache[K, string, V] {
+	var builder keygen.Builder
+	if opts != nil {
+		builder.ContextKey = opts.ContextKeyFunc
+		builder.SortSlices = opts.NormalizeSlices
+		builder.Hasher = opts.KeyHasher
+		builder.Verbatim = opts.VerifyKeys
+		builder.Fields = opts.KeyFields
+		if len(opts.KeyFields) > 0 {
+			if err := keygen.CheckFields(reflect.TypeFor[K](), opts.KeyFields); err != nil {
+				panic(errs.NewError(ErrInvalidConfig, map[string]any{"field": "KeyFields", "conflict": err.Error()}))
+			}
+		}
+	}
+	return newCache(fn, opts, h, func(arg K) (string, error) {
+		return builder.BuildKey(arg)
+	})
+}
 
-	// Default config if nil
-	if opts == nil {
-		opts = &Config{}
+// NewComparableCache is like NewCache for functions with a comparable argument type.
+//
+// The argument itself is used as the storage key, skipping keygen entirely.
+// The constraint rejects non-keyable argument types (slices, maps, funcs) at compile time.
+// For interface argument types, a dynamic value that is not comparable is rejected with ErrBuildKey.
+func NewComparableCache[K comparable, V any](fn CachedFunc[K, V], opts *Config, h *hooks.Hooks) *Cache[K, K, V] {
+	keyFn := func(arg K) (K, error) {
+		return arg, nil
+	}
+	if reflect.TypeFor[K]().Kind() == reflect.Interface {
+		// map keys of interface type panic at runtime on non-comparable dynamic values
+		keyFn = func(arg K) (K, error) {
+			if v := reflect.ValueOf(arg); v.IsValid() && !v.Comparable() {
+				return arg, errs.NewError(keygen.ErrBuildKey, map[string]interface{}{
+					"operation": "building cache key",
+					"value":     arg,
+					"error":     "value is not comparable",
+				})
+			}
+			return arg, nil
+		}
+	}
+	return newCache(fn, opts, h, keyFn)
+}
+
+// newCache applies config defaults and builds a Cache using keyFn for key generation.
+// Defaults are applied to a copy of opts, so a Config may be shared by several caches.
+func newCache[K any, SK comparable, V any](fn CachedFunc[K, V], opts *Config, h *hooks.Hooks, keyFn func(K) (SK, error)) *Cache[K, SK, V] {
+	resolved := resolveConfig(opts)
+	opts = &resolved
+	// Default hooks if nil
+	if h == nil {
+		h = &hooks.Hooks{}
+	}
+
+	c := &Cache[K, SK, V]{
+		fn:          fn,
+		store:       NewStorage[SK, V](*opts),
+		inflight:    make(map[SK]*inflightCall[V]),
+		cfg:         opts,
+		hooks:       h,
+		clone:       typedFunc[func(V) V]("CloneFunc", opts.CloneFunc),
+		shouldCache: typedFunc[func(K, V, error) bool]("ShouldCache", opts.ShouldCache),
+		equal:       typedFunc[func(V, V) bool]("Equal", opts.Equal),
+		bypassFn:    typedFunc[func(K) bool]("BypassFunc", opts.BypassFunc),
+		tagFn:       typedFunc[func(K, V) []string]("TagFunc", opts.TagFunc),
+		beforeEvict: typedFunc[func(string, V) error]("BeforeEvict", opts.BeforeEvict),
+		indexFn:     typedFunc[func(V) string]("IndexFunc", opts.IndexFunc),
+		ttlFn:       typedFunc[func(V) time.Duration]("TTLFunc", opts.TTLFunc),
+		copyHits:    opts.CopyOnGet,
+		hookTimeout: opts.HookTimeout,
+		softTTL:     softTTL(opts),
+		keyFn:       namespaceKeyFn(keyFn, opts.Namespace),
+		baseKeyFn:   keyFn,
+		prefix:      namespacePrefix(opts.Namespace),
+	}
+	c.root = c
+	// a disabled cache starts in pass-through mode
+	c.bypass.Store(opts.Disabled)
+	c.metrics.off = opts.DisableMetrics
+	if opts.AsyncHooks {
+		c.async = hooks.NewAsyncRunner(h, opts.AsyncHookWorkers, opts.AsyncHookQueueSize)
+	}
+	if opts.CopyOnGet && c.clone == nil {
+		c.clone = shallowCopy[V]
+	}
+	if opts.Compress {
+		c.codec = newCompressor[V]()
+	}
+	if h.OnPressure != nil {
+		c.pressure = newPressureDetector(opts.PressureWindow, opts.PressureThreshold)
+	}
+	if opts.BreakerThreshold > 0 {
+		c.breaker = newCircuitBreaker(opts.BreakerThreshold, opts.BreakerCooldown)
+	}
+	if opts.MemoryPressureReclaim {
+		reclaimer := newMemoryReclaimer(opts.MemoryLimit)
+		c.store.addSweep(opts.MemoryCheckInterval, func() { c.store.reclaim(reclaimer) })
+	}
+	if opts.MaxConcurrentExecutions > 0 {
+		c.limiter = newExecLimiter(opts.MaxConcurrentExecutions, opts.ConcurrencyFailFast)
 	}
-	// Apply defaults
-	if opts.TTL <= 0 {
+	if opts.MaxBytes > 0 {
+		c.budget = &costBudget{interval: opts.MaxBytesCheckInterval}
+		c.store.costFn = estimatedCost(typedFunc[func(V) int64]("CostFunc", opts.CostFunc))
+		c.store.maxCost = opts.MaxBytes
+		c.store.addSweep(opts.MaxBytesCheckInterval, func() { c.store.shrinkToCost() })
+	}
+	c.store.onRemove = c.onRemove
+	if c.beforeEvict != nil {
+		c.store.beforeEvict = c.vetoEvict
+	}
+	if c.indexFn != nil {
+		c.store.indexFn = c.indexOf
+	}
+	if c.ttlFn != nil && opts.TTLPrecedence != TTLGlobal {
+		c.store.ttlFn = c.ttlOf
+	}
+	return c
+}
+
+// resolveConfig validates opts and returns a copy with defaults applied to unset fields; opts may be nil.
+// It panics with ErrInvalidConfig on contradictory settings (see Config.Validate).
+func resolveConfig(opts *Config) Config {
+	var resolved Config
+	if opts != nil {
+		resolved = *opts
+	}
+	opts = &resolved
+	if err := opts.Validate(); err != nil {
+		panic(err)
+	}
+	// Apply defaults. Settings of a feature are only defaulted when it is enabled, so the resolved
+	// configuration, as returned by Cache.Config, passes Validate and can be used to build another cache.
+	if opts.HardTTL > 0 {
+		opts.TTL = opts.HardTTL
+	}
+	if opts.TTL <= 0 && !opts.NoExpire {
 		opts.TTL = defaultTTL
 	}
-	if opts.Capacity <= 0 {
+	if opts.Capacity <= 0 && opts.Capacity != UnboundedCapacity {
 		opts.Capacity = defaultMaxSize
 	}
 	if opts.CleanupInterval <= 0 {
-		opts.CleanupInterval = defaultCleanupInterval
+		opts.CleanupInterval = cleanupIntervalFor(opts.TTL)
 	}
-	// Default hooks if nil
-	if h == nil {
-		h = &hooks.Hooks{}
+	if opts.ServeStaleOnError && opts.StaleTTL <= 0 {
+		opts.StaleTTL = opts.TTL
+	}
+	if opts.CleanupBatchSize <= 0 {
+		opts.CleanupBatchSize = defaultCleanupBatchSize
+	}
+	if opts.AsyncHooks && opts.AsyncHookWorkers <= 0 {
+		opts.AsyncHookWorkers = defaultAsyncHookWorkers
+	}
+	if opts.AsyncHooks && opts.AsyncHookQueueSize <= 0 {
+		opts.AsyncHookQueueSize = defaultAsyncHookQueueSize
+	}
+	if opts.PressureWindow <= 0 {
+		opts.PressureWindow = defaultPressureWindow
+	}
+	if opts.PressureThreshold <= 0 {
+		opts.PressureThreshold = defaultPressureThreshold
+	}
+	if opts.BreakerThreshold > 0 && opts.BreakerCooldown <= 0 {
+		opts.BreakerCooldown = defaultBreakerCooldown
+	}
+	if opts.MemoryPressureReclaim && opts.MemoryCheckInterval <= 0 {
+		opts.MemoryCheckInterval = defaultMemoryCheckInterval
+	}
+	if opts.OnFull == FullBlock && opts.OnFullTimeout <= 0 {
+		opts.OnFullTimeout = defaultOnFullTimeout
 	}
+	if opts.MaxBytes > 0 && opts.MaxBytesCheckInterval <= 0 {
+		opts.MaxBytesCheckInterval = defaultMaxBytesCheckInterval
+	}
+	return resolved
+}
+
+// softTTL returns the effective soft TTL of a configuration whose TTL is already set:
+// Config.SoftTTL if it is shorter than the (hard) TTL, and 0, disabling background refreshes, otherwise.
+func softTTL(opts *Config) time.Duration {
+	if opts.SoftTTL <= 0 || opts.SoftTTL >= opts.TTL {
+		return 0
+	}
+	return opts.SoftTTL
+}
 
-	c := &cache[K, V]{
-		fn:       fn,
-		store:    NewStorage[V](opts.TTL, opts.Capacity, opts.CleanupInterval),
-		inflight: make(map[string]*inflightCall[V]),
-		cfg:      opts,
-		hooks:    h,
+// cleanupIntervalFor returns the default cleanup interval for the given TTL: the TTL itself if it is
+// shorter than defaultCleanupInterval, so short-lived entries don't pile up between sweeps,
+// but at least minCleanupInterval, so tiny TTLs don't make the cleanup goroutine spin.
+func cleanupIntervalFor(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl >= defaultCleanupInterval {
+		return defaultCleanupInterval
 	}
+	return max(ttl, minCleanupInterval)
+}
 
-	return c.call
+// Config returns the configuration the cache runs with: the Config it was created with, with defaults
+// applied to unset fields and TTL and Capacity reflecting changes made by SetTTL and SetCapacity.
+// Settings of disabled features keep their zero values, so the result passes Validate.
+// The result is a copy, so modifying it does not affect the cache; function and interface fields such as
+// Clock still refer to the configured values. Scoped views return the configuration of the cache they
+// were created from.
+func (c *Cache[K, SK, V]) Config() Config {
+	cfg := *c.cfg
+	if !cfg.NoExpire {
+		cfg.TTL = c.store.TTL()
+	}
+	cfg.Capacity = c.store.Capacity()
+	return cfg
+}
+
+// Metrics returns a snapshot of the cache counters.
+func (c *Cache[K, SK, V]) Metrics() Metrics {
+	m := c.metrics.snapshot()
+	if c.breaker != nil {
+		m.BreakerState, m.BreakerTrips = c.breaker.snapshot()
+	}
+	return m
 }
 
-// call executes the cached function with deduplication, TTL, and LRU eviction.
+// PurgeExpired removes all expired entries immediately and returns how many were removed.
+//
+// It is intended for use with DisableBackgroundCleanup, letting the caller run expiry
+// sweeps on its own schedule. It is safe to call concurrently with other operations.
+func (c *Cache[K, SK, V]) PurgeExpired() int {
+	return c.store.PurgeExpired()
+}
+
+// RunCleanup runs the background cleanup sweep immediately, independent of its schedule, and returns
+// the number of expired entries removed and the number of entries remaining, e.g. for dashboards or to
+// check expiry deterministically in tests. Removals run OnRemove with ReasonTTL, as in the background.
+// On a Scoped view, both counts cover the whole cache. It is safe to call concurrently with other operations.
+func (c *Cache[K, SK, V]) RunCleanup() (removed, remaining int) {
+	return c.store.RunCleanup()
+}
+
+// Contains reports whether a valid cached entry exists for arg, without loading the value
+// or affecting LRU order. It returns false for expired entries and for arguments that cannot be keyed.
+func (c *Cache[K, SK, V]) Contains(arg K) bool {
+	key, err := c.keyFn(arg)
+	if err != nil {
+		return false
+	}
+	return c.store.Contains(key)
+}
+
+// TTLRemaining returns how long the cached entry for arg stays valid and whether it is valid, e.g. to
+// prefetch entries about to expire. With SlidingTTL the time restarts on every hit. It returns a negative
+// duration and false for expired entries, 0 and false for absent or unkeyable arguments, and the maximum
+// duration for entries of a NoExpire cache. Like Contains, it does not count as a hit or reorder entries.
+func (c *Cache[K, SK, V]) TTLRemaining(arg K) (time.Duration, bool) {
+	key, err := c.keyFn(arg)
+	if err != nil {
+		return 0, false
+	}
+	return c.store.TTLRemaining(key)
+}
+
+// GetMulti looks up the cached values for several arguments under a single storage lock.
+//
+// It returns the found values keyed by cache key, and the arguments that were not found
+// (missing, expired, cached with an error, or not keyable), so the caller can batch-compute the misses.
+// Hits update LRU order and run the OnGet hooks like regular hits. In pass-through mode
+// (SetBypass) every argument is reported as missing.
+func (c *Cache[K, SK, V]) GetMulti(args []K) (map[SK]V, []K) {
+	var missing []K
+	if c.root.bypass.Load() {
+		return map[SK]V{}, append(missing, args...)
+	}
+	keys := make([]SK, len(args))
+	valid := make([]bool, len(args))
+	lookup := make([]SK, 0, len(args))
+	for i, arg := range args {
+		key, err := c.keyFn(arg)
+		if err != nil {
+			continue
+		}
+		keys[i], valid[i] = key, true
+		lookup = append(lookup, key)
+	}
+	found := c.store.GetMulti(lookup)
+	if c.codec != nil {
+		for key, val := range found {
+			if plain, err := c.codec.decompress(val); err == nil {
+				found[key] = plain
+			} else {
+				delete(found, key)
+			}
+		}
+	}
+	for i, arg := range args {
+		if !valid[i] {
+			missing = append(missing, arg)
+			continue
+		}
+		val, ok := found[keys[i]]
+		if !ok {
+			missing = append(missing, arg)
+			continue
+		}
+		c.onHit(keys[i], arg, val)
+		found[keys[i]] = c.copyHit(val)
+	}
+	return found, missing
+}
+
+// Range calls f for each live cached entry, from most to least recently used, with its cache key,
+// value and age (time since it was stored, or last hit with SlidingTTL). Iteration stops early if f
+// returns false. Expired entries are skipped.
+//
+// Range reads the entries in place under the storage read lock, without copying the cache first,
+// so it is cheap for large caches; writers block until it returns. It does not count as hits or
+// affect LRU order. f must not call methods that modify the cache, such as Call or Evict,
+// or it will deadlock.
+func (c *Cache[K, SK, V]) Range(f func(key SK, val V, age time.Duration) bool) {
+	c.store.Range(func(key SK, val V, age time.Duration) bool {
+		plain, ok := c.decode(val)
+		if !ok {
+			return true
+		}
+		return f(key, c.copyHit(plain), age)
+	})
+}
+
+// Invalidate removes the cached entry for arg, if any, so the next call recomputes it, and runs OnRemove
+// with ReasonManual. It returns ErrKeyGeneration if no cache key can be built from arg.
+// A call for arg already in flight may still store its result.
+func (c *Cache[K, SK, V]) Invalidate(arg K) error {
+	key, err := c.keyFn(arg)
+	if err != nil {
+		return errs.NewError(ErrKeyGeneration, map[string]any{
+			"value": arg,
+			"error": err,
+		})
+	}
+	c.store.Delete(key)
+	return nil
+}
+
+// Set stores val as the cached result for arg without calling the function, and returns the value it
+// replaced and whether a valid entry existed, like a map swap, e.g. to close a replaced resource.
+// The value is copied by Config.CloneFunc if set, tagged by Config.TagFunc and stored with the usual TTL;
+// Config.ShouldCache is not consulted. Overwriting does not run OnRemove.
+//
+// It returns ErrKeyGeneration if no cache key can be built from arg, and ErrCacheFull if the value was
+// not stored under Config.OnFull. In pass-through mode (SetBypass) the store is not written.
+// A call for arg already in flight may still overwrite the value when it completes.
+func (c *Cache[K, SK, V]) Set(arg K, val V) (prev V, existed bool, err error) {
+	key, err := c.keyFn(arg)
+	if err != nil {
+		return prev, false, errs.NewError(ErrKeyGeneration, map[string]any{
+			"value": arg,
+			"error": err,
+		})
+	}
+	if c.root.bypass.Load() {
+		return prev, false, nil
+	}
+	old, existed, err := c.swap(key, c.cloneValue(val), nil, c.tagsFor(arg, val))
+	if err != nil {
+		return prev, false, fullError(key, err)
+	}
+	if !existed {
+		return prev, false, nil
+	}
+	if plain, ok := c.decode(old); ok {
+		return plain, true, nil
+	}
+	return prev, false, nil
+}
+
+// InvalidateFunc removes every entry whose key matches and returns the number removed,
+// e.g. all cached results for one user. On a namespaced cache or Scoped view, only the entries
+// of its namespace are considered, and match receives their keys without the namespace prefix,
+// so InvalidateFunc(func(string) bool { return true }) on a view clears one tenant.
+//
+// It scans all entries under the storage write lock, so it costs O(n) and blocks other cache
+// operations for the duration of the scan. Calls already in flight may still store their results.
+func (c *Cache[K, SK, V]) InvalidateFunc(match func(key SK) bool) int {
+	return c.store.DeleteFunc(func(key SK) bool {
+		rest, ok := c.unprefixed(key)
+		return ok && match(rest)
+	})
+}
+
+// InvalidateTag removes every entry tagged with tag by Config.TagFunc and returns the number removed.
+//
+// This is the surrogate-key pattern used by CDNs: tag results with the records they depend on
+// (e.g. "user:42") and invalidate them all at once when a record changes. The tag index is kept
+// consistent with evictions and expirations, so removed entries are never reported. On a Scoped view,
+// only the entries of its namespace are affected.
+func (c *Cache[K, SK, V]) InvalidateTag(tag string) int {
+	return c.store.DeleteTag(c.prefix + tag)
+}
+
+// BumpEpoch starts a new cache epoch and returns it: every entry stored so far becomes a miss at once,
+// in O(1), without walking the entries. The orphaned entries are treated as expired, so they are removed
+// by lookups and cleanup (reported to OnRemove with ReasonTTL) or evicted by capacity. It is the cheap way
+// to invalidate everything after a deploy or schema change; results of calls in flight may still be stored.
+// The epoch belongs to the storage, so a bump also applies to all Scoped views.
+func (c *Cache[K, SK, V]) BumpEpoch() uint64 {
+	return c.store.BumpEpoch()
+}
+
+// SetEpoch sets the cache epoch, e.g. to a schema version or deploy number shared by several processes.
+// Entries stored in any other epoch become misses, as with BumpEpoch; setting the current epoch again
+// changes nothing.
+func (c *Cache[K, SK, V]) SetEpoch(epoch uint64) {
+	c.store.SetEpoch(epoch)
+}
+
+// Clear removes all entries and returns the number removed, running OnRemove for each with
+// ReasonClear. On a namespaced cache or Scoped view, only the entries of its namespace are removed.
+// Calls already in flight may still store their results.
+func (c *Cache[K, SK, V]) Clear() int {
+	if c.prefix == "" {
+		return c.store.Clear()
+	}
+	return c.store.deleteFunc(func(key SK) bool {
+		_, ok := c.unprefixed(key)
+		return ok
+	}, hooks.ReasonClear)
+}
+
+// Evict removes up to n least recently used entries and returns their values, from least to
+// most recently used, e.g. to reclaim memory under pressure. On a namespaced cache or Scoped view,
+// only the entries of its namespace are evicted.
+//
+// The caller owns the returned values: manual evictions do not run the OnEvict or OnRemove hooks
+// and are not counted in Metrics.Evictions.
+func (c *Cache[K, SK, V]) Evict(n int) []V {
+	values := c.store.EvictFunc(n, func(key SK) bool {
+		_, ok := c.unprefixed(key)
+		return ok
+	})
+	for i, val := range values {
+		values[i], _ = c.decode(val)
+	}
+	return values
+}
+
+// SetCapacity changes the maximum number of cache entries at runtime (default: 1000 if <= 0,
+// unless UnboundedCapacity).
+// Shrinking the capacity immediately evicts least recently used entries down to the new limit.
+func (c *Cache[K, SK, V]) SetCapacity(capacity int) {
+	c.store.SetCapacity(capacity)
+}
+
+// SetTTL changes the time-to-live of cache entries at runtime (default: 5 minutes if <= 0).
+//
+// The new TTL applies retroactively: entries expire once their timestamp is older than the
+// current TTL, so shortening it may expire existing entries immediately.
+func (c *Cache[K, SK, V]) SetTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	c.store.SetTTL(ttl)
+}
+
+// SetBypass switches pass-through mode on or off at runtime.
+//
+// While bypassed, every call executes the underlying function: the store is neither read nor written,
+// but concurrent calls with the same argument are still deduplicated. Entries stored before the bypass
+// are kept and served again once it is switched off. The flag is atomic and cheap to check.
+// It is shared by the cache and its Scoped views: switching it on any of them switches all of them.
+func (c *Cache[K, SK, V]) SetBypass(bypass bool) {
+	c.root.bypass.Store(bypass)
+}
+
+// Call executes the cached function with deduplication, TTL, and LRU eviction.
 //
 // It ensures only one execution per unique key is in-flight at a time.
-// If a panic occurs in the user function, it is caught and returned as an error.
+// If a panic occurs in the user function, it is caught and returned as an error,
+// or re-panicked after bookkeeping if Config.PropagatePanics is set.
 //
 //   - arg: The input parameter for the cached function.
 //   - Returns: The result value and error from the function or cache.
-func (c *cache[K, V]) call(arg K) (val V, err error) {
+func (c *Cache[K, SK, V]) Call(arg K) (V, error) {
+	return c.call(arg, c.fn)
+}
+
+// Do is like Call, but computes a missing value with the given producer instead of the wrapped function.
+//
+// It shares the cache's storage, TTL, eviction and in-flight deduplication with Call, so the producer
+// can capture request-scoped state (e.g. a context) that the wrapped function cannot receive.
+// If several calls for the same argument are in flight at once, only the first one's producer runs
+// and the others receive its result: the first producer wins, whether it was passed to Do or is the
+// wrapped function of a concurrent Call.
+func (c *Cache[K, SK, V]) Do(arg K, fn func() (V, error)) (V, error) {
+	return c.call(arg, func(K) (V, error) {
+		return fn()
+	})
+}
+
+// call implements Call and Do, computing misses with fn.
+func (c *Cache[K, SK, V]) call(arg K, fn CachedFunc[K, V]) (V, error) {
 	var zero V
-	defer func() {
-		if r := recover(); r != nil {
-			var panicErr error
-			switch x := r.(type) {
-			case error:
-				panicErr = errs.NewError(ErrPanic, map[string]interface{}{
-					"panic": x,
-				})
-			case string:
-				panicErr = errs.NewError(ErrPanic, map[string]interface{}{
-					"panic": x,
-				})
-			default:
-				panicErr = errs.NewError(ErrPanic, map[string]interface{}{
-					"panic": fmt.Errorf("%v", x),
-				})
-			}
-			// Safely log the panic error if a logging hook is defined.
-			if c.hooks.LogError != nil {
-				defer func() { recover() }()
-				c.hooks.LogError(panicErr)
-			}
-			err = panicErr
-			val = zero // Reset value to zero value of type V
-		}
-	}()
-	key, err := keygen.BuildKey(arg)
+	key, err := c.keyFn(arg)
 	if err != nil {
-		return zero, err
+		if c.cfg.FallbackOnKeyError {
+			return c.callUncached(arg, fn)
+		}
+		return zero, errs.NewError(ErrKeyGeneration, map[string]any{
+			"value": arg,
+			"error": err,
+		})
 	}
 
-	// Fast path: check if value is already cached.
-	if val, found := c.store.Get(key); found {
-		// Run the OnGet hook if defined.
-		if c.hooks.OnGet != nil {
-			c.hooks.Run(c.hooks.OnGet, arg)
+	bypass := c.root.bypass.Load()
+	// A forced refresh (Config.BypassFunc) skips the lookup, but still stores its result.
+	refresh := c.bypassFn != nil && c.bypassFn(arg)
+
+	// Fast path: check if value is already cached (skipped in pass-through mode and for forced refreshes).
+	if !bypass && !refresh {
+		if val, cachedErr, age, found := c.load(key); found {
+			c.onHit(key, arg, val)
+			if c.softTTL > 0 && age > c.softTTL {
+				// stale but within the hard TTL: serve it and refresh in the background
+				c.refreshAsync(key, arg, fn)
+			}
+			return c.copyHit(val), cachedErr
 		}
-		return val, nil
 	}
+	return c.compute(key, arg, fn, bypass, !bypass && !refresh, c.cfg.PropagatePanics)
+}
+
+// refreshAsync recomputes the entry for key in a new goroutine, unless a computation for it is
+// already in flight or the circuit breaker is open. The in-flight marker is set before returning,
+// so concurrent stale hits start a single refresh. Errors leave the stale entry in place until it
+// expires, and panics are never propagated.
+func (c *Cache[K, SK, V]) refreshAsync(key SK, arg K, fn CachedFunc[K, V]) {
+	c.mu.Lock()
+	if _, busy := c.inflight[key]; busy || (c.breaker != nil && !c.breaker.allow(time.Now())) {
+		c.mu.Unlock()
+		return
+	}
+	c.metrics.add(&c.metrics.misses, 1)
+	ic := &inflightCall[V]{}
+	ic.wg.Add(1)
+	c.inflight[key] = ic
+	c.mu.Unlock()
+	go c.lead(key, arg, fn, ic, false, false)
+}
 
+// compute implements a miss of call: it joins a computation for key already in flight, or runs fn
+// and stores the result unless bypass is set.
+//
+// With recheck set, the store is checked again under c.mu before leading: a leader stores its result
+// before releasing its in-flight marker, so a caller whose lookup missed just before that store and
+// that finds no marker anymore is served the stored result instead of running fn a second time.
+func (c *Cache[K, SK, V]) compute(key SK, arg K, fn CachedFunc[K, V], bypass, recheck, propagate bool) (V, error) {
+	var zero V
 	c.mu.Lock()
-	// Check if another goroutine is already computing this key.
-	if ic, ok := c.inflight[key]; ok {
+	if recheck && c.inflight[key] == nil && c.store.Contains(key) {
+		c.mu.Unlock()
+		if val, cachedErr, _, found := c.load(key); found {
+			c.onHit(key, arg, val)
+			return c.copyHit(val), cachedErr
+		}
+		// removed meanwhile, or not decodable: compute it after all
+		return c.compute(key, arg, fn, bypass, false, propagate)
+	}
+	// Check if another goroutine is already computing this key (unless Config.DisableDedup).
+	if ic, ok := c.inflight[key]; ok && !c.cfg.DisableDedup {
+		ic.waiters++
+		waiters := ic.waiters
 		c.mu.Unlock()
+		c.metrics.add(&c.metrics.deduplicated, 1)
+		if c.hooks.OnInflightJoin != nil {
+			c.runHook(key, c.hooks.OnInflightJoin, hooks.InflightJoinEvent{Key: keyString(key), Arg: arg, Waiters: waiters})
+		}
 		ic.wg.Wait()
-		return ic.val, ic.err
+		return c.cloneValue(ic.val), ic.err
 	}
 
-	// Mark this key as in-flight.
+	// Fail fast while the circuit breaker is open.
+	if c.breaker != nil && !c.breaker.allow(time.Now()) {
+		c.mu.Unlock()
+		if c.cfg.ServeStaleOnError && !bypass {
+			if stale, ok := c.loadStale(key); ok {
+				c.metrics.add(&c.metrics.staleServes, 1)
+				return c.cloneValue(stale), nil
+			}
+		}
+		return zero, errs.NewError(ErrCircuitOpen, map[string]any{"key": keyString(key)})
+	}
+
+	// Mark this key as in-flight; without deduplication, the call runs on its own and is never joined.
+	c.metrics.add(&c.metrics.misses, 1)
 	ic := &inflightCall[V]{}
 	ic.wg.Add(1)
-	c.inflight[key] = ic
+	if !c.cfg.DisableDedup {
+		c.inflight[key] = ic
+	}
 	c.mu.Unlock()
+	return c.lead(key, arg, fn, ic, bypass, propagate)
+}
 
+// lead runs fn as the leader of the in-flight call ic registered for key, stores the result unless
+// bypass is set, and hands it to the waiters. With propagate set, a panic of fn is re-panicked
+// (Config.PropagatePanics).
+func (c *Cache[K, SK, V]) lead(key SK, arg K, fn CachedFunc[K, V], ic *inflightCall[V], bypass, propagate bool) (V, error) {
+	var zero V
 	// Run the OnExecute hook if defined.
 	if c.hooks.OnExecute != nil {
-		c.hooks.Run(c.hooks.OnExecute, arg)
+		c.runHook(key, c.hooks.OnExecute, arg)
+	}
+	if c.hooks.OnExecuteContext != nil {
+		c.runHookContext(c.hooks.OnExecuteContext, hooks.HookContext{Key: keyString(key), Arg: arg})
 	}
 	// Call the underlying function outside the lock.
-	val, err = c.fn(arg)
-	// Run the OnDone hook if defined.
-	if c.hooks.OnDone != nil {
-		c.hooks.Run(c.hooks.OnDone, arg)
+	start := time.Now()
+	val, recovered, err := c.executeRetry(fn, arg)
+	c.metrics.record(time.Since(start))
+	if c.breaker != nil {
+		if errors.Is(err, ErrConcurrencyLimit) {
+			// a call rejected by the concurrency limit never reached the backend
+			c.breaker.cancel()
+		} else {
+			c.breaker.record(time.Now(), err != nil)
+		}
+	}
+
+	// Store a successful result before releasing the in-flight marker, so callers arriving
+	// after the release find it in the store.
+	stored := !bypass && c.cacheable(arg, val, recovered, err)
+	var fullErr error // the result is valid but the cache had no room for it (Config.OnFull)
+	extended := false // an unchanged result only extended the existing entry (Config.Equal)
+	if stored {
+		if err == nil && c.extend(key, val) {
+			extended = true
+		} else if setErr := c.save(key, val, err, c.tagsFor(arg, val)); setErr != nil {
+			stored = false
+			if err == nil {
+				fullErr = fullError(key, setErr)
+			}
+		}
+	}
+
+	// Answer a failure with the last good value, if one is kept (Config.ServeStaleOnError).
+	var stale V
+	served := false
+	if err != nil && !stored && !bypass && c.cfg.ServeStaleOnError && (recovered == nil || !propagate) {
+		stale, served = c.loadStale(key)
 	}
 
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	// Remove in-flight marker.
-	delete(c.inflight, key)
+	// Remove in-flight marker, unless ic was never registered (Config.DisableDedup).
+	if c.inflight[key] == ic {
+		delete(c.inflight, key)
+	}
 	// Notify waiters with result.
 	ic.val = val
 	ic.err = err
+	if fullErr != nil {
+		ic.err = fullErr
+	}
+	if served {
+		ic.val, ic.err = stale, nil
+		c.metrics.add(&c.metrics.staleServes, uint64(1+ic.waiters))
+	}
 	ic.wg.Done()
+	c.mu.Unlock()
+
+	// The remaining hooks run without any cache lock held and after the in-flight marker is
+	// released, so they may call back into the cache, even with the same argument.
+	// Run the OnDone hook if defined.
+	if c.hooks.OnDone != nil {
+		c.runHook(key, c.hooks.OnDone, arg)
+	}
+	if c.hooks.OnDoneContext != nil {
+		c.runHookContext(c.hooks.OnDoneContext, hooks.HookContext{Key: keyString(key), Arg: arg, Value: val, Err: err})
+	}
 
 	if err != nil {
-		// If the function returned an error, we do not cache it.
-		// Log the error if a logging hook is defined.
-		if c.hooks.LogError != nil {
-			c.hooks.LogError(err)
+		// Unless Config.CacheOnError kept a partial result, errors are not cached.
+		// Report it to OnError, and to LogError for backward compatibility.
+		if c.hooks.OnError != nil {
+			c.runHookContext(c.hooks.OnError, hooks.HookContext{Key: keyString(key), Arg: arg, Err: err})
+		}
+		c.hooks.LogErrorSafe(err)
+		if recovered != nil && propagate {
+			// Waiters already received ErrPanic; the leader crashes loudly with the original value.
+			panic(recovered)
+		}
+		if served {
+			return c.cloneValue(stale), nil
+		}
+		if !stored {
+			return zero, err
 		}
-		return zero, err
 	}
 
-	// Store successful result in cache.
-	c.store.Set(key, val)
+	if !stored {
+		return c.cloneValue(val), fullErr
+	}
+	if extended {
+		return c.cloneValue(val), nil
+	}
+
 	if c.hooks.OnSet != nil {
-		c.hooks.Run(c.hooks.OnSet, arg)
+		c.runHook(key, c.hooks.OnSet, arg)
+	}
+	if c.hooks.OnSetContext != nil {
+		c.runHookContext(c.hooks.OnSetContext, hooks.HookContext{Key: keyString(key), Arg: arg, Value: val})
+	}
+	return c.cloneValue(val), err
+}
+
+// callUncached runs fn for an argument that cannot be keyed (Config.FallbackOnKeyError),
+// without storage or deduplication. Panics are handled as for cached calls.
+func (c *Cache[K, SK, V]) callUncached(arg K, fn CachedFunc[K, V]) (V, error) {
+	c.metrics.add(&c.metrics.misses, 1)
+	val, recovered, err := c.executeRetry(fn, arg)
+	if err != nil {
+		if c.hooks.OnError != nil {
+			c.runHookContext(c.hooks.OnError, hooks.HookContext{Arg: arg, Err: err})
+		}
+		c.hooks.LogErrorSafe(err)
+		if recovered != nil && c.cfg.PropagatePanics {
+			panic(recovered)
+		}
+		var zero V
+		return zero, err
 	}
 	return val, nil
 }
+
+// cacheable reports whether a function result may be stored: successful results, and with
+// Config.CacheOnError non-zero values returned with an error, provided ShouldCache accepts them.
+// Panics are never cached.
+func (c *Cache[K, SK, V]) cacheable(arg K, val V, recovered any, err error) bool {
+	if err != nil && (!c.cfg.CacheOnError || recovered != nil || reflect.ValueOf(&val).Elem().IsZero()) {
+		return false
+	}
+	return c.shouldCache == nil || c.shouldCache(arg, val, err)
+}
+
+// tagsFor returns the tags of a result from Config.TagFunc, namespaced like keys so
+// InvalidateTag on a Scoped view only affects its own namespace.
+func (c *Cache[K, SK, V]) tagsFor(arg K, val V) []string {
+	if c.tagFn == nil {
+		return nil
+	}
+	tags := c.tagFn(arg, val)
+	if c.prefix == "" {
+		return tags
+	}
+	prefixed := make([]string, len(tags))
+	for i, t := range tags {
+		prefixed[i] = c.prefix + t
+	}
+	return prefixed
+}
+
+// load reads a value, the error stored with it (Config.CacheOnError) and its age from the store,
+// decompressing the value if Config.Compress is set.
+// A value that fails to decompress is treated as a miss.
+func (c *Cache[K, SK, V]) load(key SK) (V, error, time.Duration, bool) {
+	val, err, age, found := c.store.GetWithAge(key)
+	if !found {
+		return val, nil, 0, false
+	}
+	plain, ok := c.decode(val)
+	return plain, err, age, ok
+}
+
+// loadStale reads the last good value of key, even if expired, decompressing it if Config.Compress is set.
+func (c *Cache[K, SK, V]) loadStale(key SK) (V, bool) {
+	val, found := c.store.GetStale(key)
+	if !found {
+		return val, false
+	}
+	return c.decode(val)
+}
+
+// decode decompresses a stored value if Config.Compress is set.
+// It reports false if the value fails to decompress.
+func (c *Cache[K, SK, V]) decode(val V) (V, bool) {
+	if c.codec == nil {
+		return val, true
+	}
+	plain, err := c.codec.decompress(val)
+	if err != nil {
+		var zero V
+		return zero, false
+	}
+	return plain, true
+}
+
+// save writes a value, its error (nil unless Config.CacheOnError) and tags to the store,
+// compressing the value if Config.Compress is set. It returns ErrCacheFull if the value was not
+// stored under Config.OnFull.
+func (c *Cache[K, SK, V]) save(key SK, val V, err error, tags []string) error {
+	_, _, setErr := c.swap(key, val, err, tags)
+	return setErr
+}
+
+// swap is like save, but also returns the replaced value, still compressed, and whether it existed.
+func (c *Cache[K, SK, V]) swap(key SK, val V, err error, tags []string) (V, bool, error) {
+	if c.codec != nil {
+		compressed, before, after := c.codec.compress(val)
+		c.metrics.add(&c.metrics.uncompressedBytes, uint64(before))
+		c.metrics.add(&c.metrics.compressedBytes, uint64(after))
+		val = compressed
+	}
+	prev, existed, setErr := c.store.SwapEntry(key, val, err, tags)
+	if setErr != nil {
+		return prev, existed, setErr
+	}
+	if c.budget != nil && c.budget.due(c.store.clock.Now()) {
+		c.store.shrinkToCost()
+	}
+	return prev, existed, nil
+}
+
+// extend restarts the TTL of the entry for key instead of replacing it, if its value equals val by
+// Config.Equal, and reports whether it did. The entry may be expired but still kept (Config.ServeStaleOnError).
+func (c *Cache[K, SK, V]) extend(key SK, val V) bool {
+	if c.equal == nil {
+		return false
+	}
+	return c.store.Extend(key, func(stored V) bool {
+		old, ok := c.decode(stored)
+		return ok && c.equal(old, val)
+	})
+}
+
+// vetoEvict runs Config.BeforeEvict for the storage's eviction candidate; an error keeps the entry.
+func (c *Cache[K, SK, V]) vetoEvict(key SK, val V) error {
+	plain, ok := c.decode(val)
+	if !ok {
+		return nil
+	}
+	return c.beforeEvict(keyString(key), plain)
+}
+
+// fullError returns the ErrCacheFull error of a result the store refused for key with setErr,
+// carrying the Config.BeforeEvict error that prevented an eviction, if any.
+func fullError[SK comparable](key SK, setErr error) error {
+	fields := map[string]any{"key": keyString(key)}
+	var e *errs.Error
+	if errors.As(setErr, &e) && e.Fields["error"] != nil {
+		fields["error"] = e.Fields["error"]
+	}
+	return errs.NewError(ErrCacheFull, fields)
+}
+
+// onRemove runs the OnRemove hook for a removed entry; capacity evictions are also passed to onEvict.
+func (c *Cache[K, SK, V]) onRemove(key SK, val V, reason hooks.RemoveReason) {
+	if reason == hooks.ReasonCapacity {
+		c.onEvict(key, val)
+	}
+	if c.hooks.OnRemove != nil {
+		if plain, ok := c.decode(val); ok {
+			c.runHookContext(c.hooks.OnRemove, hooks.HookContext{Key: keyString(key), Value: plain, Reason: reason})
+		}
+	}
+}
+
+// onEvict records a capacity eviction, runs the OnEvict hook and reports eviction pressure.
+func (c *Cache[K, SK, V]) onEvict(key SK, val V) {
+	c.metrics.add(&c.metrics.evictions, 1)
+	if c.hooks.OnEvict != nil {
+		if plain, ok := c.decode(val); ok {
+			c.runHookContext(c.hooks.OnEvict, hooks.HookContext{Key: keyString(key), Value: plain})
+		}
+	}
+	if c.pressure == nil {
+		return
+	}
+	if ev, fire := c.pressure.evict(time.Now()); fire {
+		c.runHook(key, c.hooks.OnPressure, ev)
+	}
+}
+
+// onHit records a cache hit and runs the OnGet hooks.
+func (c *Cache[K, SK, V]) onHit(key SK, arg K, val V) {
+	c.metrics.add(&c.metrics.hits, 1)
+	if c.pressure != nil {
+		c.pressure.hit(time.Now())
+	}
+	// Run the OnGet hook if defined.
+	if c.hooks.OnGet != nil {
+		c.runHook(key, c.hooks.OnGet, arg)
+	}
+	if c.hooks.OnGetContext != nil {
+		c.runHookContext(c.hooks.OnGetContext, hooks.HookContext{Key: keyString(key), Arg: arg, Value: val})
+	}
+}
+
+// execute calls fn, converting a panic into an ErrPanic error.
+//
+// The recovered panic value is returned alongside the error so the caller can re-panic
+// once the in-flight bookkeeping is done. With Config.CaptureStack, the panicking
+// goroutine's stack trace is attached to the error.
+//
+// With Config.MaxConcurrentExecutions, fn runs only once a slot is free, and the slot is held
+// until fn returns, even if the caller stopped waiting for it after an ExecutionTimeout.
+func (c *Cache[K, SK, V]) execute(fn CachedFunc[K, V], arg K) (val V, recovered any, err error) {
+	if c.limiter != nil {
+		if !c.limiter.acquire() {
+			return val, nil, errs.NewError(ErrConcurrencyLimit, map[string]any{
+				"limit": c.cfg.MaxConcurrentExecutions,
+			})
+		}
+		defer c.limiter.release()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			var zero V
+			val = zero // Reset value to zero value of type V
+			recovered = r
+			var stack []byte
+			if c.cfg.CaptureStack {
+				stack = debug.Stack()
+			}
+			err = newPanicError(r, stack)
+		}
+	}()
+	val, err = fn(arg)
+	return val, nil, err
+}
+
+// executeTimeout is like execute, but gives up after Config.ExecutionTimeout, if set, and returns
+// ErrExecutionTimeout. A timed-out fn keeps running in its own goroutine and its result is discarded.
+func (c *Cache[K, SK, V]) executeTimeout(fn CachedFunc[K, V], arg K) (V, any, error) {
+	timeout := c.cfg.ExecutionTimeout
+	if timeout <= 0 {
+		return c.execute(fn, arg)
+	}
+
+	type result struct {
+		val       V
+		recovered any
+		err       error
+	}
+	// buffered, so a late fn does not block forever once nobody is waiting for it
+	done := make(chan result, 1)
+	go func() {
+		val, recovered, err := c.execute(fn, arg)
+		done <- result{val, recovered, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.val, r.recovered, r.err
+	case <-timer.C:
+		var zero V
+		return zero, nil, errs.NewError(ErrExecutionTimeout, map[string]any{
+			"timeout": timeout,
+		})
+	}
+}
+
+// newPanicError wraps a recovered panic value into an ErrPanic error.
+// A non-empty stack is added under the "stack" field.
+func newPanicError(r any, stack []byte) error {
+	fields := make(map[string]interface{}, 2)
+	switch x := r.(type) {
+	case error:
+		fields["panic"] = x
+	case string:
+		fields["panic"] = x
+	default:
+		fields["panic"] = fmt.Errorf("%v", x)
+	}
+	if len(stack) > 0 {
+		fields["stack"] = string(stack)
+	}
+	return errs.NewError(ErrPanic, fields)
+}
+
+// runHook runs an argument hook inline, or on the async hook workers if Config.AsyncHooks is set,
+// abandoning it after Config.HookTimeout.
+func (c *Cache[K, SK, V]) runHook(key SK, fn hooks.HookFunc, arg any) {
+	if c.async != nil {
+		c.async.Go(keyString(key), func() { c.hooks.RunTimeout(fn, arg, c.hookTimeout) })
+		return
+	}
+	c.hooks.RunTimeout(fn, arg, c.hookTimeout)
+}
+
+// runHookContext runs a context hook inline, or on the async hook workers if Config.AsyncHooks is set,
+// abandoning it after Config.HookTimeout.
+func (c *Cache[K, SK, V]) runHookContext(fn hooks.HookContextFunc, hc hooks.HookContext) {
+	if c.async != nil {
+		c.async.Go(hc.Key, func() { c.hooks.RunContextTimeout(fn, hc, c.hookTimeout) })
+		return
+	}
+	c.hooks.RunContextTimeout(fn, hc, c.hookTimeout)
+}
+
+// keyString returns the string form of a storage key for hooks and async dispatch.
+func keyString[SK comparable](key SK) string {
+	if s, ok := any(key).(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
+
+// copyHit returns a copy of a value served from the store if Config.CopyOnGet is set.
+func (c *Cache[K, SK, V]) copyHit(val V) V {
+	if !c.copyHits {
+		return val
+	}
+	return c.cloneValue(val)
+}
+
+// shallowCopy copies the top level of slice and map values, so appending to or assigning
+// into the copy does not affect the original. Other values are returned as is.
+func shallowCopy[V any](val V) V {
+	rv := reflect.ValueOf(&val).Elem()
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.IsNil() {
+			return val
+		}
+		cp := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		reflect.Copy(cp, rv)
+		rv.Set(cp)
+	case reflect.Map:
+		if rv.IsNil() {
+			return val
+		}
+		cp := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), iter.Value())
+		}
+		rv.Set(cp)
+	}
+	return val
+}
+
+// cloneValue returns a copy of val made by Config.CloneFunc, or val itself if no clone func is set.
+func (c *Cache[K, SK, V]) cloneValue(val V) V {
+	if c.clone == nil {
+		return val
+	}
+	return c.clone(val)
+}
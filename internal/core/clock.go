@@ -0,0 +1,44 @@
+package core
+
+import "time"
+
+// Clock is the source of time of the storage: entry timestamps, expiry checks and the ticker of
+// the background cleanup. Inject a fake via Config.Clock to test TTL behaviour and cleanup
+// deterministically instead of sleeping.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTicker returns a ticker delivering ticks every d, like time.NewTicker.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers periodic ticks from a Clock, like time.Ticker.
+type Ticker interface {
+	// C returns the channel on which the ticks are delivered.
+	C() <-chan time.Time
+	// Stop turns off the ticker. No more ticks are sent after Stop returns.
+	Stop()
+}
+
+// realClock is the default Clock, reading the system time.
+type realClock struct{}
+
+// Now returns time.Now().
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTicker returns a time.Ticker.
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+// realTicker adapts time.Ticker to the Ticker interface.
+type realTicker struct {
+	*time.Ticker
+}
+
+// C returns the ticker's channel.
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"context"
+
+	"github.com/osmike/fcache/internal/lib/hooks"
+)
+
+// NewCachedFunctionWithContext is like NewCachedFunction, but ties the lifetime of the cache to ctx:
+// once ctx is cancelled, the cache is closed (see Cache.Close). Use it for request- or job-scoped caches,
+// so short-lived caches don't leak their entries and cleanup goroutine.
+//
+// The returned function keeps working after cancellation, executing fn on every call without caching.
+func NewCachedFunctionWithContext[K any, V any](ctx context.Context, fn CachedFunc[K, V], opts *Config, h *hooks.Hooks) CachedFunc[K, V] {
+	c := NewCache(fn, opts, h)
+	context.AfterFunc(ctx, c.Close)
+	return c.Call
+}
+
+// Close releases the cache: it removes all entries, running OnRemove for each with ReasonClear,
+// stops the cleanup goroutine, and stops the async hook workers after they have run the hooks already
+// queued. The storage is shared with Scoped views, so closing any of them closes them all.
+//
+// The cache stays safe to use: calls still execute the function and are deduplicated, but results
+// are no longer stored, and later hooks run synchronously. Calling Close more than once is safe.
+func (c *Cache[K, SK, V]) Close() {
+	c.store.Close()
+	if c.async != nil {
+		c.async.Close()
+	}
+}
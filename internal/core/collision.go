@@ -0,0 +1,110 @@
+package core
+
+import (
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/osmike/fcache/internal/lib/errs"
+	"github.com/osmike/fcache/internal/lib/keygen"
+)
+
+// CheckKeyCollision builds the cache keys of sample arguments, as NewCachedFunction does with the
+// default configuration, and reports the keys shared by arguments that are not equal.
+//
+// The result maps each colliding key to its distinct arguments, in the order given. Arguments are
+// distinct unless deeply equal the way keys treat them: times, also in nested fields and behind pointers,
+// are equal if they are the same instant, whatever their location or monotonic reading, and a NaN
+// float equals another NaN. An empty map means no collisions among the samples. If a key
+// cannot be built for an argument, it returns ErrKeyGeneration with the argument in the "value" field.
+//
+// It is meant for tests run against representative arguments of complex types, where two different
+// arguments sharing a key would silently return each other's cached results.
+func CheckKeyCollision[K any](args ...K) (map[string][]K, error) {
+	byKey := make(map[string][]K)
+	for _, arg := range args {
+		key, err := keygen.BuildKey(arg)
+		if err != nil {
+			return nil, errs.NewError(ErrKeyGeneration, map[string]any{
+				"value": arg,
+				"error": err,
+			})
+		}
+		if !containsEqual(byKey[key], arg) {
+			byKey[key] = append(byKey[key], arg)
+		}
+	}
+	for key, group := range byKey {
+		if len(group) < 2 {
+			delete(byKey, key)
+		}
+	}
+	return byKey, nil
+}
+
+// containsEqual reports whether args contains a value equal to arg by keyEqual.
+func containsEqual[K any](args []K, arg K) bool {
+	for _, a := range args {
+		if keyEqual(reflect.ValueOf(&a).Elem(), reflect.ValueOf(&arg).Elem()) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyEqual is reflect.DeepEqual with the equalities the key builder applies: time.Time values compare
+// with Equal, and NaN floats are equal. Cycles need no tracking, since keys of cyclic values cannot
+// be built.
+func keyEqual(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	if a.Type() == reflect.TypeFor[time.Time]() && a.CanInterface() {
+		// unexported times are not part of the key and compare field by field below
+		return a.Interface().(time.Time).Equal(b.Interface().(time.Time))
+	}
+	switch a.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float() || math.IsNaN(a.Float()) && math.IsNaN(b.Float())
+	case reflect.Pointer, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return keyEqual(a.Elem(), b.Elem())
+	case reflect.Slice, reflect.Map:
+		if a.IsNil() != b.IsNil() || a.Len() != b.Len() {
+			return false
+		}
+		if a.Kind() == reflect.Map {
+			for iter := a.MapRange(); iter.Next(); {
+				bv := b.MapIndex(iter.Key())
+				if !bv.IsValid() || !keyEqual(iter.Value(), bv) {
+					return false
+				}
+			}
+			return true
+		}
+		fallthrough
+	case reflect.Array:
+		for i := range a.Len() {
+			if !keyEqual(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := range a.NumField() {
+			if !keyEqual(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Func:
+		return a.IsNil() && b.IsNil()
+	default:
+		return a.Equal(b)
+	}
+}
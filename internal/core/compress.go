@@ -0,0 +1,77 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// compressor gzip-compresses values of a []byte- or string-based type V.
+//
+// Compressed bytes are stored as a V of the same type, so the storage layer is unchanged;
+// values are decompressed transparently before they are returned to callers.
+type compressor[V any] struct {
+	typ      reflect.Type // V
+	isString bool         // V has string kind (otherwise a byte slice)
+}
+
+// newCompressor returns a compressor for V, or panics if V is not based on []byte or string.
+func newCompressor[V any]() *compressor[V] {
+	typ := reflect.TypeFor[V]()
+	switch {
+	case typ.Kind() == reflect.String:
+		return &compressor[V]{typ: typ, isString: true}
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
+		return &compressor[V]{typ: typ}
+	default:
+		panic(fmt.Sprintf("fcache: Config.Compress requires a []byte or string value type, got %s", typ))
+	}
+}
+
+// compress returns the compressed form of val along with the original and compressed sizes.
+func (c *compressor[V]) compress(val V) (V, int, int) {
+	raw := c.toBytes(val)
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(raw) // writes to a bytes.Buffer cannot fail
+	zw.Close()
+	return c.fromBytes(buf.Bytes()), len(raw), buf.Len()
+}
+
+// decompress restores a value produced by compress.
+func (c *compressor[V]) decompress(val V) (V, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(c.toBytes(val)))
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return c.fromBytes(raw), nil
+}
+
+// toBytes converts val to a byte slice.
+func (c *compressor[V]) toBytes(val V) []byte {
+	rv := reflect.ValueOf(&val).Elem()
+	if c.isString {
+		return []byte(rv.String())
+	}
+	return rv.Bytes()
+}
+
+// fromBytes converts a byte slice back to V.
+func (c *compressor[V]) fromBytes(b []byte) V {
+	var val V
+	rv := reflect.ValueOf(&val).Elem()
+	if c.isString {
+		rv.SetString(string(b))
+	} else {
+		rv.SetBytes(b)
+	}
+	return val
+}
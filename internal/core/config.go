@@ -0,0 +1,264 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/osmike/fcache/internal/lib/keygen"
+)
+
+// Config configures the cache behavior.
+//
+// Constructors copy the Config before applying defaults and never modify it, so unset fields stay zero
+// and one Config may be reused for several caches. Cache.Config reports the resolved values.
+//
+//   - TTL: Time-to-live for each cache entry (default: 5 minutes).
+//   - Capacity: Maximum number of cache entries (default: 1000 if <= 0). A capacity of 0 does not disable
+//     caching; use Disabled for that. UnboundedCapacity (-1) removes the count limit, e.g. to let MaxBytes
+//     alone bound the cache; without a MaxBytes or MemoryPressureReclaim budget, the cache then only shrinks
+//     by expiry and invalidation.
+//   - Disabled: If true, the cache starts in pass-through mode (see Cache.SetBypass): every call executes the
+//     function and nothing is stored, but concurrent calls with the same argument are still deduplicated.
+//     Use it to switch caching off by configuration without changing call sites.
+//   - DisableDedup: If true, every call that misses the store runs the function itself instead of joining a
+//     call for the same argument already in flight, for functions with side effects each caller must trigger.
+//     Results are still cached: each completed call stores its result, so the last one to complete stays.
+//     Hits are served from the store as usual (default: false).
+//   - CleanupInterval: Interval for periodic cleanup of expired entries (default: the TTL, between 1 millisecond
+//     and 1 minute, so entries of short-TTL caches are removed about as fast as they expire).
+//   - CleanupBatchSize: Maximum number of expired entries deleted per write lock acquisition during cleanup,
+//     bounding how long a sweep blocks other operations on a large cache (default: 1024).
+//   - NoExpire: If true, entries never expire and live until evicted by capacity, and no background
+//     cleanup runs. TTL, SoftTTL, HardTTL and SlidingTTL must not be set (see Validate). Use it for reference data that never changes in a process lifetime.
+//   - SoftTTL: If > 0 and shorter than the hard TTL, a hit on an entry older than SoftTTL still serves the
+//     cached value but starts a background refresh (one per key at a time, deduplicated with calls in flight),
+//     so hot entries are replaced before they expire. A failed refresh keeps the stale entry until the hard TTL.
+//   - HardTTL: The age after which an entry is never served and a call recomputes it synchronously.
+//     If > 0 it takes precedence over TTL; otherwise TTL is the hard TTL.
+//   - SlidingTTL: If true, every cache hit resets the entry's TTL clock (default: false, absolute expiration).
+//   - TTLFunc: Optional func(val V) time.Duration returning the TTL of a stored value, e.g. from the
+//     expiry of a token it holds. A duration <= 0 uses the global TTL. It runs under the storage lock on
+//     every store and must not call the cache. It conflicts with NoExpire, and panics at construction if it
+//     has the wrong type. Entries are then also kept in a heap ordered by expiry, so cleanup and full caches
+//     find the expired ones first, at O(log n) per store (and per hit with SlidingTTL).
+//   - TTLPrecedence: How a TTLFunc duration combines with the global TTL: TTLMin uses the shorter one,
+//     so a TTLFunc cannot extend entries past the global TTL by accident, TTLPerEntry the TTLFunc duration,
+//     and TTLGlobal ignores the TTLFunc. It is resolved on every expiry check, so SetTTL applies to entries
+//     with their own TTL too (default: TTLMin).
+//   - DisableBackgroundCleanup: If true, no cleanup goroutine is started; expired entries are removed lazily on access or via PurgeExpired.
+//   - CloneFunc: Optional func(V) V that copies a result before it is handed to a caller.
+//   - ShouldCache: Optional func(arg K, val V, err error) bool consulted before a result is stored.
+//     If it returns false, the result is returned to the caller but not cached, so the next call recomputes it.
+//     It panics at construction if it has the wrong type.
+//   - BypassFunc: Optional func(arg K) bool consulted at the start of every call. If it returns true, the
+//     cached entry is ignored and the function recomputes the result, which is then stored as usual, e.g. for
+//     an argument carrying a "force refresh" flag. Concurrent calls are still deduplicated, so a forced call
+//     may share the result of a computation already in flight. It panics at construction if it has the wrong type.
+//   - CacheOnError: If true, a non-zero value returned together with an error (a degraded or partial result)
+//     is cached with its error, and hits replay both; the caller receives the value as well as the error.
+//     Zero values with an error and panics are never cached (default: false, errors are never cached).
+//   - Equal: Optional func(old, new V) bool. When a computed result equals the value already stored for its key
+//     (a soft TTL or BypassFunc refresh, or an expired entry kept by ServeStaleOnError), the entry's TTL is
+//     restarted instead of storing the result, and OnSet/OnSetContext don't fire, so slowly changing data does
+//     not churn downstream consumers. It runs under the storage lock and must not call the cache.
+//     It panics at construction if it has the wrong type.
+//   - ServeStaleOnError: If true, a call whose function fails (error, panic, timeout, or a miss rejected by the
+//     open circuit breaker) returns the last successfully cached value for its key with a nil error instead,
+//     if there is one, e.g. to ride out a backend outage. Panics re-raised by PropagatePanics are not masked.
+//     Expired entries are kept for StaleTTL past their TTL for this; they are still misses for lookups.
+//     Serves are counted in Metrics.StaleServes, and OnError and LogError still see the failure.
+//     Entries orphaned by BumpEpoch or SetEpoch are never served.
+//   - StaleTTL: How long past its TTL an expired entry is kept for ServeStaleOnError (default: the TTL).
+//   - ContextKeyFunc: Optional func deriving the key of a context.Context argument, e.g. from a tenant ID it
+//     carries, to partition the cache by it. By default all contexts share one placeholder key, since contexts
+//     are request-scoped: keying on a request ID or deadline would make every call a miss and fill the cache.
+//     Only extract stable values, and keep the result deterministic. Ignored by comparable-key caches.
+//     Fields of type context.Context in composite arguments are keyed the same way.
+//   - KeyFields: Names of the fields a struct argument (or pointer to one) is keyed by, in order, e.g. "ID" and
+//     "TenantID" of a request, ignoring volatile fields like timestamps or request IDs. Each field is keyed like
+//     an argument of its own. Construction panics with ErrInvalidConfig if the argument type lacks a named field
+//     or it is unexported; for interface argument types, calls fail with ErrKeyGeneration instead.
+//     Ignored by comparable-key caches.
+//   - NormalizeSlices: If true, a slice or array argument of ordered elements (integers other than bytes, floats, strings) is
+//     sorted before its key is built, so arguments representing sets, such as []int{2, 1} and []int{1, 2},
+//     share an entry. Only the top-level argument is sorted; other element types and nested slices keep
+//     their order. Ignored by comparable-key caches (default: false, order matters).
+//   - KeyHasher: Hash function and digest encoding for keys too long to be used as is (long strings,
+//     large structs, maps), e.g. base64 SHA-256 or a multihash to match the identifiers of a content-addressable
+//     store. Nil fields keep the default, hex-encoded SHA-256. Ignored by comparable-key caches.
+//   - VerifyKeys: If true, long keys are not hashed: the full encoding of the argument is the key, so the
+//     storage compares it on every lookup and two arguments with different encodings can never share an entry
+//     through a hash collision. This costs memory (each entry keeps its full key) and hashing of long keys on
+//     every lookup, and hooks and SnapshotKeys see the long keys. Arguments whose encodings are equal, such as
+//     Stringers printing the same text, still share an entry. It conflicts with KeyHasher, and is ignored by
+//     comparable-key caches, whose keys are the arguments themselves.
+//   - Namespace: Optional prefix of every cache key, isolating the keyspace (see Cache.Scoped for views
+//     over one storage with several namespaces). It requires string keys and panics with comparable-key caches.
+//   - TagFunc: Optional func(arg K, val V) []string returning tags for a stored result, e.g. the IDs of the
+//     records it was computed from. Cache.InvalidateTag removes all entries with a tag.
+//     It panics at construction if it has the wrong type.
+//   - IndexFunc: Optional func(val V) string returning a secondary attribute of a stored value, e.g. the
+//     natural key of an object cached by ID, for reverse lookups with Cache.LookupByIndex. An empty attribute
+//     leaves the value out of the index. It runs under the storage lock on every store and must not call the
+//     cache. It panics at construction if it has the wrong type.
+//   - FallbackOnKeyError: If true, a call whose argument cannot be keyed runs the function uncached,
+//     without deduplication, instead of failing with ErrKeyGeneration (default: false).
+//   - CopyOnGet: If true, values served from the store are copied too, not only in-flight results.
+//     Without a CloneFunc, slice and map values get a shallow copy.
+//   - PropagatePanics: If true, a panic in the cached function is re-panicked in the calling goroutine
+//     after bookkeeping (LogError, OnDone, waking waiters) instead of being returned as ErrPanic.
+//   - AsyncHooks: If true, lifecycle hooks run on a bounded pool of worker goroutines instead of inline.
+//     Hooks for the same key keep their order. LogError is still called for async hook errors and panics.
+//   - AsyncHookWorkers: Number of async hook workers (default: 4).
+//   - AsyncHookQueueSize: Queue size per async hook worker (default: 256). Hooks are dropped when it is full.
+//   - HookTimeout: If > 0, each hook runs in its own goroutine and the cache (or async worker) stops waiting
+//     for it after this long, reporting ErrHookTimeout to LogError; the hook itself is abandoned, not stopped.
+//     Default 0 runs hooks without a time limit.
+//   - Clock: Source of time for entry timestamps, expiry and the cleanup ticker (default: the system clock).
+//     Inject a fake clock to test TTL behaviour and background cleanup deterministically. Timeouts, latency metrics and the circuit breaker
+//     always use the system clock.
+//   - DisableMetrics: If true, the Metrics counters and latency statistics are not updated and stay at zero,
+//     saving the atomic increments on every call (circuit breaker state is still reported).
+//   - EvictionPolicy: Which entry a full cache evicts: EvictionLRU (default), EvictionFIFO (oldest insert;
+//     hits don't reorder entries), EvictionRandom (an arbitrary entry) or EvictionSLRU (segmented LRU: entries
+//     are protected from eviction after their second hit, so scans of one-off keys don't flush the hot set).
+//     It also applies to MaxBytes and MemoryPressureReclaim evictions; Cache.Evict always follows the usage list order.
+//   - OnFull: What storing a new key into a full cache does: FullEvict evicts an entry (default),
+//     FullReject keeps the cache unchanged, and FullBlock waits up to OnFullTimeout for an entry to be
+//     removed. Expired entries are always replaced first. If the result cannot be stored, the caller and
+//     its waiters still receive the computed value, with ErrCacheFull instead of a nil error.
+//   - OnFullTimeout: How long a store waits for space under FullBlock (default: 1 second). The in-flight
+//     call, and so every caller waiting for it, is held up meanwhile.
+//   - AdmissionPolicy: Optional filter deciding whether a new key may displace the eviction victim of a full cache,
+//     e.g. NewTinyLFU(capacity) to keep one-hit-wonders from a scan out of the cache (default: nil, always admit).
+//   - BeforeEvict: Optional func(key string, val V) error, called synchronously before an entry is evicted for
+//     capacity (including MaxBytes and MemoryPressureReclaim), e.g. to persist it in a write-back cache. An error
+//     keeps the entry and the next candidate in eviction order is tried; if every entry refuses, the new result is
+//     not stored and its callers receive ErrCacheFull carrying the last error, while shrinking evictions stop early
+//     and leave the cache over its limit. It runs under the storage write lock, so it blocks every other cache
+//     operation, and it must not call the cache or it deadlocks. It panics at construction if it has the wrong type.
+//   - Compress: If true, stored values are gzip-compressed and decompressed transparently on read.
+//     V must be []byte, string, or a type based on them; other types panic at construction (default: false).
+//   - PressureWindow: Observation window of the eviction pressure detector behind Hooks.OnPressure (default: 10 seconds).
+//   - PressureThreshold: Pressure is signalled when evictions in a window exceed this multiple of hits (default: 1).
+//   - CaptureStack: If true, the stack trace of a panic is recorded in the ErrPanic error under the "stack" field.
+//   - ExecutionTimeout: If positive, a call whose function does not return within it fails with
+//     ErrExecutionTimeout for the caller and all waiters, and the in-flight marker is cleared so a later
+//     call can retry. The function keeps running in the background; its late result is discarded (default: 0, no timeout).
+//   - BreakerThreshold: If positive, a circuit breaker opens after this many consecutive function failures
+//     (errors, panics, timeouts) across all keys. While open, cache misses fail fast with ErrCircuitOpen
+//     and hits are still served (default: 0, no breaker).
+//   - BreakerCooldown: How long the breaker stays open before a single probe call is let through;
+//     the probe closes it on success and reopens it on failure (default: 30 seconds).
+//   - MemoryPressureReclaim: If true, heap usage is checked in the background every MemoryCheckInterval
+//     (on Clock) while the cache holds entries, and a quarter of the entries (least recently used first)
+//     is evicted whenever the heap is above MemoryLimit, which must be set. Evicted entries are reported
+//     like capacity evictions. It needs the background cleanup (default: false).
+//   - MemoryLimit: Heap size in bytes (runtime.MemStats.HeapAlloc) above which entries are reclaimed.
+//   - MemoryCheckInterval: Time between heap checks, which briefly stop the world (default: 1 second).
+//   - MaxConcurrentExecutions: If positive, at most this many executions of the function run at once across
+//     all keys (and Scoped views), so a cold burst of distinct keys cannot overwhelm the backend. Excess
+//     executions wait for a slot; deduplication already limits each key to one execution (default: 0, unbounded).
+//   - ConcurrencyFailFast: If true, an execution over MaxConcurrentExecutions fails at once with
+//     ErrConcurrencyLimit instead of waiting. The error is not cached and does not trip the circuit breaker.
+//   - MaxBytes: If positive, the total cost of stored values is kept under this budget. Each value is
+//     measured once when it is stored, and the cache keeps the running total. At most once per
+//     MaxBytesCheckInterval, on writes and from a background sweep on the Clock, least recently used entries
+//     are evicted until the total is within the budget, so it may be briefly exceeded in between. The sweep
+//     does not run with DisableBackgroundCleanup. Evicted entries are reported like capacity evictions
+//     (default: 0, no budget).
+//   - CostFunc: Optional func(V) int64 returning the cost of a stored value in the unit of MaxBytes.
+//     Without it, sizes are estimated in bytes by reflection (see estimateSize): strings, slices, maps,
+//     structs and pointers are followed, shared memory is counted once per value, and allocator overhead
+//     is ignored, so the estimate is approximate but grows with the data. With Compress, the compressed
+//     value is measured. It runs under the storage lock on every store and must not call the cache; values
+//     mutated after they were stored are not measured again. It panics at construction if it has the wrong type.
+//   - MaxBytesCheckInterval: Minimum time between MaxBytes checks on writes, and the interval of the
+//     background sweep (default: 1 second).
+//   - Retry: Optional retries of failed calls with exponential backoff (see RetryPolicy). Each attempt
+//     gets its own ExecutionTimeout, and only the final result is cached or reported (default: no retries).
+//
+// # Shared results
+//
+// Concurrent callers deduplicated onto the same in-flight call all receive the same value.
+// When V is a pointer, map, or slice, a mutation by one caller is visible to every other caller
+// and to the cached entry. Set CloneFunc to a deep-copy function to give each caller of an
+// in-flight call its own copy, and CopyOnGet to extend this to cache hits, so a caller appending
+// to a returned slice cannot modify the cached entry. Without them, callers must treat returned
+// values as read-only.
+type Config struct {
+	TTL                      time.Duration                // Time-to-live for each cache entry.
+	Capacity                 int                          // Maximum number of cache entries.
+	Disabled                 bool                         // Never cache: start in pass-through mode.
+	DisableDedup             bool                         // Run the function for every miss instead of joining an in-flight call.
+	CleanupInterval          time.Duration                // Interval for periodic cleanup (if implemented).
+	CleanupBatchSize         int                          // Deletions per lock acquisition during cleanup.
+	NoExpire                 bool                         // Entries never expire (TTL ignored).
+	SoftTTL                  time.Duration                // Age after which hits trigger a background refresh.
+	HardTTL                  time.Duration                // Age after which entries are never served; overrides TTL.
+	SlidingTTL               bool                         // Refresh entry timestamp on every hit (sliding expiration).
+	TTLFunc                  any                          // func(V) time.Duration; per-entry TTL of stored values (nil: global TTL only).
+	TTLPrecedence            TTLPrecedence                // How TTLFunc durations combine with the global TTL.
+	DisableBackgroundCleanup bool                         // Never start the background cleanup goroutine.
+	CloneFunc                any                          // func(V) V; copies results handed to callers (nil: share values).
+	ShouldCache              any                          // func(K, V, error) bool; filters results worth storing (nil: store all).
+	BypassFunc               any                          // func(K) bool; forces a recompute for matching arguments (nil: none).
+	CacheOnError             bool                         // Cache non-zero values returned with an error, replaying both.
+	Equal                    any                          // func(V, V) bool; extends unchanged entries instead of replacing them (nil: always replace).
+	ServeStaleOnError        bool                         // Serve the last good value when the function fails.
+	StaleTTL                 time.Duration                // How long expired entries are kept for ServeStaleOnError.
+	ContextKeyFunc           func(context.Context) string // Keys context arguments by a value they carry.
+	NormalizeSlices          bool                         // Key slice arguments of ordered elements regardless of element order.
+	KeyHasher                keygen.Hasher                // Hashing of long keys (zero: hex SHA-256).
+	VerifyKeys               bool                         // Key entries by the full argument encoding instead of its hash.
+	KeyFields                []string                     // Key struct arguments by these fields only.
+	Namespace                string                       // Prefix isolating the keyspace of this cache.
+	TagFunc                  any                          // func(K, V) []string; tags stored results for InvalidateTag.
+	IndexFunc                any                          // func(V) string; secondary index attribute for LookupByIndex (nil: no index).
+	FallbackOnKeyError       bool                         // Run the function uncached for arguments that cannot be keyed.
+	CopyOnGet                bool                         // Copy cache hits as well (shallow copy for slices/maps without CloneFunc).
+	PropagatePanics          bool                         // Re-panic instead of returning ErrPanic.
+	CaptureStack             bool                         // Record the panic stack trace in ErrPanic errors.
+	AsyncHooks               bool                         // Run lifecycle hooks on background workers.
+	AsyncHookWorkers         int                          // Number of async hook workers.
+	AsyncHookQueueSize       int                          // Queue size per async hook worker.
+	HookTimeout              time.Duration                // Abandon hooks that run longer than this.
+	Clock                    Clock                        // Time source of the storage (nil: system clock).
+	DisableMetrics           bool                         // Don't update the Metrics counters.
+	EvictionPolicy           EvictionPolicy               // Which entry a full cache evicts.
+	OnFull                   FullPolicy                   // What storing a new key into a full cache does.
+	OnFullTimeout            time.Duration                // How long a FullBlock store waits for space.
+	AdmissionPolicy          AdmissionPolicy              // Admission filter for new keys in a full cache.
+	BeforeEvict              any                          // func(string, V) error; flushes or vetoes capacity evictions (nil: evict freely).
+	Compress                 bool                         // Gzip-compress stored []byte/string values.
+	PressureWindow           time.Duration                // Window for the OnPressure eviction detector.
+	PressureThreshold        float64                      // Evictions-to-hits ratio that signals pressure.
+	ExecutionTimeout         time.Duration                // Fail in-flight calls that run longer than this.
+	BreakerThreshold         int                          // Consecutive failures that open the circuit breaker.
+	BreakerCooldown          time.Duration                // Time the breaker stays open before a probe.
+	Retry                    RetryPolicy                  // Retries of failed calls with backoff.
+	MemoryPressureReclaim    bool                         // Evict entries while the heap is over MemoryLimit.
+	MemoryLimit              uint64                       // Heap size in bytes that triggers reclaiming.
+	MemoryCheckInterval      time.Duration                // Time between heap checks.
+	MaxConcurrentExecutions  int                          // Bound on simultaneous executions of the function.
+	ConcurrencyFailFast      bool                         // Fail with ErrConcurrencyLimit instead of waiting for a slot.
+	MaxBytes                 int64                        // Budget for the total cost of stored values.
+	CostFunc                 any                          // func(V) int64; cost of a stored value (nil: estimated size in bytes).
+	MaxBytesCheckInterval    time.Duration                // Minimum time between MaxBytes checks.
+}
+
+// typedFunc asserts that an untyped Config callback has the signature required by the cache's
+// type parameters. It returns the zero F for a nil callback and panics on a mismatched signature,
+// since that is a programming error in the cache construction.
+func typedFunc[F any](name string, f any) F {
+	var zero F
+	if f == nil {
+		return zero
+	}
+	fn, ok := f.(F)
+	if !ok {
+		panic(fmt.Sprintf("fcache: Config.%s must be of type %T, got %T", name, zero, f))
+	}
+	return fn
+}
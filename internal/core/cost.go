@@ -0,0 +1,149 @@
+package core
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// maxEstimateDepth bounds how deep estimateSize follows pointers, slices and maps,
+// so self-referencing values cannot make the estimate recurse forever.
+const maxEstimateDepth = 8
+
+// costBudget rate-limits the MaxBytes checks on the write path to one per interval. The storage keeps
+// the total cost of its entries, so a check is O(1), plus the evictions it makes.
+type costBudget struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time // time of the last check
+}
+
+// due reports whether a check is due at now, and if so records it.
+func (b *costBudget) due(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.Sub(b.last) < b.interval {
+		return false
+	}
+	b.last = now
+	return true
+}
+
+// estimatedCost returns cost or, if nil, estimateSize as the cost of values.
+func estimatedCost[V any](cost func(V) int64) func(V) int64 {
+	if cost != nil {
+		return cost
+	}
+	return func(v V) int64 { return estimateSize(v) }
+}
+
+// recost measures the value now stored in item and updates the total cost, if the storage has a costFn.
+// The caller must hold the write lock.
+func (s *Storage[K, V]) recost(item *StorageItem[V]) {
+	if s.costFn == nil {
+		return
+	}
+	cost := s.costFn(item.Value)
+	s.totalCost += cost - item.cost
+	item.cost = cost
+}
+
+// shrinkToCost evicts entries, chosen by the eviction policy, while the total cost is over the budget,
+// and returns how many it evicted, which are reported to onRemove. It stops early if beforeEvict vetoes
+// every entry.
+func (s *Storage[K, V]) shrinkToCost() int {
+	s.mu.Lock()
+	var evicted []storageEntry[K, V]
+	for s.totalCost > s.maxCost && len(s.data) > 0 {
+		victim, _ := s.evictable()
+		if victim == nil {
+			break // every entry is vetoed by beforeEvict
+		}
+		evicted = s.evict(victim, evicted)
+	}
+	s.stopWhenEmpty()
+	s.mu.Unlock()
+	s.notifyRemoved(evicted)
+	return len(evicted)
+}
+
+// estimateSize returns an approximate size in bytes of the memory referenced by v.
+//
+// Fixed-size values count their in-memory size; strings, slices and maps add their contents;
+// pointers and interfaces add the value they point to. Memory shared between values is counted
+// for each of them, allocator overhead and map buckets are ignored, and references deeper than
+// maxEstimateDepth are not followed, so the result is an approximation that grows with the data.
+func estimateSize(v any) int64 {
+	if v == nil {
+		return 0
+	}
+	return estimateValue(reflect.ValueOf(v), 0)
+}
+
+// estimateValue returns the in-memory size of rv plus the size of the data it references.
+func estimateValue(rv reflect.Value, depth int) int64 {
+	return int64(rv.Type().Size()) + estimateReferenced(rv, depth)
+}
+
+// estimateReferenced returns the size of the data referenced by rv, excluding rv itself.
+func estimateReferenced(rv reflect.Value, depth int) int64 {
+	if depth > maxEstimateDepth {
+		return 0
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return int64(rv.Len())
+	case reflect.Slice:
+		if rv.IsNil() {
+			return 0
+		}
+		elem := rv.Type().Elem()
+		size := int64(rv.Cap()) * int64(elem.Size())
+		if hasReferences(elem.Kind()) {
+			for i := 0; i < rv.Len(); i++ {
+				size += estimateReferenced(rv.Index(i), depth+1)
+			}
+		}
+		return size
+	case reflect.Array:
+		var size int64
+		if hasReferences(rv.Type().Elem().Kind()) {
+			for i := 0; i < rv.Len(); i++ {
+				size += estimateReferenced(rv.Index(i), depth+1)
+			}
+		}
+		return size
+	case reflect.Map:
+		var size int64
+		iter := rv.MapRange()
+		for iter.Next() {
+			size += estimateValue(iter.Key(), depth+1) + estimateValue(iter.Value(), depth+1)
+		}
+		return size
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return 0
+		}
+		return estimateValue(rv.Elem(), depth+1)
+	case reflect.Struct:
+		var size int64
+		for i := 0; i < rv.NumField(); i++ {
+			size += estimateReferenced(rv.Field(i), depth+1)
+		}
+		return size
+	default:
+		return 0
+	}
+}
+
+// hasReferences reports whether values of kind k may reference memory outside themselves.
+func hasReferences(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return false
+	default:
+		return true
+	}
+}
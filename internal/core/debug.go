@@ -0,0 +1,29 @@
+package core
+
+// DebugState reports the state of a cache's background goroutines.
+//
+// It is meant for tests of the cleanup lifecycle and of goroutine leaks, not for monitoring:
+// its fields may change between releases.
+type DebugState struct {
+	CleanupRunning    bool // a cleanup goroutine is scheduled: started, and not told to stop
+	CleanupGoroutines int  // cleanup and sweep goroutines alive, including stopped ones that have not exited yet
+	HookWorkers       int  // async hook worker goroutines alive (Config.AsyncHooks)
+}
+
+// DebugState returns whether the cleanup goroutine is scheduled and how many cleanup goroutines are alive.
+func (s *Storage[K, V]) DebugState() (running bool, goroutines int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cleanupRunning, int(s.cleanupAlive.Load())
+}
+
+// DebugState returns a snapshot of the background goroutines of the cache. Scoped views report
+// the goroutines of the cache they were created from, which they share.
+func (c *Cache[K, SK, V]) DebugState() DebugState {
+	var state DebugState
+	state.CleanupRunning, state.CleanupGoroutines = c.store.DebugState()
+	if c.async != nil {
+		state.HookWorkers = c.async.Workers()
+	}
+	return state
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"container/list"
+
+	"github.com/osmike/fcache/internal/lib/errs"
+)
+
+// EvictionPolicy selects which entry is evicted to make room in a full cache.
+type EvictionPolicy int
+
+const (
+	// EvictionLRU evicts the least recently used entry (default).
+	EvictionLRU EvictionPolicy = iota
+	// EvictionFIFO evicts the entry inserted first. Hits and overwrites don't change the order,
+	// which saves reordering the usage list on every hit.
+	EvictionFIFO
+	// EvictionRandom evicts an arbitrary entry, in O(1) on average.
+	EvictionRandom
+	// EvictionSLRU is a segmented LRU: new entries enter a probationary segment and are promoted to a
+	// protected segment, holding up to 80% of the capacity, on their second hit. Evictions take the least
+	// recently used probationary entry first, so a scan of one-off keys cannot flush the hot set.
+	// When the protected segment overflows, its least recently used entry is demoted back to probation.
+	EvictionSLRU
+)
+
+// String returns the name of the policy.
+func (p EvictionPolicy) String() string {
+	switch p {
+	case EvictionLRU:
+		return "lru"
+	case EvictionFIFO:
+		return "fifo"
+	case EvictionRandom:
+		return "random"
+	case EvictionSLRU:
+		return "slru"
+	default:
+		return "unknown"
+	}
+}
+
+// victim returns the list element of the next entry to evict under the eviction policy,
+// or nil if the storage is empty. The caller must hold the write lock.
+func (s *Storage[K, V]) victim() *list.Element {
+	if s.policy == EvictionRandom {
+		// map iteration starts at a random position
+		for key := range s.data {
+			return s.elems[key]
+		}
+		return nil
+	}
+	// the usage list is in insertion order under FIFO, since it is never reordered,
+	// and ends with the probationary segment under SLRU
+	return s.ll.Back()
+}
+
+// evictable returns the element of the next entry to evict like victim, skipping the entries whose
+// eviction beforeEvict vetoes, in eviction policy order. If every entry is vetoed, it returns nil and
+// the last veto wrapped in ErrCacheFull. The caller must hold the write lock.
+func (s *Storage[K, V]) evictable() (*list.Element, error) {
+	if s.beforeEvict == nil {
+		return s.victim(), nil
+	}
+	var veto error
+	if s.policy == EvictionRandom {
+		for key, item := range s.data {
+			if veto = s.beforeEvict(key, item.Value); veto == nil {
+				return s.elems[key], nil
+			}
+		}
+	} else {
+		for elem := s.ll.Back(); elem != nil; elem = elem.Prev() {
+			key := elem.Value.(K)
+			if veto = s.beforeEvict(key, s.data[key].Value); veto == nil {
+				return elem, nil
+			}
+		}
+	}
+	if veto == nil {
+		return nil, nil // empty storage
+	}
+	return nil, errs.NewError(ErrCacheFull, map[string]any{"error": veto})
+}
+
+// link inserts key into the usage list as a new entry and returns its element. Under SLRU, the entry
+// goes to the front of the probationary segment, otherwise to the front of the list.
+// The caller must hold the write lock.
+func (s *Storage[K, V]) link(key K) *list.Element {
+	if s.policy != EvictionSLRU {
+		return s.ll.PushFront(key)
+	}
+	var elem *list.Element
+	if s.probation != nil {
+		elem = s.ll.InsertBefore(key, s.probation)
+	} else {
+		elem = s.ll.PushBack(key)
+	}
+	s.probation = elem
+	return elem
+}
+
+// unlink removes the element of an entry from the usage list. The caller must hold the write lock.
+func (s *Storage[K, V]) unlink(elem *list.Element, item *StorageItem[V]) {
+	if elem == s.probation {
+		s.probation = elem.Next()
+	}
+	if item.protected {
+		s.protected--
+	}
+	s.ll.Remove(elem)
+}
+
+// touch records a hit on or an overwrite of the entry at elem in the usage list: it moves the entry
+// to the front, except under FIFO, and promotes a probationary entry under SLRU.
+// The caller must hold the write lock.
+func (s *Storage[K, V]) touch(elem *list.Element, item *StorageItem[V]) {
+	switch {
+	case s.policy == EvictionFIFO:
+	case s.policy == EvictionSLRU && !item.protected:
+		s.promote(elem, item)
+	default:
+		s.ll.MoveToFront(elem)
+	}
+}
+
+// promote moves a probationary entry to the front of the protected segment, demoting the least
+// recently used protected entries to the front of probation while the segment is over its size.
+// The caller must hold the write lock.
+func (s *Storage[K, V]) promote(elem *list.Element, item *StorageItem[V]) {
+	if elem == s.probation {
+		s.probation = elem.Next()
+	}
+	s.ll.MoveToFront(elem)
+	item.protected = true
+	s.protected++
+	for s.protected > s.protectedCap() {
+		// the protected segment is the part of the list before probation
+		last := s.ll.Back()
+		if s.probation != nil {
+			last = s.probation.Prev()
+		}
+		s.data[last.Value.(K)].protected = false
+		s.protected--
+		s.probation = last
+	}
+}
+
+// protectedCap returns the size of the protected segment under SLRU: 80% of the capacity, at least 1,
+// or 80% of the entries of an unbounded storage.
+func (s *Storage[K, V]) protectedCap() int {
+	if s.capacity == UnboundedCapacity {
+		return max(len(s.data)*4/5, 1)
+	}
+	return max(s.capacity*4/5, 1)
+}
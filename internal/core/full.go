@@ -0,0 +1,69 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/osmike/fcache/internal/lib/hooks"
+)
+
+// ErrCacheFull is returned with the computed value if a result could not be stored because the cache
+// is full and Config.OnFull is FullReject, or FullBlock and no space freed up within OnFullTimeout.
+var ErrCacheFull = errors.New("cache is full")
+
+// FullPolicy selects what happens when a new key is stored into a full cache.
+type FullPolicy int
+
+const (
+	// FullEvict evicts an entry chosen by the eviction policy to make room (default).
+	FullEvict FullPolicy = iota
+	// FullReject leaves the cache unchanged and fails the store with ErrCacheFull.
+	FullReject
+	// FullBlock waits up to Config.OnFullTimeout for an entry to be removed, e.g. by expiry or
+	// invalidation, and then fails the store with ErrCacheFull.
+	FullBlock
+)
+
+// String returns the name of the policy.
+func (p FullPolicy) String() string {
+	switch p {
+	case FullEvict:
+		return "evict"
+	case FullReject:
+		return "reject"
+	case FullBlock:
+		return "block"
+	default:
+		return "unknown"
+	}
+}
+
+// dropExpired removes the entries that expired first (see nextToExpire), so they can be
+// replaced without evicting valid entries, and appends them to removed.
+// The caller must hold the write lock.
+func (s *Storage[K, V]) dropExpired(removed []storageEntry[K, V]) []storageEntry[K, V] {
+	now := s.clock.Now()
+	for {
+		key, ok := s.nextToExpire()
+		if !ok || !s.expired(s.data[key], now) {
+			return removed
+		}
+		removed = s.remove(key, hooks.ReasonTTL, removed)
+	}
+}
+
+// spaceFreed returns a channel that is closed when an entry is removed or the capacity grows.
+// The caller must hold the write lock.
+func (s *Storage[K, V]) spaceFreed() <-chan struct{} {
+	if s.space == nil {
+		s.space = make(chan struct{})
+	}
+	return s.space
+}
+
+// freeSpace wakes stores blocked on a full cache. The caller must hold the write lock.
+func (s *Storage[K, V]) freeSpace() {
+	if s.space != nil {
+		close(s.space)
+		s.space = nil
+	}
+}
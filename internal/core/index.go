@@ -0,0 +1,91 @@
+package core
+
+// LookupByIndex returns the value of a valid entry whose Config.IndexFunc attribute is attr, e.g. to find
+// whether an object is cached under some ID given its natural key. If several entries share the attribute,
+// the most recently stored one is returned. It reports false if none is cached or no IndexFunc is set.
+//
+// The index is kept consistent with stores, overwrites, evictions, expirations and invalidations. Like
+// Contains, a lookup only takes the read lock and neither reorders the LRU list nor runs the OnGet hooks.
+// On a namespaced cache or Scoped view, only the entries of its namespace are considered.
+func (c *Cache[K, SK, V]) LookupByIndex(attr string) (V, bool) {
+	val, ok := c.store.lookupIndex(attr, func(key SK) bool {
+		_, ok := c.unprefixed(key)
+		return ok
+	})
+	if !ok {
+		return val, false
+	}
+	plain, ok := c.decode(val)
+	if !ok {
+		return plain, false
+	}
+	return c.copyHit(plain), true
+}
+
+// indexOf returns the Config.IndexFunc attribute of a stored value, decompressing it if needed.
+func (c *Cache[K, SK, V]) indexOf(val V) string {
+	plain, ok := c.decode(val)
+	if !ok {
+		return ""
+	}
+	return c.indexFn(plain)
+}
+
+// lookupIndex returns the value of the most recently stored valid entry indexed under attr whose key
+// matches, if match is not nil.
+func (s *Storage[K, V]) lookupIndex(attr string, match func(key K) bool) (V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := s.clock.Now()
+	var found *StorageItem[V]
+	for key := range s.index[attr] {
+		item := s.data[key]
+		if s.expired(item, now) || (match != nil && !match(key)) {
+			continue
+		}
+		if found == nil || item.Timestamp.After(found.Timestamp) {
+			found = item
+		}
+	}
+	if found == nil {
+		var zero V
+		return zero, false
+	}
+	return found.Value, true
+}
+
+// reindex updates the secondary index for the value now stored in item, if the storage has an indexFn.
+// The caller must hold the write lock.
+func (s *Storage[K, V]) reindex(key K, item *StorageItem[V]) {
+	if s.indexFn == nil {
+		return
+	}
+	s.unindex(key, item)
+	if item.attr = s.indexFn(item.Value); item.attr == "" {
+		return
+	}
+	if s.index == nil {
+		s.index = make(map[string]map[K]struct{})
+	}
+	keys, ok := s.index[item.attr]
+	if !ok {
+		keys = make(map[K]struct{})
+		s.index[item.attr] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+// unindex removes key from the secondary index. Like untag, every removal path calls it, so the index
+// never refers to evicted or expired entries. The caller must hold the write lock.
+func (s *Storage[K, V]) unindex(key K, item *StorageItem[V]) {
+	if item.attr == "" {
+		return
+	}
+	if keys, ok := s.index[item.attr]; ok {
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(s.index, item.attr)
+		}
+	}
+	item.attr = ""
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// latencyEMAWeight is the weight of a new sample in the moving average of execution latency.
+const latencyEMAWeight = 0.1
+
+// latencyBuckets is the number of histogram buckets: 4 linear sub-buckets per power of two of nanoseconds.
+const latencyBuckets = 4 * 63
+
+// latencyTracker records the duration of function executions.
+//
+// It keeps an exponential moving average and a log-linear histogram with four buckets per
+// power of two, from which quantiles are estimated within about 12% without storing samples.
+type latencyTracker struct {
+	mu      sync.Mutex
+	ema     float64 // moving average in nanoseconds
+	count   uint64
+	buckets [latencyBuckets]uint64
+}
+
+// record adds an execution duration.
+func (l *latencyTracker) record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.count == 0 {
+		l.ema = float64(d)
+	} else {
+		l.ema += latencyEMAWeight * (float64(d) - l.ema)
+	}
+	l.count++
+	l.buckets[latencyBucket(uint64(d))]++
+}
+
+// snapshot returns the moving average and the estimated p50 and p99 latencies.
+func (l *latencyTracker) snapshot() (avg, p50, p99 time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.count == 0 {
+		return 0, 0, 0
+	}
+	return time.Duration(l.ema), l.quantile(0.5), l.quantile(0.99)
+}
+
+// quantile estimates the q-th quantile (nearest rank) as the midpoint of the bucket holding it.
+// The caller must hold mu.
+func (l *latencyTracker) quantile(q float64) time.Duration {
+	rank := uint64(math.Ceil(q * float64(l.count)))
+	var seen uint64
+	for i, n := range l.buckets {
+		seen += n
+		if seen >= rank {
+			lo, hi := latencyBucketBounds(i)
+			return time.Duration(lo + (hi-lo)/2)
+		}
+	}
+	return 0
+}
+
+// latencyBucket returns the histogram bucket of a duration in nanoseconds.
+func latencyBucket(ns uint64) int {
+	if ns < 4 {
+		return int(ns)
+	}
+	exp := bits.Len64(ns) - 1
+	sub := int(ns>>(exp-2)) & 3
+	return 4*(exp-1) + sub
+}
+
+// latencyBucketBounds returns the range [lo, hi) of nanoseconds covered by bucket i.
+func latencyBucketBounds(i int) (lo, hi uint64) {
+	if i < 4 {
+		return uint64(i), uint64(i) + 1
+	}
+	exp, sub := i/4+1, uint64(i%4)
+	return (4 + sub) << (exp - 2), (5 + sub) << (exp - 2)
+}
@@ -0,0 +1,38 @@
+package core
+
+import "errors"
+
+// ErrConcurrencyLimit is returned if Config.MaxConcurrentExecutions functions are already running
+// and Config.ConcurrencyFailFast is set.
+var ErrConcurrencyLimit = errors.New("too many concurrent executions of cached function")
+
+// execLimiter is a semaphore bounding the number of simultaneous executions of the cached function.
+type execLimiter struct {
+	slots    chan struct{}
+	failFast bool // fail instead of waiting for a free slot
+}
+
+// newExecLimiter creates a limiter allowing n simultaneous executions.
+func newExecLimiter(n int, failFast bool) *execLimiter {
+	return &execLimiter{slots: make(chan struct{}, n), failFast: failFast}
+}
+
+// acquire takes a slot, waiting for one to free up unless failFast is set.
+// It reports false if no slot was taken.
+func (l *execLimiter) acquire() bool {
+	if !l.failFast {
+		l.slots <- struct{}{}
+		return true
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release frees a slot taken by acquire.
+func (l *execLimiter) release() {
+	<-l.slots
+}
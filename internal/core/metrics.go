@@ -0,0 +1,96 @@
+package core
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Metrics is a point-in-time snapshot of cache counters.
+type Metrics struct {
+	Hits      uint64 // calls served from the cache
+	Misses    uint64 // calls that executed the underlying function
+	Evictions uint64 // entries evicted to respect the capacity
+
+	// DeduplicatedSaves counts calls that waited for a concurrent in-flight call for the same key
+	// and shared its result: neither hits nor misses, they are the executions saved by deduplication.
+	DeduplicatedSaves uint64
+
+	StaleServes uint64 // calls answered with a stale value because the function failed (Config.ServeStaleOnError)
+
+	UncompressedBytes uint64 // total size of stored values before compression (Config.Compress)
+	CompressedBytes   uint64 // total size of stored values after compression (Config.Compress)
+
+	BreakerState string // circuit breaker state: "closed", "open" or "half-open" (empty without a breaker)
+	BreakerTrips uint64 // number of times the circuit breaker opened
+
+	LatencyAvg time.Duration // exponential moving average of function execution time
+	LatencyP50 time.Duration // estimated median function execution time
+	LatencyP99 time.Duration // estimated 99th percentile of function execution time
+}
+
+// CompressionRatio returns CompressedBytes / UncompressedBytes, or 0 if nothing was compressed.
+// Lower is better: 0.25 means stored values take a quarter of their original size.
+func (m Metrics) CompressionRatio() float64 {
+	if m.UncompressedBytes == 0 {
+		return 0
+	}
+	return float64(m.CompressedBytes) / float64(m.UncompressedBytes)
+}
+
+// cacheLineSize is the assumed CPU cache line size: 64 bytes on amd64 and most arm64 cores.
+const cacheLineSize = 64
+
+// paddedCounter is an atomic counter that fills a whole cache line, so counters updated by
+// different CPUs never share a line and bounce it between their caches (false sharing).
+type paddedCounter struct {
+	atomic.Uint64
+	_ [cacheLineSize - 8]byte
+}
+
+// metrics holds the live cache counters, updated atomically.
+//
+// Every counter is padded to its own cache line, and a leading pad separates the first one from the
+// Cache fields before it: on a parallel hit path, hits are counted by every CPU, and without padding
+// each increment would also invalidate the line holding the neighbouring counters. The padding costs
+// under 512 bytes per cache (and Scoped view), in exchange for one contended line instead of several.
+type metrics struct {
+	_                 [cacheLineSize]byte
+	hits              paddedCounter
+	misses            paddedCounter
+	evictions         paddedCounter
+	deduplicated      paddedCounter
+	staleServes       paddedCounter
+	uncompressedBytes paddedCounter
+	compressedBytes   paddedCounter
+	latency           latencyTracker
+	off               bool // Config.DisableMetrics: counters stay at zero
+}
+
+// add increments counter by n, unless metrics are disabled.
+func (m *metrics) add(counter *paddedCounter, n uint64) {
+	if !m.off {
+		counter.Add(n)
+	}
+}
+
+// record adds an execution duration to the latency tracker, unless metrics are disabled.
+func (m *metrics) record(d time.Duration) {
+	if !m.off {
+		m.latency.record(d)
+	}
+}
+
+// snapshot returns the current counter values.
+func (m *metrics) snapshot() Metrics {
+	s := Metrics{
+		Hits:              m.hits.Load(),
+		Misses:            m.misses.Load(),
+		Evictions:         m.evictions.Load(),
+		DeduplicatedSaves: m.deduplicated.Load(),
+		StaleServes:       m.staleServes.Load(),
+		UncompressedBytes: m.uncompressedBytes.Load(),
+		CompressedBytes:   m.compressedBytes.Load(),
+	}
+	s.LatencyAvg, s.LatencyP50, s.LatencyP99 = m.latency.snapshot()
+	return s
+}
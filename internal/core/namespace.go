@@ -0,0 +1,99 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Scoped returns a view of the cache whose keys live in the given namespace.
+//
+// The view shares storage, capacity, TTL and configuration with the cache, but its keys are prefixed
+// with the namespace, so it can never read or overwrite entries of another namespace, even for the
+// same argument. This is cheaper than a separate cache per tenant, since all tenants share one capacity.
+// Scoped always derives from the cache's base keyspace (Config.Namespace is replaced, not nested),
+// and returns the same view for the same namespace. Calls through a view are deduplicated and counted
+// in the view's own Metrics; capacity evictions are counted by the cache they were created from.
+//
+// Namespaces require string cache keys: Scoped panics on comparable-key caches.
+func (c *Cache[K, SK, V]) Scoped(namespace string) *Cache[K, SK, V] {
+	root := c.root
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	if view, ok := root.scopes[namespace]; ok {
+		return view
+	}
+	view := &Cache[K, SK, V]{
+		fn:          root.fn,
+		store:       root.store,
+		inflight:    make(map[SK]*inflightCall[V]),
+		cfg:         root.cfg,
+		hooks:       root.hooks,
+		clone:       root.clone,
+		shouldCache: root.shouldCache,
+		equal:       root.equal,
+		bypassFn:    root.bypassFn,
+		tagFn:       root.tagFn,
+		beforeEvict: root.beforeEvict,
+		indexFn:     root.indexFn,
+		ttlFn:       root.ttlFn,
+		copyHits:    root.copyHits,
+		async:       root.async,
+		hookTimeout: root.hookTimeout,
+		softTTL:     root.softTTL,
+		baseKeyFn:   root.baseKeyFn,
+		keyFn:       namespaceKeyFn(root.baseKeyFn, namespace),
+		prefix:      namespacePrefix(namespace),
+		codec:       root.codec,
+		pressure:    root.pressure,
+		breaker:     root.breaker,
+		budget:      root.budget,
+		limiter:     root.limiter,
+		root:        root,
+	}
+	view.metrics.off = root.metrics.off
+	if root.scopes == nil {
+		root.scopes = make(map[string]*Cache[K, SK, V])
+	}
+	root.scopes[namespace] = view
+	return view
+}
+
+// namespaceKeyFn prefixes the keys built by keyFn with namespace, so keys of different namespaces
+// never collide. The prefix includes the namespace length, so no namespace and key can be split
+// differently into another namespace's key. It panics if SK is not string.
+func namespaceKeyFn[K any, SK comparable](keyFn func(K) (SK, error), namespace string) func(K) (SK, error) {
+	if namespace == "" {
+		return keyFn
+	}
+	var zero SK
+	if _, ok := any(zero).(string); !ok {
+		panic(fmt.Sprintf("fcache: Config.Namespace requires string cache keys, got %T", zero))
+	}
+	prefix := namespacePrefix(namespace)
+	return func(arg K) (SK, error) {
+		key, err := keyFn(arg)
+		if err != nil {
+			return key, err
+		}
+		return any(prefix + any(key).(string)).(SK), nil
+	}
+}
+
+// namespacePrefix returns the key prefix of namespace, or "" for no namespace.
+func namespacePrefix(namespace string) string {
+	if namespace == "" {
+		return ""
+	}
+	return strconv.Itoa(len(namespace)) + ":" + namespace + ":"
+}
+
+// unprefixed reports whether key belongs to the cache's namespace and returns it without the prefix.
+// Without a namespace, every key belongs to the cache.
+func (c *Cache[K, SK, V]) unprefixed(key SK) (SK, bool) {
+	if c.prefix == "" {
+		return key, true
+	}
+	rest, ok := strings.CutPrefix(any(key).(string), c.prefix)
+	return any(rest).(SK), ok
+}
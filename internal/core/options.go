@@ -0,0 +1,108 @@
+package core
+
+import (
+	"time"
+
+	"github.com/osmike/fcache/internal/lib/hooks"
+)
+
+// Option configures a cache built by New.
+type Option func(*options)
+
+// options collects the settings applied by Options.
+type options struct {
+	cfg   Config
+	hooks *hooks.Hooks // the caller's hooks, read on every call, or a copy once modified by an option
+}
+
+// New wraps fn with caching logic configured by the given options, e.g.
+//
+//	cached := core.New(fetch, core.WithTTL(time.Minute), core.WithCapacity(500))
+//
+// Options are applied in order, so later options override earlier ones. Without options,
+// the defaults of NewCachedFunction apply.
+func New[K any, V any](fn CachedFunc[K, V], opts ...Option) CachedFunc[K, V] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return NewCache(fn, &o.cfg, o.hooks).Call
+}
+
+// WithConfig sets all configuration fields from cfg. A nil cfg leaves the configuration unchanged.
+// Options after it override individual fields.
+func WithConfig(cfg *Config) Option {
+	return func(o *options) {
+		if cfg != nil {
+			o.cfg = *cfg
+		}
+	}
+}
+
+// WithTTL sets the time-to-live of cache entries (Config.TTL).
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.cfg.TTL = ttl
+	}
+}
+
+// WithCapacity sets the maximum number of cache entries (Config.Capacity).
+func WithCapacity(capacity int) Option {
+	return func(o *options) {
+		o.cfg.Capacity = capacity
+	}
+}
+
+// WithCleanupInterval sets the interval of the background cleanup (Config.CleanupInterval).
+func WithCleanupInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.cfg.CleanupInterval = interval
+	}
+}
+
+// WithNamespace sets the prefix isolating the cache's keyspace (Config.Namespace).
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.cfg.Namespace = namespace
+	}
+}
+
+// WithKeyFields keys struct arguments by the named fields only (Config.KeyFields), e.g.
+//
+//	cached := core.New(handle, core.WithKeyFields("ID", "TenantID"))
+//
+// Building the cache panics with ErrInvalidConfig if the argument type has no such exported field.
+func WithKeyFields(fields ...string) Option {
+	return func(o *options) {
+		o.cfg.KeyFields = fields
+	}
+}
+
+// WithHooks sets the lifecycle hooks to h. A nil h leaves the hooks unchanged.
+// h is kept, not copied, and read on every call, so hooks assigned to it after the cache is built apply.
+// An option after it that overrides an individual hook, such as WithEviction, works on a copy instead.
+func WithHooks(h *hooks.Hooks) Option {
+	return func(o *options) {
+		if h != nil {
+			o.hooks = h
+		}
+	}
+}
+
+// WithEviction sets the callback run with the key and value of every entry evicted to make room
+// for a new one (Hooks.OnEvict), e.g. to close resources held by cached values.
+func WithEviction(fn hooks.HookContextFunc) Option {
+	return func(o *options) {
+		o.hooks = copyHooks(o.hooks)
+		o.hooks.OnEvict = fn
+	}
+}
+
+// copyHooks returns a copy of h, or empty hooks if h is nil, so an option never modifies the caller's hooks.
+func copyHooks(h *hooks.Hooks) *hooks.Hooks {
+	if h == nil {
+		return &hooks.Hooks{}
+	}
+	cp := *h
+	return &cp
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/osmike/fcache/internal/lib/hooks"
+)
+
+// pressureDetector tracks capacity evictions against cache hits over a tumbling time window.
+//
+// When evictions in the current window exceed threshold × hits, the cache is evicting entries
+// faster than it is getting value from them, i.e. the capacity is too small for the working set.
+// The detector reports this at most once per window.
+type pressureDetector struct {
+	mu        sync.Mutex
+	window    time.Duration
+	threshold float64
+
+	start     time.Time // start of the current window
+	hits      uint64    // hits in the current window
+	evictions uint64    // evictions in the current window
+	fired     bool      // pressure already reported in the current window
+}
+
+// newPressureDetector creates a detector with the given window and threshold.
+func newPressureDetector(window time.Duration, threshold float64) *pressureDetector {
+	return &pressureDetector{window: window, threshold: threshold, start: time.Now()}
+}
+
+// hit records a cache hit.
+func (p *pressureDetector) hit(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.roll(now)
+	p.hits++
+}
+
+// evict records a capacity eviction and reports whether pressure should be signalled.
+func (p *pressureDetector) evict(now time.Time) (hooks.PressureEvent, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.roll(now)
+	p.evictions++
+	if p.fired || float64(p.evictions) <= p.threshold*float64(p.hits) {
+		return hooks.PressureEvent{}, false
+	}
+	p.fired = true
+	return hooks.PressureEvent{Window: p.window, Evictions: p.evictions, Hits: p.hits}, true
+}
+
+// roll starts a new window if the current one has elapsed. The caller must hold mu.
+func (p *pressureDetector) roll(now time.Time) {
+	if now.Sub(p.start) < p.window {
+		return
+	}
+	p.start = now
+	p.hits = 0
+	p.evictions = 0
+	p.fired = false
+}
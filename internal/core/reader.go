@@ -0,0 +1,88 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/osmike/fcache/internal/lib/hooks"
+)
+
+// streamValue is the cached form of a stream: its content, buffered in memory.
+//
+// If the stream was longer than the size limit, data holds only the buffered prefix and rest the
+// unread remainder. Such values are never cached, and rest is handed to a single caller.
+type streamValue struct {
+	data []byte
+
+	mu   sync.Mutex
+	rest io.ReadCloser // unread remainder of an oversized stream (nil: data is complete)
+	full bool          // data is the complete stream content
+}
+
+// claim returns the remainder of an oversized stream to the first caller, and nil afterwards.
+func (v *streamValue) claim() io.ReadCloser {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	rest := v.rest
+	v.rest = nil
+	return rest
+}
+
+// prefixedReadCloser reads a buffered prefix followed by the rest of a stream, and closes the stream.
+type prefixedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// WrapReader caches a function returning a stream, such as an HTTP response body.
+//
+// A stream can only be read once, so caching it directly is useless. Instead, the stream returned
+// by fn is read into memory and closed, the bytes are cached, and every caller receives a fresh
+// reader over the cached bytes. Streams longer than maxBytes are not cached: the caller that
+// triggered the read gets a reader over the buffered prefix followed by the unread remainder,
+// and other deduplicated callers call fn themselves. Read errors are returned and not cached.
+// The ShouldCache option is not supported, since it is used internally.
+func WrapReader[K any](fn func(K) (io.ReadCloser, error), maxBytes int64, opts *Config, h *hooks.Hooks) func(K) (io.ReadCloser, error) {
+	var cfg Config
+	if opts != nil {
+		cfg = *opts
+	}
+	cfg.ShouldCache = func(_ K, v *streamValue, _ error) bool {
+		return v.full
+	}
+
+	buffered := NewCachedFunction(func(arg K) (*streamValue, error) {
+		rc, err := fn(arg)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		n, err := io.Copy(&buf, io.LimitReader(rc, maxBytes+1))
+		if err != nil {
+			rc.Close()
+			return nil, err
+		}
+		if n > maxBytes {
+			// too large to cache: keep the stream open for a caller to finish reading
+			return &streamValue{data: buf.Bytes(), rest: rc}, nil
+		}
+		rc.Close()
+		return &streamValue{data: buf.Bytes(), full: true}, nil
+	}, &cfg, h)
+
+	return func(arg K) (io.ReadCloser, error) {
+		v, err := buffered(arg)
+		if err != nil {
+			return nil, err
+		}
+		if v.full {
+			return io.NopCloser(bytes.NewReader(v.data)), nil
+		}
+		if rest := v.claim(); rest != nil {
+			return prefixedReadCloser{io.MultiReader(bytes.NewReader(v.data), rest), rest}, nil
+		}
+		// another caller took the oversized stream: read our own
+		return fn(arg)
+	}
+}
@@ -0,0 +1,40 @@
+package core
+
+import "runtime"
+
+// reclaimFraction is the share of entries evicted when the heap is over Config.MemoryLimit.
+const reclaimFraction = 4 // evict 1/4 of the entries per check
+
+// memoryReclaimer reports when heap usage is over the limit. It is checked by a storage sweep every
+// Config.MemoryCheckInterval, so the stop-the-world heap read stays off the callers' path.
+//
+// Go has no weak references, so values cannot be released by the GC on its own terms. Instead, the
+// cache sheds its least recently used entries while the heap is over the limit, letting the GC free them.
+type memoryReclaimer struct {
+	limit uint64
+	heap  func() uint64 // reads the current heap size
+}
+
+// newMemoryReclaimer creates a reclaimer for the given heap limit in bytes.
+func newMemoryReclaimer(limit uint64) *memoryReclaimer {
+	return &memoryReclaimer{limit: limit, heap: heapAlloc}
+}
+
+// overLimit reports whether the heap is above the limit.
+func (r *memoryReclaimer) overLimit() bool {
+	return r.heap() > r.limit
+}
+
+// reclaim sheds the LRU tail of the storage if the heap is over the limit; at least one entry per check.
+func (s *Storage[K, V]) reclaim(r *memoryReclaimer) {
+	if r.overLimit() {
+		s.Shrink(s.Len()/reclaimFraction + 1)
+	}
+}
+
+// heapAlloc returns the bytes of allocated heap objects.
+func heapAlloc() uint64 {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
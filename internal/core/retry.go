@@ -0,0 +1,49 @@
+package core
+
+import "time"
+
+// RetryPolicy configures retries of failed function calls (Config.Retry).
+//
+// Retries run inside the in-flight call, so concurrent callers for the same key share a single
+// retry sequence. Only the final result, success or the last error, is cached or reported.
+// Panics are never retried.
+type RetryPolicy struct {
+	MaxAttempts int              // Total attempts including the first (<= 1: no retries).
+	Backoff     time.Duration    // Delay before the first retry, doubled after each further attempt.
+	MaxBackoff  time.Duration    // Upper bound of the delay (0: unbounded).
+	Retryable   func(error) bool // Reports whether an error is transient (nil: retry every error).
+}
+
+// delay returns the backoff before retry number n, starting at 1.
+func (p RetryPolicy) delay(n int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < n && d > 0; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// retryable reports whether err should be retried.
+func (p RetryPolicy) retryable(err error) bool {
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// executeRetry calls fn like executeTimeout, retrying failed attempts according to Config.Retry.
+func (c *Cache[K, SK, V]) executeRetry(fn CachedFunc[K, V], arg K) (V, any, error) {
+	policy := c.cfg.Retry
+	for attempt := 1; ; attempt++ {
+		val, recovered, err := c.executeTimeout(fn, arg)
+		if err == nil || recovered != nil || attempt >= policy.MaxAttempts || !policy.retryable(err) {
+			return val, recovered, err
+		}
+		if d := policy.delay(attempt); d > 0 {
+			time.Sleep(d)
+		}
+	}
+}
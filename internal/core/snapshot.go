@@ -0,0 +1,65 @@
+package core
+
+import (
+	"slices"
+	"time"
+)
+
+// Stats returns a snapshot of the valid cache entries, in LRU order from most to least recently used.
+// On a namespaced cache or Scoped view, only the entries of its namespace are included.
+// Values are decompressed if Config.Compress is set; entries that fail to decompress are left out.
+func (c *Cache[K, SK, V]) Stats() StorageStat[V] {
+	stat := c.store.statsFunc(func(key SK) bool {
+		_, ok := c.unprefixed(key)
+		return ok
+	})
+	items := stat.Items[:0]
+	for _, item := range stat.Items {
+		plain, ok := c.decode(item.Value)
+		if !ok {
+			continue
+		}
+		item.Value = c.copyHit(plain)
+		items = append(items, item)
+	}
+	return StorageStat[V]{Entries: len(items), Items: items}
+}
+
+// SnapshotKeys returns the keys of the valid cache entries, sorted, e.g. to compare the contents of
+// the cache at two points in time with DiffKeys. On a namespaced cache or Scoped view, only the keys
+// of its namespace are returned, without the namespace prefix.
+func (c *Cache[K, SK, V]) SnapshotKeys() []string {
+	var keys []string
+	c.store.Range(func(key SK, _ V, _ time.Duration) bool {
+		if rest, ok := c.unprefixed(key); ok {
+			keys = append(keys, keyString(rest))
+		}
+		return true
+	})
+	slices.Sort(keys)
+	return keys
+}
+
+// DiffKeys compares two key snapshots taken by SnapshotKeys and returns the keys only in after
+// (added) and only in before (removed), sorted. Keys in both, even if refreshed, are in neither.
+func DiffKeys(before, after []string) (added, removed []string) {
+	inBefore := make(map[string]struct{}, len(before))
+	for _, key := range before {
+		inBefore[key] = struct{}{}
+	}
+	inAfter := make(map[string]struct{}, len(after))
+	for _, key := range after {
+		inAfter[key] = struct{}{}
+		if _, ok := inBefore[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	for _, key := range before {
+		if _, ok := inAfter[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+	return added, removed
+}
@@ -2,32 +2,101 @@ package core
 
 import (
 	"container/list"
+	"hash/maphash"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/osmike/fcache/internal/lib/hooks"
 )
 
-// Storage is a generic, thread-safe LRU cache for values of type Val.
+// Storage is a generic, thread-safe LRU cache for values of type V, keyed by K.
 //
 // It supports per-entry TTL expiration, capacity-based eviction, and LRU ordering.
 // Each entry is moved to the front of the usage list on access.
-type Storage[Val any] struct {
+// A second list keeps entries in timestamp order, so expired entries can be found without a full scan.
+// K is the string key built by keygen, or the argument itself for comparable-key caches.
+type Storage[K comparable, V any] struct {
 	mu       sync.RWMutex
-	data     map[string]*StorageItem[Val] // map key to cached value
-	ll       *list.List                   // list of keys, front is most recently used
-	elems    map[string]*list.Element     // map key to list element
+	data     map[K]*StorageItem[V] // map key to cached value
+	ll       *list.List            // list of keys, front is most recently used
+	byAge    *list.List            // list of keys by timestamp, front is oldest (next to expire)
+	elems    map[K]*list.Element   // map key to list element
 	capacity int
 	ttl      time.Duration // time-to-live for cache entries
+	ttlPrec  TTLPrecedence // how per-entry TTLs (ttlFn) combine with ttl
+	epoch    uint64        // current epoch; entries stored in earlier epochs are treated as expired
+	sliding  bool          // refresh timestamp on every hit
+	clock    Clock         // source of timestamps and expiry checks
+	noExpire bool          // entries never expire, only capacity evicts them
+	stale    time.Duration // how long expired entries are kept past their TTL for GetStale (0: not kept)
+
+	cleanInterval  time.Duration  // interval for periodic cleanup of expired entries
+	cleanBatch     int            // maximum number of deletions per write lock acquisition in cleanup
+	stopCleanup    chan struct{}  // channel to signal cleanup goroutine to stop
+	cleanupRunning bool           // indicates if cleanup goroutine is active
+	cleanupOff     bool           // background cleanup disabled; expiry is lazy or manual
+	cleanupAlive   atomic.Int32   // cleanup and sweep goroutines alive, including stopped ones that have not exited yet
+	sweeps         []storageSweep // periodic maintenance tasks, e.g. the MaxBytes budget
+	stopSweep      chan struct{}  // closed to stop the sweep goroutines (nil: not running)
+	closed         bool           // Close was called; nothing is stored anymore
+
+	policy    EvictionPolicy  // which entry capacity evictions remove
+	full      FullPolicy      // what a store of a new key into a full cache does
+	fullWait  time.Duration   // how long a FullBlock store waits for space
+	space     chan struct{}   // closed when space frees up, waking FullBlock stores (nil: no waiters)
+	admission AdmissionPolicy // optional admission filter for new keys (nil: always admit)
+	probation *list.Element   // front of the probationary segment under SLRU (nil: segment empty)
+	protected int             // entries in the protected segment under SLRU
+	seed      maphash.Seed    // seed for key hashes passed to the admission policy
+
+	onRemove    func(key K, value V, reason hooks.RemoveReason) // called after removals, outside the lock (optional)
+	beforeEvict func(key K, value V) error                      // vetoes capacity evictions, called under the lock (optional)
+	indexFn     func(value V) string                            // secondary index attribute of values, called under the lock (optional)
+	ttlFn       func(value V) time.Duration                     // per-entry TTL of values, called under the lock (optional)
+
+	tags  map[string]map[K]struct{} // reverse index from tag to tagged keys
+	index map[string]map[K]struct{} // secondary index from value attribute to keys (nil: no indexFn)
+
+	expiry expiryHeap[K, V] // entries by deadline, maintained only with a ttlFn
+
+	costFn    func(value V) int64 // cost of a stored value, called under the lock once per store (optional)
+	maxCost   int64               // budget for totalCost (Config.MaxBytes)
+	totalCost int64               // sum of the costs of the entries, maintained only with a costFn
+}
 
-	cleanInterval  time.Duration // interval for periodic cleanup of expired entries
-	stopCleanup    chan struct{} // channel to signal cleanup goroutine to stop
-	cleanupRunning bool          // indicates if cleanup goroutine is active
+// storageEntry is a removed key/value pair, collected under the lock and reported after it is released.
+type storageEntry[K comparable, V any] struct {
+	key    K
+	value  V
+	reason hooks.RemoveReason
 }
 
 // StorageItem represents a single cache entry, holding the stored value
-// and its insertion timestamp for TTL validation.
+// and its timestamp for TTL validation.
+//
+// With sliding expiration enabled, Timestamp is also refreshed on every hit. Created and LastAccess
+// are for observability only and never affect expiration: an entry resident for long but hit recently
+// is hot, while one whose LastAccess is old is cold.
 type StorageItem[V any] struct {
-	Value     V         // cached value
-	Timestamp time.Time // timestamp of last insert
+	Value V        // cached value
+	Err   error    // error stored with the value (Config.CacheOnError), usually nil
+	Tags  []string // tags of the entry (Config.TagFunc), indexed for DeleteTag
+	Hits  uint64   // lookups served by the entry since its key was inserted; overwrites keep the count
+
+	Created    time.Time // when the key was inserted; overwrites keep it
+	LastAccess time.Time // time of the last hit, or of the insert if the key has not been hit since
+
+	ageElem   *list.Element // position in the storage's timestamp-ordered list
+	protected bool          // in the protected segment under SLRU
+	attr      string        // secondary index attribute of the value (Config.IndexFunc), "" if not indexed
+	ttl       time.Duration // TTL of the value (Config.TTLFunc), resolved by entryTTL; 0 uses the global TTL
+	cost      int64         // cost of the value (Config.MaxBytes), measured when it was stored
+	deadline  time.Time     // when the entry expires, kept with per-entry TTLs to order the expiry heap
+	expIdx    int           // position in the expiry heap plus one (0: not in the heap)
+	epoch     uint64        // storage epoch the entry was stored in
+	Timestamp time.Time     // timestamp of last insert (or last hit with sliding TTL)
 }
 
 // StorageStat holds statistics and a snapshot of cache items.
@@ -37,26 +106,71 @@ type StorageStat[V any] struct {
 	Items   []StorageItem[V] // items in LRU order, from most to least recent
 }
 
-// NewStorage initializes a new Storage with specified TTL and capacity.
+// UnboundedCapacity, as Config.Capacity or SetCapacity, removes the limit on the number of entries, so
+// only expiry, invalidation and the MaxBytes or MemoryPressureReclaim budgets remove them.
+const UnboundedCapacity = -1
+
+// NewStorage initializes a new Storage from the given cache configuration.
 //
-//   - ttl: Time-to-live for each cache entry.
-//   - capacity: Maximum number of cache entries (default: 1000 if <= 0).
-//   - cleanInterval: Interval for periodic cleanup of expired entries.
+//   - cfg.TTL: Time-to-live for each cache entry.
+//   - cfg.Capacity: Maximum number of cache entries (default: 1000 if <= 0, unless UnboundedCapacity).
+//   - cfg.CleanupInterval: Interval for periodic cleanup of expired entries (default: the TTL, between 1ms and 1 minute, if <= 0).
+//   - cfg.CleanupBatchSize: Maximum number of deletions per lock acquisition in cleanup (default: 1024 if <= 0).
+//   - cfg.SlidingTTL: Refresh the entry timestamp on every hit.
+//   - cfg.Clock: Source of timestamps and expiry checks (default: the system clock).
+//   - cfg.NoExpire: Entries never expire; the TTL is ignored and no cleanup goroutine runs.
+//   - cfg.ServeStaleOnError: Keep expired entries for cfg.StaleTTL (default: the TTL) past their TTL, for GetStale.
+//   - cfg.DisableBackgroundCleanup: Never start the cleanup goroutine.
+//   - cfg.EvictionPolicy: Which entry is evicted to make room (default: least recently used).
+//   - cfg.OnFull: Whether a new key evicts, is rejected, or waits when the storage is full (default: evict).
+//   - cfg.OnFullTimeout: How long a store waits for space under FullBlock (default: 1 second if <= 0).
+//   - cfg.AdmissionPolicy: Optional filter deciding whether new keys may displace the eviction victim.
 //
 // Returns a pointer to the initialized Storage.
-func NewStorage[V any](ttl time.Duration, capacity int, cleanInterval time.Duration) *Storage[V] {
-	if capacity <= 0 {
-		capacity = 1000
+func NewStorage[K comparable, V any](cfg Config) *Storage[K, V] {
+	capacity := cfg.Capacity
+	if capacity <= 0 && capacity != UnboundedCapacity {
+		capacity = defaultMaxSize
+	}
+	if cfg.CleanupInterval <= 0 {
+		cfg.CleanupInterval = cleanupIntervalFor(cfg.TTL)
+	}
+	if cfg.CleanupBatchSize <= 0 {
+		cfg.CleanupBatchSize = defaultCleanupBatchSize
 	}
-	s := &Storage[V]{
-		data:           make(map[string]*StorageItem[V]),
+	if cfg.OnFullTimeout <= 0 {
+		cfg.OnFullTimeout = defaultOnFullTimeout
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = realClock{}
+	}
+	if !cfg.ServeStaleOnError {
+		cfg.StaleTTL = 0
+	} else if cfg.StaleTTL <= 0 {
+		cfg.StaleTTL = cfg.TTL
+	}
+	s := &Storage[K, V]{
+		data:           make(map[K]*StorageItem[V]),
 		ll:             list.New(),
-		elems:          make(map[string]*list.Element),
+		byAge:          list.New(),
+		elems:          make(map[K]*list.Element),
+		tags:           make(map[string]map[K]struct{}),
 		capacity:       capacity,
-		ttl:            ttl,
-		cleanInterval:  cleanInterval,
-		stopCleanup:    make(chan struct{}),
+		ttl:            cfg.TTL,
+		ttlPrec:        cfg.TTLPrecedence,
+		sliding:        cfg.SlidingTTL,
+		clock:          cfg.Clock,
+		noExpire:       cfg.NoExpire,
+		stale:          cfg.StaleTTL,
+		cleanInterval:  cfg.CleanupInterval,
+		cleanBatch:     cfg.CleanupBatchSize,
 		cleanupRunning: false,
+		cleanupOff:     cfg.DisableBackgroundCleanup,
+		policy:         cfg.EvictionPolicy,
+		full:           cfg.OnFull,
+		fullWait:       cfg.OnFullTimeout,
+		admission:      cfg.AdmissionPolicy,
+		seed:           maphash.MakeSeed(),
 	}
 
 	return s
@@ -64,113 +178,734 @@ func NewStorage[V any](ttl time.Duration, capacity int, cleanInterval time.Durat
 
 // Get retrieves the cached value for the given key.
 //
-// If the entry exists and is not expired, it moves the entry to the front of the LRU list.
+// If the entry exists and is not expired, it moves the entry to the front of the LRU list,
+// unless the eviction policy is FIFO. Under SLRU, the first hit promotes the entry to the protected segment.
+// With sliding TTL enabled, a hit also resets the entry's timestamp.
 // Returns (value, true) if found and valid; otherwise returns (zero, false).
-func (s *Storage[V]) Get(key string) (V, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+//
+// Get takes the write lock because it reorders the LRU list.
+func (s *Storage[K, V]) Get(key K) (V, bool) {
+	val, _, ok := s.GetWithError(key)
+	return val, ok
+}
+
+// GetWithError is like Get, but also returns the error stored with the value by SetWithError.
+func (s *Storage[K, V]) GetWithError(key K) (V, error, bool) {
+	val, err, _, ok := s.GetWithAge(key)
+	return val, err, ok
+}
+
+// GetWithAge is like GetWithError, and also returns the age of the entry: the time since it was stored,
+// or since its previous hit with sliding TTL, measured before this hit refreshes it.
+func (s *Storage[K, V]) GetWithAge(key K) (V, error, time.Duration, bool) {
+	s.mu.Lock()
+	now := s.clock.Now()
+	var stamp time.Time
+	if item, ok := s.data[key]; ok {
+		stamp = item.Timestamp
+	}
+	item, ok, expired := s.getLocked(key, now, nil)
+	if !ok {
+		s.mu.Unlock()
+		s.notifyRemoved(expired)
+		var zero V
+		return zero, nil, 0, false
+	}
+	val, err := item.Value, item.Err
+	s.mu.Unlock()
+	return val, err, now.Sub(stamp), true
+}
+
+// GetMulti looks up several keys under a single lock acquisition.
+//
+// It returns the values of valid entries, keyed by storage key, with the same LRU and
+// sliding TTL effects as Get for each hit. Entries stored with an error are left out.
+func (s *Storage[K, V]) GetMulti(keys []K) map[K]V {
+	found := make(map[K]V, len(keys))
+	var expired []storageEntry[K, V]
+	s.mu.Lock()
+	now := s.clock.Now()
+	for _, key := range keys {
+		var item *StorageItem[V]
+		var ok bool
+		if item, ok, expired = s.getLocked(key, now, expired); ok && item.Err == nil {
+			found[key] = item.Value
+		}
+	}
+	s.mu.Unlock()
+	s.notifyRemoved(expired)
+	return found
+}
+
+// getLocked implements Get; the caller must hold the write lock.
+// An expired entry found for key is removed and appended to expired.
+func (s *Storage[K, V]) getLocked(key K, now time.Time, expired []storageEntry[K, V]) (*StorageItem[V], bool, []storageEntry[K, V]) {
+	if s.admission != nil {
+		s.admission.Record(s.hash(key))
+	}
 	if elem, ok := s.elems[key]; ok {
-		s.ll.MoveToFront(elem)
 		val := s.data[key]
 		// Check if the item is still valid based on TTL
-		if time.Since(val.Timestamp) > s.ttl {
-			s.deleteProxy(key)
-			var zero V
-			return zero, false
+		if s.expired(val, now) {
+			if !s.removable(val, now) {
+				return nil, false, expired // kept for GetStale
+			}
+			return nil, false, s.remove(key, hooks.ReasonTTL, expired)
+		}
+		val.Hits++
+		val.LastAccess = now
+		s.touch(elem, val)
+		if s.sliding {
+			val.Timestamp = now
+			s.byAge.MoveToBack(val.ageElem)
+			s.schedule(key, val)
+		}
+		return val, true, expired
+	}
+	return nil, false, expired
+}
+
+// Len returns the number of entries in storage, including expired entries not yet removed.
+func (s *Storage[K, V]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.data)
+}
+
+// Contains reports whether a valid (non-expired) entry exists for the given key.
+//
+// Unlike Get, it only takes the read lock and neither copies the value, reorders the LRU list,
+// nor refreshes a sliding TTL. Expired entries are reported as absent but left for cleanup.
+func (s *Storage[K, V]) Contains(key K) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.data[key]
+	return ok && !s.expired(item, s.clock.Now())
+}
+
+// TTLRemaining returns how long the entry for key stays valid, measured from its timestamp with
+// the current TTL (or its own, see Config.TTLPrecedence), and whether it is valid. With sliding TTL, the remaining time restarts on each hit.
+// For an expired entry it returns the negative time since expiry and false, and for an absent key 0
+// and false. With NoExpire, a valid entry never expires and the maximum duration is returned.
+//
+// Like Contains, it only takes the read lock and does not count as a hit.
+func (s *Storage[K, V]) TTLRemaining(key K) (time.Duration, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.data[key]
+	if !ok {
+		return 0, false
+	}
+	now := s.clock.Now()
+	if s.expired(item, now) {
+		if item.epoch != s.epoch {
+			return -1, false // orphaned by an epoch change, not by its TTL
+		}
+		return s.entryTTL(item) - now.Sub(item.Timestamp), false
+	}
+	if s.noExpire {
+		return math.MaxInt64, true
+	}
+	return s.entryTTL(item) - now.Sub(item.Timestamp), true
+}
+
+// Range calls f for each valid (non-expired) entry, from most to least recently used,
+// with the entry's key, value and age. It stops early if f returns false.
+//
+// Range holds the read lock for the whole iteration, so concurrent writers wait until it returns.
+// It does not reorder the LRU list or refresh sliding TTLs. f must not call back into the storage
+// with write operations, or it will deadlock.
+func (s *Storage[K, V]) Range(f func(key K, val V, age time.Duration) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := s.clock.Now()
+	for elem := s.ll.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(K)
+		item := s.data[key]
+		if s.expired(item, now) {
+			continue
+		}
+		if !f(key, item.Value, now.Sub(item.Timestamp)) {
+			return
+		}
+	}
+}
+
+// Stats returns a snapshot of the valid (non-expired) entries, in LRU order from most to least
+// recently used. It holds the read lock while copying and does not reorder the LRU list.
+func (s *Storage[K, V]) Stats() StorageStat[V] {
+	return s.statsFunc(nil)
+}
+
+// statsFunc implements Stats, including only the entries whose key matches, if match is not nil.
+func (s *Storage[K, V]) statsFunc(match func(key K) bool) StorageStat[V] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := s.clock.Now()
+	items := make([]StorageItem[V], 0, len(s.data))
+	for elem := s.ll.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(K)
+		item := s.data[key]
+		if s.expired(item, now) || (match != nil && !match(key)) {
+			continue
 		}
-		return val.Value, true
+		snapshot := *item
+		snapshot.ageElem = nil // don't leak the list position
+		items = append(items, snapshot)
 	}
-	var zero V
-	return zero, false
+	return StorageStat[V]{Entries: len(items), Items: items}
 }
 
 // Set inserts or updates the cache entry for the given key with the provided value.
 //
-// It timestamps the entry and moves it to the front of the LRU list.
-// If capacity is exceeded, the least recently used entry is evicted.
-// Starts the cleanup goroutine if not already running.
-func (s *Storage[V]) Set(key string, value V) {
+// It timestamps the entry and moves it to the front of the LRU list (under FIFO, only new keys
+// are placed at the front; under SLRU, new keys enter the probationary segment and an overwrite counts as a hit). Overwriting an existing key updates it in place and never evicts.
+// Inserting a new key when the cache is full first evicts an entry chosen by the eviction policy,
+// so with capacity 1 every new key replaces the previous one, while re-setting the same key keeps it.
+// With an admission policy, a new key is only inserted into a full cache if the policy admits it
+// over the entry that would be evicted. Under the FullReject and FullBlock policies, a new key is
+// instead not inserted into a full cache (see SetEntry).
+// Starts the cleanup goroutine if not already running, unless background cleanup is disabled.
+func (s *Storage[K, V]) Set(key K, value V) {
+	s.SetWithError(key, value, nil)
+}
+
+// SetWithError is like Set, but stores err alongside the value, to be returned by GetWithError.
+func (s *Storage[K, V]) SetWithError(key K, value V, err error) {
+	s.SetEntry(key, value, err, nil)
+}
+
+// SetEntry is like SetWithError, and also tags the entry, so DeleteTag can remove it.
+// Overwriting an entry replaces its tags.
+//
+// Under the FullReject and FullBlock policies, expired entries are removed to make room for a new
+// key in a full storage. If none were expired, FullReject returns ErrCacheFull at once, and FullBlock
+// waits for an entry to be removed (or the capacity to grow) for up to the configured timeout before
+// returning ErrCacheFull. Under FullEvict, a new key is not inserted either if beforeEvict vetoes the
+// eviction of every entry; the returned ErrCacheFull then also wraps the last veto error.
+// Otherwise SetEntry returns nil.
+func (s *Storage[K, V]) SetEntry(key K, value V, err error, tags []string) error {
+	_, _, setErr := s.SwapEntry(key, value, err, tags)
+	return setErr
+}
+
+// Swap is like Set, but also returns the value it replaced and whether a valid (non-expired) entry
+// existed for the key, like a map swap. It returns ErrCacheFull if the value was not stored (see SetEntry).
+func (s *Storage[K, V]) Swap(key K, value V) (V, bool, error) {
+	return s.SwapEntry(key, value, nil, nil)
+}
+
+// SwapEntry is like SetEntry, but also returns the replaced value and whether it existed (see Swap).
+func (s *Storage[K, V]) SwapEntry(key K, value V, err error, tags []string) (prev V, existed bool, setErr error) {
+	var timeout *time.Timer
+	for {
+		s.mu.Lock()
+		if item, ok := s.data[key]; ok && !s.expired(item, s.clock.Now()) {
+			prev, existed = item.Value, true
+		}
+		var removed []storageEntry[K, V]
+		removed, setErr = s.setLocked(key, value, err, tags)
+		var space <-chan struct{}
+		if setErr != nil && s.full == FullBlock {
+			space = s.spaceFreed()
+		}
+		s.mu.Unlock()
+		s.notifyRemoved(removed)
+		if space == nil {
+			return prev, existed, setErr
+		}
+
+		if timeout == nil {
+			timeout = time.NewTimer(s.fullWait)
+			defer timeout.Stop()
+		}
+		select {
+		case <-space: // retry
+		case <-timeout.C:
+			var zero V
+			return zero, false, ErrCacheFull
+		}
+	}
+}
+
+// setLocked implements Set and returns the removed entries, or ErrCacheFull if the key was not
+// inserted because the storage is full and the policy is not FullEvict, or beforeEvict vetoed every
+// eviction candidate. The caller must hold the write lock.
+func (s *Storage[K, V]) setLocked(key K, value V, err error, tags []string) ([]storageEntry[K, V], error) {
+	if s.closed {
+		return nil, nil
+	}
+	var evicted []storageEntry[K, V]
+	if elem, ok := s.elems[key]; ok {
+		// overwrite in place: reuse the list node, so the key never has two nodes
+		item := s.data[key]
+		s.untag(key, item.Tags)
+		item.Value = value
+		item.Err = err
+		item.Tags = tags
+		item.Timestamp = s.clock.Now()
+		item.epoch = s.epoch
+		s.byAge.MoveToBack(item.ageElem)
+		s.tag(key, tags)
+		s.reindex(key, item)
+		s.retime(item)
+		s.schedule(key, item)
+		s.recost(item)
+		s.touch(elem, item)
+	} else {
+		if s.atCapacity() && s.full != FullEvict {
+			if evicted = s.dropExpired(evicted); s.atCapacity() {
+				return evicted, ErrCacheFull
+			}
+		}
+		if s.atCapacity() {
+			// make room before inserting, so the new entry is never the victim
+			victim, veto := s.evictable()
+			if veto != nil {
+				return evicted, veto
+			}
+			if !s.admit(key, victim) {
+				return nil, nil
+			}
+			evicted = s.evict(victim, evicted)
+		}
+		now := s.clock.Now()
+		item := &StorageItem[V]{
+			Value:      value,
+			Err:        err,
+			Tags:       tags,
+			Created:    now,
+			LastAccess: now,
+			Timestamp:  now,
+			epoch:      s.epoch,
+		}
+		item.ageElem = s.byAge.PushBack(key)
+		s.tag(key, tags)
+		s.reindex(key, item)
+		s.retime(item)
+		s.schedule(key, item)
+		s.recost(item)
+		// insert new entry
+		s.elems[key] = s.link(key)
+		s.data[key] = item
+	}
+	// If cleanup is not running, start it (there is nothing to clean up if entries never expire)
+	if !s.cleanupRunning && !s.cleanupOff && !s.noExpire {
+		s.cleanupRunning = true
+		// each run gets its own stop channel, since a previous one may already be closed
+		s.stopCleanup = make(chan struct{})
+		s.cleanupAlive.Add(1)
+		go s.startCleanup(s.cleanInterval, s.stopCleanup)
+	}
+	s.startSweeps()
+	return evicted, nil
+}
+
+// SetCapacity changes the maximum number of entries (default: 1000 if <= 0, unless UnboundedCapacity).
+// Shrinking the capacity immediately evicts entries down to the new limit, chosen by the eviction policy.
+func (s *Storage[K, V]) SetCapacity(capacity int) {
+	if capacity <= 0 && capacity != UnboundedCapacity {
+		capacity = defaultMaxSize
+	}
+	s.mu.Lock()
+	if capacity == UnboundedCapacity || capacity > s.capacity {
+		s.freeSpace()
+	}
+	s.capacity = capacity
+	var evicted []storageEntry[K, V]
+	for s.capacity != UnboundedCapacity && len(s.data) > s.capacity {
+		victim, _ := s.evictable()
+		if victim == nil {
+			break // every entry is vetoed by beforeEvict
+		}
+		evicted = s.evict(victim, evicted)
+	}
+	s.mu.Unlock()
+	s.notifyRemoved(evicted)
+}
+
+// Capacity returns the maximum number of entries, or UnboundedCapacity.
+func (s *Storage[K, V]) Capacity() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.capacity
+}
+
+// TTL returns the time-to-live of cache entries.
+func (s *Storage[K, V]) TTL() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ttl
+}
+
+// SetTTL changes the time-to-live of cache entries.
+//
+// Expiry is always computed as entry timestamp + current TTL, so the new TTL applies
+// retroactively to existing entries: shortening it can expire them immediately,
+// lengthening it extends their lifetime.
+func (s *Storage[K, V]) SetTTL(ttl time.Duration) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.ttl = ttl
+	s.rescheduleAll()
+}
+
+// Evict removes up to n least recently used entries and returns their values,
+// from least to most recently used. Eviction order is deterministic: it strictly follows the LRU list.
+//
+// The removed entries are not reported to onRemove; the caller owns the returned values.
+func (s *Storage[K, V]) Evict(n int) []V {
+	return s.EvictFunc(n, func(K) bool { return true })
+}
 
-	item := &StorageItem[V]{
-		Value:     value,
-		Timestamp: time.Now(),
+// EvictFunc is like Evict, but only removes entries whose key matches, skipping the others.
+func (s *Storage[K, V]) EvictFunc(n int, match func(key K) bool) []V {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var values []V
+	for e := s.ll.Back(); e != nil && n > 0; {
+		prev := e.Prev() // deleteProxy unlinks e
+		if key := e.Value.(K); match(key) {
+			values = append(values, s.data[key].Value)
+			s.deleteProxy(key)
+			n--
+		}
+		e = prev
 	}
-	// insert new entry
-	elem := s.ll.PushFront(key)
-	s.elems[key] = elem
-	s.data[key] = item
+	return values
+}
 
-	// evict least recently used if over capacity
-	if len(s.data) > s.capacity {
-		tail := s.ll.Back()
-		if tail != nil {
-			oldKey := tail.Value.(string)
-			s.ll.Remove(tail)
-			delete(s.elems, oldKey)
-			delete(s.data, oldKey)
+// Shrink evicts n entries like capacity evictions, chosen by the eviction policy and reported to onRemove.
+func (s *Storage[K, V]) Shrink(n int) {
+	s.mu.Lock()
+	var evicted []storageEntry[K, V]
+	for ; n > 0 && len(s.data) > 0; n-- {
+		victim, _ := s.evictable()
+		if victim == nil {
+			break // every entry is vetoed by beforeEvict
 		}
+		evicted = s.evict(victim, evicted)
 	}
-	// If cleanup is not running, start it
-	if !s.cleanupRunning {
-		s.cleanupRunning = true
-		go s.startCleanup(s.cleanInterval) // start cleanup every 5 minutes
+	s.stopWhenEmpty()
+	s.mu.Unlock()
+	s.notifyRemoved(evicted)
+}
+
+// atCapacity reports whether the storage holds its maximum number of entries, so a new key needs room.
+// An unbounded storage never does. The caller must hold the lock.
+func (s *Storage[K, V]) atCapacity() bool {
+	return s.capacity != UnboundedCapacity && len(s.data) >= s.capacity
+}
+
+// expired reports whether item's TTL has elapsed at now, or it was stored before the current epoch.
+// With NoExpire, entries only expire by epoch.
+func (s *Storage[K, V]) expired(item *StorageItem[V], now time.Time) bool {
+	return item.epoch != s.epoch || (!s.noExpire && now.Sub(item.Timestamp) > s.entryTTL(item))
+}
+
+// removable reports whether item is expired and no longer kept for GetStale, so it may be removed.
+func (s *Storage[K, V]) removable(item *StorageItem[V], now time.Time) bool {
+	return item.epoch != s.epoch || (!s.noExpire && now.Sub(item.Timestamp) > s.entryTTL(item)+s.stale)
+}
+
+// Extend restarts the TTL of the entry for key, as if its value had been stored again, if unchanged
+// reports true for the stored value, and returns whether it did. The value, tags and hit count are kept.
+// Expired entries still kept for GetStale are extended too, while entries stored with an error, entries
+// of other epochs and absent keys are not. unchanged runs under the write lock and must not use the storage.
+func (s *Storage[K, V]) Extend(key K, unchanged func(stored V) bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.data[key]
+	now := s.clock.Now()
+	if !ok || item.Err != nil || s.removable(item, now) || !unchanged(item.Value) {
+		return false
+	}
+	item.Timestamp = now
+	s.byAge.MoveToBack(item.ageElem)
+	s.schedule(key, item)
+	return true
+}
+
+// GetStale returns the value stored for key, whether or not it has expired, provided it is still
+// kept: with a stale window (Config.ServeStaleOnError), expired entries are kept for that long past
+// their TTL. Entries of other epochs and entries stored with an error are not returned.
+// It only takes the read lock and does not count as a hit.
+func (s *Storage[K, V]) GetStale(key K) (V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.data[key]
+	if !ok || item.Err != nil || s.removable(item, s.clock.Now()) {
+		var zero V
+		return zero, false
+	}
+	return item.Value, true
+}
+
+// SetEpoch sets the storage epoch. Entries stored in other epochs are treated as expired from then on:
+// lookups miss, and they are removed by lookups and cleanup like entries whose TTL has elapsed.
+// Since epochs only go forward in practice, stale entries are the oldest ones, and cleanup reaches them first.
+func (s *Storage[K, V]) SetEpoch(epoch uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.epoch = epoch
+}
+
+// BumpEpoch increments the storage epoch, expiring all current entries in O(1), and returns the new epoch.
+func (s *Storage[K, V]) BumpEpoch() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.epoch++
+	return s.epoch
+}
+
+// evict removes the entry of the given list element, if not nil, and appends it to evicted.
+// The caller must hold the write lock.
+func (s *Storage[K, V]) evict(elem *list.Element, evicted []storageEntry[K, V]) []storageEntry[K, V] {
+	if elem != nil {
+		oldKey := elem.Value.(K)
+		item := s.data[oldKey]
+		evicted = append(evicted, storageEntry[K, V]{key: oldKey, value: item.Value, reason: hooks.ReasonCapacity})
+		s.untag(oldKey, item.Tags)
+		s.unindex(oldKey, item)
+		s.unschedule(item)
+		s.totalCost -= item.cost
+		s.byAge.Remove(item.ageElem)
+		s.unlink(elem, item)
+		delete(s.elems, oldKey)
+		delete(s.data, oldKey)
+		s.freeSpace()
+	}
+	return evicted
+}
+
+// notifyRemoved reports removed entries to onRemove. It must be called without holding the lock,
+// so the callback may safely use the storage.
+func (s *Storage[K, V]) notifyRemoved(removed []storageEntry[K, V]) {
+	if s.onRemove == nil {
+		return
 	}
+	for _, e := range removed {
+		s.onRemove(e.key, e.value, e.reason)
+	}
+}
+
+// admit reports whether the new key may displace victim in a full cache, consulting the admission policy.
+// The caller must hold the write lock.
+func (s *Storage[K, V]) admit(key K, victim *list.Element) bool {
+	if s.admission == nil || victim == nil {
+		return true
+	}
+	return s.admission.Admit(s.hash(key), s.hash(victim.Value.(K)))
+}
+
+// hash returns the hash of key used by the admission policy.
+func (s *Storage[K, V]) hash(key K) uint64 {
+	return maphash.Comparable(s.seed, key)
 }
 
 // Delete removes the cache entry for the given key, if present,
 // updating both the map and the LRU list.
-func (s *Storage[V]) Delete(key string) {
+func (s *Storage[K, V]) Delete(key K) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	removed := s.remove(key, hooks.ReasonManual, nil)
+	s.mu.Unlock()
+	s.notifyRemoved(removed)
+}
+
+// DeleteFunc removes every entry whose key matches and returns the number removed.
+// It holds the write lock for the whole scan.
+func (s *Storage[K, V]) DeleteFunc(match func(key K) bool) int {
+	return s.deleteFunc(match, hooks.ReasonManual)
+}
+
+// deleteFunc implements DeleteFunc, reporting removals with the given reason.
+func (s *Storage[K, V]) deleteFunc(match func(key K) bool, reason hooks.RemoveReason) int {
+	s.mu.Lock()
+	var removed []storageEntry[K, V]
+	for key := range s.data {
+		if match(key) {
+			// deleting the current key while ranging over the map is allowed
+			removed = s.remove(key, reason, removed)
+		}
+	}
+	s.mu.Unlock()
+	s.notifyRemoved(removed)
+	return len(removed)
+}
+
+// DeleteTag removes every entry tagged with tag and returns the number removed.
+func (s *Storage[K, V]) DeleteTag(tag string) int {
+	s.mu.Lock()
+	var removed []storageEntry[K, V]
+	for key := range s.tags[tag] {
+		// remove unindexes the key, deleting from the tag's keys while ranging over them, which is allowed
+		removed = s.remove(key, hooks.ReasonManual, removed)
+	}
+	s.mu.Unlock()
+	s.notifyRemoved(removed)
+	return len(removed)
+}
+
+// Clear removes all entries, reporting them to onRemove, and returns the number removed.
+func (s *Storage[K, V]) Clear() int {
+	s.mu.Lock()
+	removed := s.clearLocked()
+	s.mu.Unlock()
+	s.notifyRemoved(removed)
+	return len(removed)
+}
+
+// Close removes all entries like Clear, stops the cleanup goroutine, and makes later stores no-ops,
+// so the storage stays empty and never starts cleanup again. It returns the number of entries removed.
+// Calling Close more than once is safe.
+func (s *Storage[K, V]) Close() int {
+	s.mu.Lock()
+	s.closed = true
+	removed := s.clearLocked()
+	s.mu.Unlock()
+	s.notifyRemoved(removed)
+	return len(removed)
+}
+
+// clearLocked implements Clear and returns the removed entries. The caller must hold the write lock.
+func (s *Storage[K, V]) clearLocked() []storageEntry[K, V] {
+	removed := make([]storageEntry[K, V], 0, len(s.data))
+	for elem := s.ll.Back(); elem != nil; elem = elem.Prev() {
+		key := elem.Value.(K)
+		removed = append(removed, storageEntry[K, V]{key: key, value: s.data[key].Value, reason: hooks.ReasonClear})
+	}
+	s.data = make(map[K]*StorageItem[V])
+	s.elems = make(map[K]*list.Element)
+	s.tags = make(map[string]map[K]struct{})
+	s.index = nil
+	s.expiry = nil
+	s.totalCost = 0
+	s.ll.Init()
+	s.byAge.Init()
+	s.probation, s.protected = nil, 0
+	s.freeSpace()
+	s.stopWhenEmpty()
+	return removed
+}
+
+// tag adds key to the reverse index of each tag. The caller must hold the write lock.
+func (s *Storage[K, V]) tag(key K, tags []string) {
+	for _, t := range tags {
+		keys, ok := s.tags[t]
+		if !ok {
+			keys = make(map[K]struct{})
+			s.tags[t] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+// untag removes key from the reverse index of each tag, dropping tags left without keys.
+// Every removal path calls it, so the index never refers to evicted or expired entries.
+// The caller must hold the write lock.
+func (s *Storage[K, V]) untag(key K, tags []string) {
+	for _, t := range tags {
+		if keys, ok := s.tags[t]; ok {
+			delete(keys, key)
+			if len(keys) == 0 {
+				delete(s.tags, t)
+			}
+		}
+	}
+}
+
+// remove deletes the entry of key, if present, and appends it to removed with the given reason.
+// The caller must hold the write lock.
+func (s *Storage[K, V]) remove(key K, reason hooks.RemoveReason, removed []storageEntry[K, V]) []storageEntry[K, V] {
+	item, ok := s.data[key]
+	if !ok {
+		return removed
+	}
+	removed = append(removed, storageEntry[K, V]{key: key, value: item.Value, reason: reason})
 	s.deleteProxy(key)
+	return removed
 }
 
 // deleteProxy is an internal helper to remove a key from the cache and LRU list.
 // If the cache becomes empty, it stops the cleanup goroutine.
-func (s *Storage[V]) deleteProxy(key string) {
+func (s *Storage[K, V]) deleteProxy(key K) {
 	if elem, ok := s.elems[key]; ok {
-		s.ll.Remove(elem)
+		item := s.data[key]
+		s.untag(key, item.Tags)
+		s.unindex(key, item)
+		s.unschedule(item)
+		s.totalCost -= item.cost
+		s.byAge.Remove(item.ageElem)
+		s.unlink(elem, item)
 		delete(s.elems, key)
 		delete(s.data, key)
-		if len(s.data) == 0 && s.cleanupRunning {
-			// If no entries left, stop the cleanup goroutine
-			s.cleanupRunning = false
-			close(s.stopCleanup) // signal cleanup goroutine to stop
-		}
+		s.freeSpace()
+		// If no entries left, stop the cleanup goroutine
+		s.stopWhenEmpty()
 	}
 }
 
-// startCleanup launches a ticker that triggers cleanupExpired at the given interval.
-// The cleanup goroutine stops when the cache becomes empty.
-func (s *Storage[V]) startCleanup(interval time.Duration) {
-	ticker := time.NewTicker(interval)
+// startCleanup launches a ticker of the storage clock that triggers cleanupExpired at the given interval.
+// The cleanup goroutine stops when the cache becomes empty and stop is closed.
+func (s *Storage[K, V]) startCleanup(interval time.Duration, stop <-chan struct{}) {
+	defer s.cleanupAlive.Add(-1)
+	ticker := s.clock.NewTicker(interval)
 	defer ticker.Stop()
 	for {
 		select {
-		case <-ticker.C:
+		case <-ticker.C():
 			s.cleanupExpired() // perform cleanup
-		case <-s.stopCleanup:
+		case <-stop:
 			return
 		}
 	}
 }
 
-// cleanupExpired removes all entries whose TTL has elapsed.
-func (s *Storage[V]) cleanupExpired() {
-	now := time.Now()
-	s.mu.Lock()
-	// collect keys to delete to avoid mutation during iteration
-	var expired []string
-	for key, item := range s.data {
-		if now.Sub(item.Timestamp) > s.ttl {
-			expired = append(expired, key)
+// PurgeExpired removes all expired entries on demand and returns how many were removed.
+// It is safe to call concurrently with other Storage operations.
+func (s *Storage[K, V]) PurgeExpired() int {
+	removed, _ := s.cleanupExpired()
+	return removed
+}
+
+// RunCleanup runs the cleanup sweep of the background goroutine immediately, independent of its ticker,
+// and returns the number of expired entries removed and the number of entries remaining afterwards.
+// Entries kept for GetStale (ServeStaleOnError) are not removed and count as remaining.
+// It is safe to call concurrently with other Storage operations, including a running sweep.
+func (s *Storage[K, V]) RunCleanup() (removed, remaining int) {
+	return s.cleanupExpired()
+}
+
+// cleanupExpired removes all entries whose TTL has elapsed and returns the number removed,
+// and the number of entries left when the last batch released the lock.
+//
+// With sliding TTL enabled, the TTL is measured from the last hit rather than
+// the last insert, so only entries that have not been read for a full TTL are removed.
+//
+// Since all entries share the TTL, they expire in timestamp order: the sweep walks the
+// timestamp-ordered list from the oldest entry and stops at the first one that is still valid,
+// so its cost is proportional to the number of expired entries, not to the cache size.
+// With per-entry TTLs (Config.TTLFunc), it takes the entries from the expiry heap instead.
+// Deletions are done in batches of cleanBatch keys, releasing the write lock between batches,
+// so a sweep over many expired entries does not stall readers for its whole duration.
+func (s *Storage[K, V]) cleanupExpired() (total, remaining int) {
+	now := s.clock.Now()
+	for {
+		var removed []storageEntry[K, V]
+		s.mu.Lock()
+		for len(removed) < s.cleanBatch {
+			key, ok := s.nextToExpire()
+			if !ok || !s.removable(s.data[key], now) {
+				break
+			}
+			removed = s.remove(key, hooks.ReasonTTL, removed)
+		}
+		remaining = len(s.data)
+		s.mu.Unlock()
+		s.notifyRemoved(removed)
+		total += len(removed)
+		if len(removed) < s.cleanBatch {
+			return total, remaining
 		}
 	}
-	// delete expired entries
-	for _, key := range expired {
-		s.deleteProxy(key)
-	}
-	s.mu.Unlock()
 }
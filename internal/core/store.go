@@ -0,0 +1,12 @@
+package core
+
+// NewStore returns a standalone storage, for values computed elsewhere, with the TTL, capacity,
+// eviction and cleanup semantics of the storage behind a cache built with opts. opts is validated
+// and its defaults are applied as for NewCache (e.g. a TTL of 5 minutes); pass nil for all defaults.
+//
+// Only the storage settings apply: TTL (or HardTTL), Capacity, CleanupInterval, CleanupBatchSize,
+// SlidingTTL, NoExpire, DisableBackgroundCleanup, Clock, EvictionPolicy, OnFull, OnFullTimeout,
+// AdmissionPolicy, and ServeStaleOnError with StaleTTL for GetStale. Function and hook settings are ignored.
+func NewStore[K comparable, V any](opts *Config) *Storage[K, V] {
+	return NewStorage[K, V](resolveConfig(opts))
+}
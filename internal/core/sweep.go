@@ -0,0 +1,61 @@
+package core
+
+import "time"
+
+// storageSweep is a periodic maintenance task of the storage, such as enforcing the MaxBytes budget.
+type storageSweep struct {
+	interval time.Duration
+	run      func()
+}
+
+// addSweep registers a task run every interval, but at most once per millisecond, on the storage clock.
+// Like the expiry cleanup, sweeps run while the storage holds entries, and not at all with
+// DisableBackgroundCleanup. It must be called before the storage is used.
+func (s *Storage[K, V]) addSweep(interval time.Duration, run func()) {
+	s.sweeps = append(s.sweeps, storageSweep{interval: max(interval, minCleanupInterval), run: run})
+}
+
+// startSweeps launches a goroutine for each registered sweep, unless they are running.
+// The caller must hold the write lock.
+func (s *Storage[K, V]) startSweeps() {
+	if s.stopSweep != nil || len(s.sweeps) == 0 || s.cleanupOff || s.closed {
+		return
+	}
+	stop := make(chan struct{})
+	s.stopSweep = stop
+	for _, sw := range s.sweeps {
+		s.cleanupAlive.Add(1)
+		go s.runSweep(sw, stop)
+	}
+}
+
+// runSweep runs sw at its interval until stop is closed.
+func (s *Storage[K, V]) runSweep(sw storageSweep, stop <-chan struct{}) {
+	defer s.cleanupAlive.Add(-1)
+	ticker := s.clock.NewTicker(sw.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			sw.run()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// stopWhenEmpty stops the cleanup and sweep goroutines once the storage holds no entries; the next
+// store starts them again. The caller must hold the write lock.
+func (s *Storage[K, V]) stopWhenEmpty() {
+	if len(s.data) > 0 {
+		return
+	}
+	if s.cleanupRunning {
+		s.cleanupRunning = false
+		close(s.stopCleanup)
+	}
+	if s.stopSweep != nil {
+		close(s.stopSweep)
+		s.stopSweep = nil
+	}
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"container/heap"
+	"time"
+)
+
+// TTLPrecedence selects how the TTL of an entry given by Config.TTLFunc combines with the global TTL,
+// via Config.TTLPrecedence.
+type TTLPrecedence int
+
+const (
+	// TTLMin expires an entry after the shorter of its own TTL and the global TTL (default), so a TTLFunc
+	// returning a longer duration than intended cannot keep entries past the global TTL.
+	TTLMin TTLPrecedence = iota
+	// TTLPerEntry expires an entry after its own TTL, which may exceed the global TTL.
+	TTLPerEntry
+	// TTLGlobal expires every entry after the global TTL, ignoring the TTLFunc, e.g. to switch it off.
+	TTLGlobal
+)
+
+// String returns the name of the precedence.
+func (p TTLPrecedence) String() string {
+	switch p {
+	case TTLMin:
+		return "min"
+	case TTLPerEntry:
+		return "per-entry"
+	case TTLGlobal:
+		return "global"
+	default:
+		return "unknown"
+	}
+}
+
+// ttlOf returns the Config.TTLFunc TTL of a stored value, decompressing it if needed.
+func (c *Cache[K, SK, V]) ttlOf(val V) time.Duration {
+	plain, ok := c.decode(val)
+	if !ok {
+		return 0
+	}
+	return c.ttlFn(plain)
+}
+
+// retime records the per-entry TTL of the value now stored in item, if the storage has a ttlFn.
+// The caller must hold the write lock.
+func (s *Storage[K, V]) retime(item *StorageItem[V]) {
+	if s.ttlFn != nil {
+		item.ttl = s.ttlFn(item.Value)
+	}
+}
+
+// entryTTL returns the TTL that applies to item, resolving its own TTL against the global one by the
+// storage's TTLPrecedence. Entries without a TTL of their own use the global TTL. It is resolved on every
+// check, so SetTTL applies retroactively like it does without per-entry TTLs.
+func (s *Storage[K, V]) entryTTL(item *StorageItem[V]) time.Duration {
+	if item.ttl <= 0 {
+		return s.ttl
+	}
+	switch s.ttlPrec {
+	case TTLPerEntry:
+		return item.ttl
+	case TTLGlobal:
+		return s.ttl
+	default:
+		return min(item.ttl, s.ttl)
+	}
+}
+
+// expiryRef is an entry in the storage's expiry heap.
+type expiryRef[K comparable, V any] struct {
+	key  K
+	item *StorageItem[V]
+}
+
+// expiryHeap orders entries with per-entry TTLs by deadline, earliest first, implementing heap.Interface.
+// The timestamp-ordered list no longer is in expiry order once entries have TTLs of their own.
+type expiryHeap[K comparable, V any] []expiryRef[K, V]
+
+func (h expiryHeap[K, V]) Len() int           { return len(h) }
+func (h expiryHeap[K, V]) Less(i, j int) bool { return h[i].item.deadline.Before(h[j].item.deadline) }
+
+func (h expiryHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].item.expIdx, h[j].item.expIdx = i+1, j+1
+}
+
+func (h *expiryHeap[K, V]) Push(x any) {
+	ref := x.(expiryRef[K, V])
+	ref.item.expIdx = len(*h) + 1
+	*h = append(*h, ref)
+}
+
+func (h *expiryHeap[K, V]) Pop() any {
+	old := *h
+	ref := old[len(old)-1]
+	old[len(old)-1] = expiryRef[K, V]{}
+	*h = old[:len(old)-1]
+	ref.item.expIdx = 0
+	return ref
+}
+
+// schedule places the entry of key in the expiry heap by its current deadline, after it was stored or
+// its timestamp changed, if the storage has a ttlFn. The caller must hold the write lock.
+func (s *Storage[K, V]) schedule(key K, item *StorageItem[V]) {
+	if s.ttlFn == nil {
+		return
+	}
+	item.deadline = item.Timestamp.Add(s.entryTTL(item))
+	if item.expIdx > 0 {
+		heap.Fix(&s.expiry, item.expIdx-1)
+		return
+	}
+	heap.Push(&s.expiry, expiryRef[K, V]{key: key, item: item})
+}
+
+// unschedule removes item from the expiry heap. Every removal path calls it, like unindex.
+// The caller must hold the write lock.
+func (s *Storage[K, V]) unschedule(item *StorageItem[V]) {
+	if item.expIdx > 0 {
+		heap.Remove(&s.expiry, item.expIdx-1)
+	}
+}
+
+// rescheduleAll recomputes every deadline after the global TTL changed. The caller must hold the write lock.
+func (s *Storage[K, V]) rescheduleAll() {
+	for _, ref := range s.expiry {
+		ref.item.deadline = ref.item.Timestamp.Add(s.entryTTL(ref.item))
+	}
+	heap.Init(&s.expiry)
+}
+
+// nextToExpire returns the key of the entry that expires first: the front of the timestamp-ordered list,
+// or, with per-entry TTLs, the top of the expiry heap. The caller must hold the lock.
+func (s *Storage[K, V]) nextToExpire() (K, bool) {
+	if s.ttlFn != nil {
+		if len(s.expiry) == 0 {
+			var zero K
+			return zero, false
+		}
+		return s.expiry[0].key, true
+	}
+	oldest := s.byAge.Front()
+	if oldest == nil {
+		var zero K
+		return zero, false
+	}
+	return oldest.Value.(K), true
+}
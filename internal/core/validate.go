@@ -0,0 +1,78 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/osmike/fcache/internal/lib/errs"
+)
+
+// ErrInvalidConfig is returned by Config.Validate for contradictory settings. The constructors panic with it.
+var ErrInvalidConfig = errors.New("invalid cache configuration")
+
+// configConflict is a combination of Config fields that cannot take effect together.
+type configConflict struct {
+	field    string // the field that has no effect or contradicts another
+	conflict string // why
+	invalid  func(cfg *Config) bool
+}
+
+// configConflicts lists the combinations rejected by Validate, in the order they are checked.
+var configConflicts = []configConflict{
+	{"TTL", "NoExpire entries never expire", func(c *Config) bool { return c.NoExpire && c.TTL > 0 }},
+	{"HardTTL", "NoExpire entries never expire", func(c *Config) bool { return c.NoExpire && c.HardTTL > 0 }},
+	{"SoftTTL", "NoExpire entries never expire", func(c *Config) bool { return c.NoExpire && c.SoftTTL > 0 }},
+	{"SlidingTTL", "NoExpire entries never expire", func(c *Config) bool { return c.NoExpire && c.SlidingTTL }},
+	{"TTLFunc", "NoExpire entries never expire", func(c *Config) bool { return c.NoExpire && c.TTLFunc != nil }},
+	{"TTLPrecedence", "TTLFunc is not set", func(c *Config) bool { return c.TTLFunc == nil && c.TTLPrecedence != TTLMin }},
+	{"KeyHasher", "VerifyKeys keys are never hashed", func(c *Config) bool {
+		return c.VerifyKeys && (c.KeyHasher.New != nil || c.KeyHasher.Encode != nil)
+	}},
+	{"StaleTTL", "ServeStaleOnError is not set", func(c *Config) bool { return !c.ServeStaleOnError && c.StaleTTL > 0 }},
+	{"AsyncHookWorkers", "AsyncHooks is not set", func(c *Config) bool { return !c.AsyncHooks && c.AsyncHookWorkers > 0 }},
+	{"AsyncHookQueueSize", "AsyncHooks is not set", func(c *Config) bool { return !c.AsyncHooks && c.AsyncHookQueueSize > 0 }},
+	{"OnFullTimeout", "OnFull is not FullBlock", func(c *Config) bool { return c.OnFull != FullBlock && c.OnFullTimeout > 0 }},
+	{"BreakerCooldown", "BreakerThreshold is not set", func(c *Config) bool {
+		return c.BreakerThreshold <= 0 && c.BreakerCooldown > 0
+	}},
+	{"MemoryLimit", "MemoryPressureReclaim is not set", func(c *Config) bool {
+		return !c.MemoryPressureReclaim && c.MemoryLimit > 0
+	}},
+	{"MemoryCheckInterval", "MemoryPressureReclaim is not set", func(c *Config) bool {
+		return !c.MemoryPressureReclaim && c.MemoryCheckInterval > 0
+	}},
+	{"MemoryPressureReclaim", "MemoryLimit is not set", func(c *Config) bool {
+		return c.MemoryPressureReclaim && c.MemoryLimit == 0
+	}},
+	{"MemoryPressureReclaim", "DisableBackgroundCleanup stops its heap checks", func(c *Config) bool {
+		return c.MemoryPressureReclaim && c.DisableBackgroundCleanup
+	}},
+	{"ConcurrencyFailFast", "MaxConcurrentExecutions is not set", func(c *Config) bool {
+		return c.ConcurrencyFailFast && c.MaxConcurrentExecutions <= 0
+	}},
+	{"CostFunc", "MaxBytes is not set", func(c *Config) bool { return c.MaxBytes <= 0 && c.CostFunc != nil }},
+	{"MaxBytesCheckInterval", "MaxBytes is not set", func(c *Config) bool {
+		return c.MaxBytes <= 0 && c.MaxBytesCheckInterval > 0
+	}},
+}
+
+// Validate reports contradictory settings, which the constructors would otherwise resolve silently:
+// a setting that has no effect because of another one, such as a TTL together with NoExpire.
+// It returns ErrInvalidConfig with the offending field in Fields["field"] and the reason in
+// Fields["conflict"], or nil. Zero values are never reported; they select the defaults.
+//
+// NewCachedFunction and the other constructors panic with this error, since a contradictory
+// configuration is a programming error, like a CloneFunc of the wrong type.
+func (cfg *Config) Validate() error {
+	if cfg == nil {
+		return nil
+	}
+	for _, c := range configConflicts {
+		if c.invalid(cfg) {
+			return errs.NewError(ErrInvalidConfig, map[string]any{
+				"field":    c.field,
+				"conflict": c.conflict,
+			})
+		}
+	}
+	return nil
+}
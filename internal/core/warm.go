@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"runtime"
+
+	"github.com/osmike/fcache/internal/lib/errs"
+)
+
+// ErrWarmCapacity is reported by WarmAndWait for arguments it left cold because warming them
+// would exceed the capacity of the cache.
+var ErrWarmCapacity = errors.New("warming exceeds cache capacity")
+
+// warmResult is the outcome of warming the argument at index i.
+type warmResult struct {
+	i   int
+	err error
+}
+
+// WarmAndWait computes and caches the results for args, e.g. at startup, and blocks until all of them
+// are cached or ctx is done, so a readiness probe can wait for a warm cache. It returns the error of
+// each argument, in the order of args: nil once it is cached (or was already), the error of the
+// function, ErrKeyGeneration, ErrWarmCapacity, or ctx.Err() for arguments not warmed before ctx was done.
+//
+// Arguments are warmed by a bounded number of workers: Config.MaxConcurrentExecutions if set, otherwise
+// GOMAXPROCS. Warming more distinct keys than the capacity would evict the first ones to make room for
+// the last, so only as many as fit are warmed, in order, and the rest get ErrWarmCapacity. Duplicate
+// arguments are warmed once. Computations still running when ctx is done are not interrupted; their
+// results are stored when they return. Warming goes through Call, so hooks, deduplication with
+// concurrent calls, and the other Config options apply as usual.
+func (c *Cache[K, SK, V]) WarmAndWait(ctx context.Context, args []K) []error {
+	results := make([]error, len(args))
+	first := make(map[SK]int, len(args)) // key -> index of its first argument
+	dups := map[int]int{}                // index of a duplicate argument -> index of the first one
+	var jobs []int
+	capacity := c.store.Capacity()
+	for i, arg := range args {
+		key, err := c.keyFn(arg)
+		if err != nil {
+			results[i] = errs.NewError(ErrKeyGeneration, map[string]any{"value": arg, "error": err})
+			continue
+		}
+		if j, ok := first[key]; ok {
+			dups[i] = j
+			continue
+		}
+		if capacity != UnboundedCapacity && len(first) >= capacity {
+			results[i] = errs.NewError(ErrWarmCapacity, map[string]any{"key": keyString(key), "capacity": capacity})
+			continue
+		}
+		first[key] = i
+		if !c.store.Contains(key) {
+			jobs = append(jobs, i)
+		}
+	}
+
+	if len(jobs) > 0 {
+		c.warm(ctx, args, jobs, results)
+	}
+	for i, j := range dups {
+		results[i] = results[j]
+	}
+	return results
+}
+
+// warm runs the calls for args[i], i in jobs, on bounded workers and records their errors in results.
+// When ctx is done it records ctx.Err() for the unfinished ones and returns without waiting for them.
+func (c *Cache[K, SK, V]) warm(ctx context.Context, args []K, jobs []int, results []error) {
+	workers := runtime.GOMAXPROCS(0)
+	if c.cfg.MaxConcurrentExecutions > 0 {
+		workers = c.cfg.MaxConcurrentExecutions
+	}
+	workers = min(workers, len(jobs))
+
+	queue := make(chan int, len(jobs))
+	for _, i := range jobs {
+		queue <- i
+	}
+	close(queue)
+	// buffered, so workers never block on a WarmAndWait that returned early
+	done := make(chan warmResult, len(jobs))
+	for range workers {
+		go func() {
+			for i := range queue {
+				if err := ctx.Err(); err != nil {
+					done <- warmResult{i, err}
+					continue
+				}
+				_, err := c.call(args[i], c.fn)
+				done <- warmResult{i, err}
+			}
+		}()
+	}
+
+	finished := make([]bool, len(results))
+	for range jobs {
+		select {
+		case r := <-done:
+			results[r.i], finished[r.i] = r.err, true
+		case <-ctx.Done():
+			for _, i := range jobs {
+				if !finished[i] {
+					results[i] = ctx.Err()
+				}
+			}
+			return
+		}
+	}
+}
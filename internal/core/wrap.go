@@ -0,0 +1,34 @@
+package core
+
+import "github.com/osmike/fcache/internal/lib/hooks"
+
+// pair boxes the two results of a function wrapped by Wrap2 into a single cacheable value.
+type pair[V1 any, V2 any] struct {
+	first  V1
+	second V2
+}
+
+// WrapMethod caches a method bound to the receiver recv. method is a method expression, such as
+// (*Service).Fetch, so the cached function calls recv.Fetch(arg).
+func WrapMethod[R any, K any, V any](recv R, method func(R, K) (V, error), opts *Config, h *hooks.Hooks) CachedFunc[K, V] {
+	return NewCachedFunction(func(arg K) (V, error) {
+		return method(recv, arg)
+	}, opts, h)
+}
+
+// Wrap2 caches a function returning two values and an error.
+//
+// The results are boxed into a private pair value internally, so keying, TTL, eviction and
+// deduplication work exactly as for NewCachedFunction. Config options that depend on the value
+// type (CloneFunc, ShouldCache, TagFunc, Compress) are not supported, since that type is private.
+func Wrap2[K any, V1 any, V2 any](fn func(K) (V1, V2, error), opts *Config, h *hooks.Hooks) func(K) (V1, V2, error) {
+	boxed := NewCachedFunction(func(arg K) (pair[V1, V2], error) {
+		v1, v2, err := fn(arg)
+		return pair[V1, V2]{first: v1, second: v2}, err
+	}, opts, h)
+
+	return func(arg K) (V1, V2, error) {
+		p, err := boxed(arg)
+		return p.first, p.second, err
+	}
+}
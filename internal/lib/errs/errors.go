@@ -1,25 +1,65 @@
 package errs
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Error is a structured fcache error.
+//
+// It wraps a sentinel error (e.g. ErrPanic, ErrBuildKey) and carries the context fields
+// it was created with, so callers can inspect them programmatically via errors.As.
+type Error struct {
+	Err    error          // sentinel error type
+	Fields map[string]any // additional context (may be nil)
+}
 
 // NewError wraps an error with additional context fields for structured error reporting.
 //
 //   - err: The base error to wrap.
 //   - fields: A map of key-value pairs providing additional context.
 //
-// Returns an error that includes both the original error and the provided fields.
+// Returns an *Error that includes both the original error and the provided fields.
 func NewError(errType error, kv map[string]interface{}) error {
-	if kv == nil {
-		return fmt.Errorf("[fcache error], [%w]", errType)
+	return &Error{Err: errType, Fields: kv}
+}
+
+// Error formats the error and its fields, with fields sorted by key for stable output.
+func (e *Error) Error() string {
+	if e.Fields == nil {
+		return fmt.Sprintf("[fcache error], [%v]", e.Err)
 	}
-	var details string
-	for k, v := range kv {
-		switch val := v.(type) {
+	var details strings.Builder
+	for _, k := range e.sortedKeys() {
+		switch val := e.Fields[k].(type) {
 		case error:
-			details += fmt.Sprintf("%s: %v; ", k, val.Error())
+			fmt.Fprintf(&details, "%s: %v; ", k, val.Error())
 		default:
-			details += fmt.Sprintf("%s: %v; ", k, val)
+			fmt.Fprintf(&details, "%s: %v; ", k, val)
+		}
+	}
+	return fmt.Sprintf("[fcache error], [%v], details: [%s]", e.Err, details.String())
+}
+
+// Unwrap returns the sentinel error followed by any error-valued fields,
+// so errors.Is and errors.As see both the fcache error type and its underlying cause.
+func (e *Error) Unwrap() []error {
+	errs := []error{e.Err}
+	for _, k := range e.sortedKeys() {
+		if err, ok := e.Fields[k].(error); ok {
+			errs = append(errs, err)
 		}
 	}
-	return fmt.Errorf("[fcache error], [%w], details: [%s]", errType, details)
+	return errs
+}
+
+// sortedKeys returns the field names in lexical order.
+func (e *Error) sortedKeys() []string {
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
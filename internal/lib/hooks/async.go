@@ -0,0 +1,97 @@
+package hooks
+
+import (
+	"errors"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrHookQueueFull is reported to LogError when an async hook is dropped because its worker queue is full.
+var ErrHookQueueFull = errors.New("async hook queue is full, hook dropped")
+
+// AsyncRunner dispatches hook invocations to a bounded pool of worker goroutines.
+//
+// Tasks are assigned to workers by key, so hooks for the same key run in submission order.
+// Each worker has a bounded queue; when it is full the task is dropped and ErrHookQueueFull
+// is forwarded to LogError, so a slow hook never blocks the caller.
+type AsyncRunner struct {
+	hooks   *Hooks
+	queues  []chan func()
+	start   sync.Once
+	running atomic.Int32 // worker goroutines alive
+	mu      sync.RWMutex // guards closed against sends on closed queues
+	closed  bool         // Close was called; tasks run synchronously
+}
+
+// NewAsyncRunner creates a runner with the given number of workers and per-worker queue size.
+// Workers are started lazily on the first dispatched task.
+func NewAsyncRunner(h *Hooks, workers, queueSize int) *AsyncRunner {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+	queues := make([]chan func(), workers)
+	for i := range queues {
+		queues[i] = make(chan func(), queueSize)
+	}
+	return &AsyncRunner{hooks: h, queues: queues}
+}
+
+// Go queues task on the worker owning key. After Close, it runs task synchronously instead.
+func (r *AsyncRunner) Go(key string, task func()) {
+	r.mu.RLock()
+	if r.closed {
+		r.mu.RUnlock()
+		task()
+		return
+	}
+	r.start.Do(func() {
+		for _, q := range r.queues {
+			r.running.Add(1)
+			go r.worker(q)
+		}
+	})
+	select {
+	case r.queues[r.index(key)] <- task:
+	default:
+		r.hooks.safeLogError(ErrHookQueueFull)
+	}
+	r.mu.RUnlock()
+}
+
+// Close stops the workers once they have run the tasks already queued. It does not wait for them.
+// Calling Close more than once is a no-op.
+func (r *AsyncRunner) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	r.closed = true
+	for _, q := range r.queues {
+		close(q)
+	}
+}
+
+// index maps key to a worker queue.
+func (r *AsyncRunner) index(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(r.queues)))
+}
+
+// Workers returns the number of worker goroutines alive. Workers are started by the first task.
+func (r *AsyncRunner) Workers() int {
+	return int(r.running.Load())
+}
+
+// worker runs queued tasks one by one.
+func (r *AsyncRunner) worker(q <-chan func()) {
+	defer r.running.Add(-1)
+	for task := range q {
+		task()
+	}
+}
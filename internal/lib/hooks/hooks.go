@@ -3,9 +3,14 @@
 package hooks
 
 import (
+	"errors"
 	"fmt"
+	"time"
 )
 
+// ErrHookTimeout is reported to LogError when a hook runs longer than the hook timeout and is abandoned.
+var ErrHookTimeout = errors.New("hook timed out, result abandoned")
+
 // HookFunc is called on lifecycle events. It receives any number of arguments
 // and may return an error to signal that something went wrong.
 type HookFunc func(arg any) error
@@ -14,13 +19,107 @@ type HookFunc func(arg any) error
 // It must never panic itself.
 type HookFuncError func(err error)
 
+// HookContext carries the details of a cache event to a HookContextFunc.
+// Fields that do not apply to an event are left at their zero value.
+type HookContext struct {
+	Key   string // cache key built from Arg
+	Arg   any    // argument of the cached function
+	Value any    // result value (OnGet, OnSet, OnDone) or removed value (OnEvict, OnRemove)
+	Err   error  // result error (OnDone, OnError)
+
+	Reason RemoveReason // why the entry was removed (OnRemove)
+}
+
+// RemoveReason tells the OnRemove hook why an entry left the cache.
+type RemoveReason int
+
+const (
+	ReasonCapacity RemoveReason = iota // evicted to make room (capacity, MaxBytes or memory reclaim)
+	ReasonTTL                          // expired, found by cleanup, PurgeExpired or a lookup
+	ReasonManual                       // deleted explicitly, e.g. by InvalidateFunc or InvalidateTag
+	ReasonClear                        // removed by Clear
+)
+
+// String returns the name of the reason.
+func (r RemoveReason) String() string {
+	switch r {
+	case ReasonCapacity:
+		return "capacity"
+	case ReasonTTL:
+		return "ttl"
+	case ReasonManual:
+		return "manual"
+	case ReasonClear:
+		return "clear"
+	default:
+		return "unknown"
+	}
+}
+
+// PressureEvent describes cache pressure reported to the OnPressure hook:
+// within Window, capacity evictions outnumbered hits by more than the configured threshold.
+type PressureEvent struct {
+	Window    time.Duration // length of the observation window
+	Evictions uint64        // capacity evictions in the window
+	Hits      uint64        // cache hits in the window
+}
+
+// InflightJoinEvent describes a call that joined a computation already in flight for its key,
+// reported to the OnInflightJoin hook.
+type InflightJoinEvent struct {
+	Key     string // cache key being computed
+	Arg     any    // argument of the joining call
+	Waiters int    // calls waiting for the computation so far, including this one (the leader not counted)
+}
+
+// HookContextFunc is called on lifecycle events with the full event context.
+// It may return an error to signal that something went wrong.
+type HookContextFunc func(hc HookContext) error
+
 // Hooks holds the set of lifecycle hooks and an error‐logging hook.
+//
+// Each event has an argument-only hook (e.g. OnSet) and a context hook (e.g. OnSetContext)
+// that also receives the cache key and result. Both are called if both are set.
+//
+// Hooks run without any cache lock held, so they may call back into the cache. The exceptions are
+// OnExecute (and OnExecuteContext), which runs while the call is in flight, so calling the cache with
+// the same argument from it waits for its own result and deadlocks, and OnInflightJoin, which would
+// join the computation again and recurse.
 type Hooks struct {
 	OnSet     HookFunc      // called after a Set operation
 	OnGet     HookFunc      // called after a Get operation
 	OnExecute HookFunc      // called after a function execution
 	OnDone    HookFunc      // called after a function execution is done
-	LogError  HookFuncError // called on any hook error or panic
+	LogError  HookFuncError // called on any hook error or panic, and (for compatibility) on function errors
+
+	// OnError is called when the cached function itself returns an error or panics,
+	// with the key, argument and error. Unlike LogError it never fires for hook failures.
+	OnError HookContextFunc
+
+	// OnPressure is called with a PressureEvent when capacity evictions outpace hits,
+	// signalling that Capacity is too small for the working set. It fires at most once per window.
+	OnPressure HookFunc
+
+	// OnInflightJoin is called with an InflightJoinEvent when a call finds its key already being computed
+	// and waits for that result instead of executing the function (OnExecute fires for the leader only).
+	// It runs before the call starts waiting, so like OnExecute it must not call the cache with the same argument.
+	OnInflightJoin HookFunc
+
+	// OnEvict is called after an entry is evicted to make room for a new one, with the key and
+	// the evicted value, e.g. to close resources held by the value. Arg is not known at eviction time.
+	OnEvict HookContextFunc
+
+	// OnRemove is called after any entry leaves the cache, with the key, the removed value and
+	// the Reason: eviction, expiry, explicit deletion, or Clear. Use it to release resources held by
+	// cached values in one place. Overwrites are not removals, and Cache.Evict hands the values to
+	// its caller instead. Removals are reported after the storage lock is released, so an entry
+	// may be observed as gone shortly before its hook runs.
+	OnRemove HookContextFunc
+
+	OnSetContext     HookContextFunc // like OnSet, with key and stored value
+	OnGetContext     HookContextFunc // like OnGet, with key and cached value
+	OnExecuteContext HookContextFunc // like OnExecute, with key
+	OnDoneContext    HookContextFunc // like OnDone, with key, result value and error
 }
 
 // Run executes the given hook fn with the provided args.
@@ -44,6 +143,69 @@ func (h *Hooks) Run(fn HookFunc, arg any) {
 	}
 }
 
+// RunTimeout is like Run, but stops waiting for fn after timeout and reports ErrHookTimeout to LogError.
+// The abandoned hook keeps running in its own goroutine; a later error or panic is still recovered
+// and reported. A timeout <= 0 runs fn inline, like Run.
+func (h *Hooks) RunTimeout(fn HookFunc, arg any, timeout time.Duration) {
+	if fn == nil {
+		return
+	}
+	h.withTimeout(timeout, func() { h.Run(fn, arg) })
+}
+
+// RunContextTimeout is like RunContext, with the timeout handling of RunTimeout.
+func (h *Hooks) RunContextTimeout(fn HookContextFunc, hc HookContext, timeout time.Duration) {
+	if fn == nil {
+		return
+	}
+	h.withTimeout(timeout, func() { h.RunContext(fn, hc) })
+}
+
+// withTimeout runs run in a new goroutine and waits for it for up to timeout, or inline if timeout <= 0.
+func (h *Hooks) withTimeout(timeout time.Duration, run func()) {
+	if timeout <= 0 {
+		run()
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run()
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		h.safeLogError(ErrHookTimeout)
+	}
+}
+
+// LogErrorSafe forwards err to the LogError hook if set, recovering if LogError panics.
+func (h *Hooks) LogErrorSafe(err error) {
+	h.safeLogError(err)
+}
+
+// RunContext executes the given context hook fn with the provided event context.
+// Errors and panics are handled the same way as in Run.
+func (h *Hooks) RunContext(fn HookContextFunc, hc HookContext) {
+	if fn == nil {
+		return
+	}
+
+	// catch panics in the hook
+	defer func() {
+		if r := recover(); r != nil {
+			h.safeLogError(toError(r))
+		}
+	}()
+
+	// run the hook
+	if err := fn(hc); err != nil {
+		h.safeLogError(err)
+	}
+}
+
 // safeLogError calls the LogError hook if set, and recovers if it panics.
 func (h *Hooks) safeLogError(err error) {
 	if h.LogError == nil {
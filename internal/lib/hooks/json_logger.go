@@ -0,0 +1,127 @@
+package hooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/osmike/fcache/internal/lib/errs"
+)
+
+// JSONLogger writes cache events as JSON lines, one object per event, built purely on the hook types:
+// its methods are hooks to set in Hooks, or Hooks returns a set wired to them.
+//
+// Each line has the time (RFC 3339, UTC) and event name, and where they apply the cache key, the removal
+// reason, and the error. For a structured fcache error, the error is its message, "type" is its sentinel,
+// and "fields" its context fields, stringified, like the Fields of errs.Error. The arguments and values
+// passed to hooks are not written, since they may be large or sensitive, but the fields of an error may
+// include an argument (e.g. "value" of ErrKeyGeneration). Lines are written whole, so concurrent hooks
+// may share the logger.
+type JSONLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// jsonLogLine is a line written by JSONLogger.
+type jsonLogLine struct {
+	Time   string         `json:"time"`
+	Event  string         `json:"event"`
+	Key    string         `json:"key,omitempty"`
+	Reason string         `json:"reason,omitempty"`
+	Error  string         `json:"error,omitempty"`
+	Type   string         `json:"type,omitempty"`
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+// NewJSONLogger returns a JSONLogger writing to w. Write errors are ignored, as a logging hook must not fail.
+func NewJSONLogger(w io.Writer) *JSONLogger {
+	return &JSONLogger{enc: json.NewEncoder(w)}
+}
+
+// Hooks returns hooks wired to the logger: LogError ("error" events, for function errors and failing
+// hooks alike), OnRemove ("remove" events with the reason, including evictions) and OnPressure
+// ("pressure" events). Set more events with Event, e.g. OnSetContext: l.Event("set").
+func (l *JSONLogger) Hooks() *Hooks {
+	return &Hooks{
+		LogError:   l.LogError,
+		OnRemove:   l.Remove,
+		OnPressure: l.Hook("pressure"),
+	}
+}
+
+// LogError writes an "error" event; it is a HookFuncError for Hooks.LogError.
+func (l *JSONLogger) LogError(err error) {
+	line := jsonLogLine{Event: "error"}
+	line.setError(err)
+	l.write(line)
+}
+
+// Remove writes a "remove" event with the key and reason; it is a HookContextFunc for Hooks.OnRemove.
+func (l *JSONLogger) Remove(hc HookContext) error {
+	l.write(jsonLogLine{Event: "remove", Key: hc.Key, Reason: hc.Reason.String()})
+	return nil
+}
+
+// Event returns a HookContextFunc writing an event of the given name with the key and error, if any,
+// e.g. OnEvict: l.Event("evict") or OnError: l.Event("error").
+func (l *JSONLogger) Event(name string) HookContextFunc {
+	return func(hc HookContext) error {
+		line := jsonLogLine{Event: name, Key: hc.Key}
+		line.setError(hc.Err)
+		l.write(line)
+		return nil
+	}
+}
+
+// Hook returns a HookFunc writing an event of the given name, e.g. OnInflightJoin: l.Hook("join").
+// The details of an InflightJoinEvent or PressureEvent argument are written too; other arguments are not.
+func (l *JSONLogger) Hook(name string) HookFunc {
+	return func(arg any) error {
+		line := jsonLogLine{Event: name}
+		switch ev := arg.(type) {
+		case InflightJoinEvent:
+			line.Key = ev.Key
+			line.Fields = map[string]any{"waiters": ev.Waiters}
+		case PressureEvent:
+			line.Fields = map[string]any{"window": ev.Window.String(), "evictions": ev.Evictions, "hits": ev.Hits}
+		}
+		l.write(line)
+		return nil
+	}
+}
+
+// setError sets the error of the line, with the sentinel and fields of a structured fcache error.
+func (line *jsonLogLine) setError(err error) {
+	if err == nil {
+		return
+	}
+	line.Error = err.Error()
+	var e *errs.Error
+	if !errors.As(err, &e) {
+		return
+	}
+	line.Type = fmt.Sprint(e.Err)
+	if len(e.Fields) > 0 {
+		line.Fields = make(map[string]any, len(e.Fields))
+		for k, v := range e.Fields {
+			// values are stringified, since arbitrary values (e.g. arguments) may not marshal
+			switch v := v.(type) {
+			case error:
+				line.Fields[k] = v.Error()
+			default:
+				line.Fields[k] = fmt.Sprint(v)
+			}
+		}
+	}
+}
+
+// write encodes line as one JSON line, stamped with the current time.
+func (l *JSONLogger) write(line jsonLogLine) {
+	line.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_ = l.enc.Encode(line)
+}
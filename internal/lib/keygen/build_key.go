@@ -11,6 +11,11 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/osmike/fcache/internal/lib/errs"
 )
@@ -18,6 +23,9 @@ import (
 // Maximum length for string keys before hashing
 const maxLen = 100
 
+// stringerType is the reflect type of fmt.Stringer.
+var stringerType = reflect.TypeFor[fmt.Stringer]()
+
 var (
 	// ErrMarshallJSON indicates a failure to marshal a value to JSON.
 	ErrMarshallJSON = fmt.Errorf("error marshalling to JSON")
@@ -26,6 +34,64 @@ var (
 	ErrBuildKey = fmt.Errorf("error building cache key")
 )
 
+// Builder builds cache keys with optional customizations. The zero value behaves like BuildKey.
+type Builder struct {
+	// ContextKey, if set, derives the key of a context.Context value, e.g. from a tenant ID it carries.
+	// It must be deterministic. If nil, all contexts share the "context" placeholder key.
+	ContextKey func(ctx context.Context) string
+
+	// SortSlices, if set, sorts a slice or array argument of ordered elements (integers except bytes,
+	// floats, strings) before encoding, so arguments representing sets share a key regardless of element order.
+	// Only the top-level value is sorted; slices nested in structs or maps keep their order.
+	SortSlices bool
+
+	// Hasher, if set, hashes keys that are too long to be used as is, instead of hex-encoded SHA-256.
+	Hasher Hasher
+
+	// Verbatim, if set, never hashes: keys are the full encoding of the value, however long, so values with
+	// different encodings can never share a key through a hash collision. Hasher is then unused.
+	Verbatim bool
+
+	// Fields, if set, keys a struct value by the named fields only, e.g. an ID and a tenant, ignoring
+	// volatile fields such as timestamps. Naming a field the value does not have is an error (see CheckFields).
+	Fields []string
+}
+
+// Hasher hashes long cache keys, e.g. to line them up with the identifiers of a content-addressable store.
+// A nil field keeps its default.
+type Hasher struct {
+	// New returns the hash function digesting the encoded key (default: sha256.New).
+	New func() hash.Hash
+	// Encode turns the digest into the key string, e.g. base64 or a multihash (default: hex.EncodeToString).
+	Encode func(sum []byte) string
+}
+
+// hash returns the key of data, which is too long to be used as is.
+func (h Hasher) hash(data []byte) string {
+	if h.New == nil && h.Encode == nil {
+		return hashBytes(data)
+	}
+	hh := h.newHash()
+	hh.Write(data)
+	return h.encode(hh.Sum(nil))
+}
+
+// newHash returns a new instance of the hash function.
+func (h Hasher) newHash() hash.Hash {
+	if h.New == nil {
+		return sha256.New()
+	}
+	return h.New()
+}
+
+// encode returns the key string of a digest.
+func (h Hasher) encode(sum []byte) string {
+	if h.Encode == nil {
+		return hex.EncodeToString(sum)
+	}
+	return h.Encode(sum)
+}
+
 // BuildKey returns a deterministic string key for caching based on the provided value.
 //
 //   - value: Any value to be encoded as a cache key. Supports primitives, strings, fmt.Stringer, slices, maps, structs, etc.
@@ -33,7 +99,16 @@ var (
 // The key is deterministic for the same input value. If the encoded key exceeds maxLen, it is hashed to ensure a consistent length.
 // Returns an error if the value cannot be encoded.
 func BuildKey(value any) (string, error) {
-	encoded, err := encodeValue(value)
+	return Builder{}.BuildKey(value)
+}
+
+// BuildKey is like the package-level BuildKey, applying the Builder's customizations.
+func (b Builder) BuildKey(value any) (string, error) {
+	encode := b.encodeValue
+	if len(b.Fields) > 0 {
+		encode = b.encodeFields
+	}
+	encoded, err := encode(value)
 	if err != nil {
 		return "", errs.NewError(ErrBuildKey, map[string]interface{}{
 			"operation": "building cache key",
@@ -41,94 +116,276 @@ func BuildKey(value any) (string, error) {
 			"error":     err,
 		})
 	}
-	if len(encoded) > maxLen {
-		// If the concatenated string is too long, hash it to ensure a consistent key
-		return hashBytes([]byte(encoded)), nil
-	}
-
+	// encodeValue already hashed encodings longer than maxLen; the digest itself is not hashed again,
+	// even if a custom Hasher makes it longer than maxLen
 	return encoded, nil
 }
 
 // encodeValue encodes a single value into a string suitable for use as a cache key.
 //
 // Handles primitive types, strings, fmt.Stringer, and complex types (slices, maps, structs).
-// For context.Context, returns a placeholder string.
+// Pointers are dereferenced, so they share the key of the value they point to; nil pointers of
+// any type share the "nil" key of an untyped nil. A value whose type implements fmt.Stringer only
+// on its pointer type is keyed by String, like a pointer to it.
+//
+// Any fmt.Stringer, scalar or composite, is keyed by its String result alone, which is what lets types
+// with unexported fields be keyed at all. String must therefore be injective: two values that are
+// not equal must not print the same, or they silently share a cache entry. A lossy String, such as
+// one printing only a name while the type also carries an ID, collides; CheckKeyCollision finds such cases.
+// For context.Context, returns a placeholder string, or the Builder's ContextKey of it. A time.Time, or a
+// pointer to one, is keyed by its instant alone. Context and time fields of composite values are keyed
+// the same way (see normalized).
 // If the encoded string is too long, it is hashed.
 // Returns an error if encoding fails.
-func encodeValue(v interface{}) (string, error) {
+func (b Builder) encodeValue(v interface{}) (string, error) {
 	switch val := v.(type) {
 	// Primitive types and basic values
 	case nil:
 		return "nil", nil
 
 	case context.Context:
-		// For context, we return a placeholder since contexts are not serializable
-		return "context", nil
+		return b.encodeString(b.contextKey(val))
+
+	// Integers are formatted with strconv rather than fmt: it returns constant strings for values
+	// below 100, so warm hits with small integer keys don't allocate.
+	case int:
+		return strconv.Itoa(val), nil
+	case int8:
+		return strconv.FormatInt(int64(val), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(val), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(val), 10), nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(val), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(val), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(val), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(val), 10), nil
+	case uint64:
+		return strconv.FormatUint(val, 10), nil
+	case uintptr:
+		return strconv.FormatUint(uint64(val), 10), nil
 
-	case int, int8, int16, int32, int64,
-		uint, uint8, uint16, uint32, uint64, uintptr,
-		float32, float64:
-		return fmt.Sprint(val), nil
+	case float32:
+		return encodeFloat(float64(val)), nil
+
+	case float64:
+		return encodeFloat(val), nil
 
 	case bool:
-		return "b:" + fmt.Sprint(val), nil
+		if val {
+			return "b:true", nil
+		}
+		return "b:false", nil
 
 	case string:
-		return encodeString("s:" + val)
+		return b.encodeString("s:" + val)
+
+	case time.Time:
+		// Key on the instant only: monotonic clock reading and location don't affect the key
+		return timeKey(val), nil
+
+	case *time.Time:
+		// matched before fmt.Stringer, whose String would include the monotonic reading and zone
+		if val == nil {
+			return "nil", nil
+		}
+		return timeKey(*val), nil
 
 	case fmt.Stringer:
+		if isNilPointer(val) {
+			// calling String on a nil receiver may panic
+			return "nil", nil
+		}
 		s := val.String()
-		return encodeString("s:" + s)
+		return b.encodeString("s:" + s)
 
 	// Collections and complex types
 	default:
-		return encodeComplex(val)
+		rv := reflect.ValueOf(val)
+		if rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return "nil", nil
+			}
+			// Pointers are keyed by the value they point to
+			return b.encodeValue(rv.Elem().Interface())
+		}
+		if reflect.PointerTo(rv.Type()).Implements(stringerType) {
+			// String has a pointer receiver: call it on a copy, so T and *T share a key
+			ptr := reflect.New(rv.Type())
+			ptr.Elem().Set(rv)
+			return b.encodeValue(ptr.Interface())
+		}
+		if b.SortSlices {
+			if sorted, ok := sortedSlice(rv); ok {
+				return b.encodeComplex(sorted.Interface())
+			}
+		}
+		if conv, ok := b.normalized(rv); ok {
+			// context and time fields are keyed like context and time arguments
+			return b.encodeComplex(conv.Interface())
+		}
+		return b.encodeComplex(val)
 	}
 }
 
+// timeKey returns the key of a time: its instant in UTC, without the monotonic reading and location.
+func timeKey(t time.Time) string {
+	return "t:" + t.UTC().Format(time.RFC3339Nano)
+}
+
+// contextKey returns the key of a context: a placeholder, since contexts are not serializable,
+// or "c:" and the Builder's ContextKey of it, if the caller opted in to partition keys by a value
+// carried in the context.
+func (b Builder) contextKey(ctx context.Context) string {
+	if b.ContextKey != nil {
+		return "c:" + b.ContextKey(ctx)
+	}
+	return "context"
+}
+
+// sortedSlice returns a sorted copy of a slice or array of ordered elements, as a slice.
+// It reports false for other values, which keep their order.
+func sortedSlice(rv reflect.Value) (reflect.Value, bool) {
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return rv, false
+	}
+	var less func(a, b reflect.Value) bool
+	switch rv.Type().Elem().Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		less = func(a, b reflect.Value) bool { return a.Int() < b.Int() }
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		// byte slices are data, not sets, so uint8 elements are left out
+		less = func(a, b reflect.Value) bool { return a.Uint() < b.Uint() }
+	case reflect.Float32, reflect.Float64:
+		less = func(a, b reflect.Value) bool { return a.Float() < b.Float() }
+	case reflect.String:
+		less = func(a, b reflect.Value) bool { return a.String() < b.String() }
+	default:
+		return rv, false
+	}
+	if rv.Kind() == reflect.Slice && rv.IsNil() {
+		return rv, true
+	}
+
+	sorted := reflect.MakeSlice(reflect.SliceOf(rv.Type().Elem()), rv.Len(), rv.Len())
+	reflect.Copy(sorted, rv)
+	sort.SliceStable(sorted.Interface(), func(i, j int) bool {
+		return less(sorted.Index(i), sorted.Index(j))
+	})
+	return sorted, true
+}
+
+// isNilPointer reports whether v is a nil pointer of some concrete type.
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// encodeFloat encodes a float value for use as a cache key.
+//
+// The value is formatted in exact binary form (mantissa "p" exponent), so distinct floats never
+// share a key, and tagged with "f:" so floats don't collide with integers of the same value.
+// float32 values are keyed by their exact float64 value. All NaNs share the key "f:NaN",
+// and the infinities are "f:+Inf" and "f:-Inf"; +0 and -0 get distinct keys.
+func encodeFloat(f float64) string {
+	return "f:" + strconv.FormatFloat(f, 'b', -1, 64)
+}
+
 // encodeString encodes a string value for use as a cache key.
 //
 // If the string exceeds maxLen, it is hashed to ensure a consistent key length.
 // Otherwise, returns the string as is.
-func encodeString(s string) (string, error) {
-	if len(s) > maxLen {
-		return hashBytes([]byte(s)), nil
+func (b Builder) encodeString(s string) (string, error) {
+	if len(s) > maxLen && !b.Verbatim {
+		return b.Hasher.hash([]byte(s)), nil
 	}
 	return s, nil
 }
 
 // encodeComplex encodes complex types (slices, maps, structs) for use as a cache key.
 //
-// Marshals the value to JSON. For maps, always hashes the JSON to ignore key order.
+// Encodes the value to JSON. For maps, always hashes the JSON to ignore key order.
 // For slices/arrays, hashes if the JSON is too long. For other types, returns the JSON string directly if short enough.
-// Returns an error if marshaling fails.
-func encodeComplex(v interface{}) (string, error) {
-	data, err := json.Marshal(v)
-	if err != nil {
+// The JSON is streamed into the hash rather than marshalled into a byte slice first, so a large
+// argument is not copied out of the encoder just to be hashed (see keyWriter).
+// Returns an error if encoding fails.
+func (b Builder) encodeComplex(v interface{}) (string, error) {
+	_, always := v.(map[string]interface{}) // for maps, we hash the JSON to ignore key order
+	w := keyWriter{hasher: b.Hasher, always: always && !b.Verbatim, verbatim: b.Verbatim}
+	if err := json.NewEncoder(&w).Encode(v); err != nil {
 		return "", errs.NewError(ErrMarshallJSON, map[string]interface{}{
 			"operation": "encoding complex value to build cache key",
 			"value":     v,
 			"error":     err,
 		})
 	}
+	if always && b.Verbatim {
+		// unhashed maps would otherwise share keys with structs of the same JSON
+		return "m:" + w.key(), nil
+	}
+	return w.key(), nil
+}
 
-	switch v.(type) {
-	case map[string]interface{}:
-		// for maps, we hash the JSON to ignore key order
-		return hashBytes(data), nil
-	default:
-		// for slices, arrays, and other types
-		if shouldHashData(data) {
-			return hashBytes(data), nil
-		}
-		// for other types, return the JSON string directly
-		return string(data), nil
+// keyWriter receives the JSON encoding of a value from a json.Encoder and builds its key.
+//
+// It keeps the encoding itself while it is short enough to be the key, and switches to hashing once
+// it grows longer than maxLen (or from the start, for values that are always hashed). The encoder's
+// trailing newline is held back and left out, so keys equal those of the marshalled JSON.
+//
+// encoding/json still encodes each value into a pooled buffer before writing it, so the memory
+// saved is the per-call copy of the whole encoding made by json.Marshal, not the encoding itself.
+type keyWriter struct {
+	hasher   Hasher
+	always   bool      // hash even a short encoding
+	verbatim bool      // never hash (Builder.Verbatim)
+	head     []byte    // the encoding so far, while it may still be the key
+	h        hash.Hash // digest of the encoding, once it is hashed
+	last     byte      // last byte written, held back since it may be the trailing newline
+	hasLast  bool
+}
+
+// Write implements io.Writer.
+func (w *keyWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if w.hasLast {
+		w.write([]byte{w.last})
+	}
+	w.write(p[:len(p)-1])
+	w.last, w.hasLast = p[len(p)-1], true
+	return len(p), nil
+}
+
+// write adds data to the encoding, switching to hashing when it gets too long.
+func (w *keyWriter) write(data []byte) {
+	if w.h == nil && (w.always || (!w.verbatim && len(w.head)+len(data) > maxLen)) {
+		w.h = w.hasher.newHash()
+		w.h.Write(w.head)
+		w.head = nil
+	}
+	if w.h != nil {
+		w.h.Write(data)
+		return
 	}
+	w.head = append(w.head, data...)
 }
 
-// shouldHashData returns true if the JSON representation of a value is too long for a cache key.
-func shouldHashData(data []byte) bool {
-	return len(data) > maxLen
+// key returns the key of the encoding written so far, without the trailing newline.
+func (w *keyWriter) key() string {
+	if w.h == nil && w.always {
+		w.write(nil) // an empty encoding is still hashed
+	}
+	if w.h != nil {
+		return w.hasher.encode(w.h.Sum(nil))
+	}
+	return string(w.head)
 }
 
 // hashBytes hashes the byte slice using SHA-256 and returns the hex string.
@@ -0,0 +1,69 @@
+package keygen
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// CheckFields reports an error if a value of type t cannot be keyed by the named fields (Builder.Fields):
+// t must be a struct or a pointer to one, and each name must be an exported field of it, possibly promoted
+// from an embedded struct. Interface types are only checked when keys are built.
+func CheckFields(t reflect.Type, fields []string) error {
+	if t.Kind() == reflect.Interface {
+		return nil
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("key fields need a struct argument, got %s", t)
+	}
+	for _, name := range fields {
+		f, ok := t.FieldByName(name)
+		if !ok {
+			return fmt.Errorf("type %s has no field %q", t, name)
+		}
+		if !f.IsExported() {
+			return fmt.Errorf("field %q of type %s is unexported", name, t)
+		}
+	}
+	return nil
+}
+
+// encodeFields encodes the Builder's Fields of a struct value, in the order they are named, ignoring the
+// other fields. Each field is encoded like an argument of its own, and the encodings are joined as a JSON
+// array, so the boundaries between them stay unambiguous. A nil pointer is keyed like nil.
+func (b Builder) encodeFields(v any) (string, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return "nil", nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return "nil", nil
+	}
+	if err := CheckFields(rv.Type(), b.Fields); err != nil {
+		return "", err
+	}
+	parts := make([]string, len(b.Fields))
+	for i, name := range b.Fields {
+		sf, _ := rv.Type().FieldByName(name)
+		f, err := rv.FieldByIndexErr(sf.Index)
+		if err != nil {
+			// promoted through a nil embedded pointer
+			parts[i] = "nil"
+			continue
+		}
+		if parts[i], err = b.encodeValue(f.Interface()); err != nil {
+			return "", err
+		}
+	}
+	encoded, err := json.Marshal(parts)
+	if err != nil {
+		return "", err
+	}
+	return b.encodeString("k:" + string(encoded))
+}
@@ -0,0 +1,207 @@
+package keygen
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// contextType is the reflect type of context.Context.
+var contextType = reflect.TypeFor[context.Context]()
+
+// timeType is the reflect type of time.Time.
+var timeType = reflect.TypeFor[time.Time]()
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// normalizedTypes caches, per type, the type its values are converted to by normalized,
+// or nil if they are encoded as they are.
+var normalizedTypes sync.Map // reflect.Type -> reflect.Type
+
+// normalized returns a copy of a composite value in which every field of static type context.Context
+// or time.Time, also in nested structs, pointers, slices, arrays and map values, is replaced by its key:
+// for a context, the "context" placeholder, "c:" and the Builder's ContextKey of it, or "nil"; for a
+// time, its instant like a time argument. Marshalling a context would otherwise encode its internals,
+// or fail on them, and marshalling a time would keep its zone offset, so equal instants in different
+// locations would get different keys.
+//
+// The copy has the same JSON encoding as the value apart from the contexts and times. It reports false if
+// the value carries no such fields, or its type cannot be rebuilt, e.g. a context inside an embedded
+// struct or a recursive type; such values are encoded as they are. Contexts held in fields of
+// interface types other than context.Context, and times used as map keys, are not normalized.
+func (b Builder) normalized(rv reflect.Value) (reflect.Value, bool) {
+	conv := convertedType(rv.Type())
+	if conv == nil {
+		return rv, false
+	}
+	return b.convertValue(rv, conv), true
+}
+
+// convertedType returns the normalized type of t, or nil if t needs no conversion or cannot be converted.
+func convertedType(t reflect.Type) reflect.Type {
+	if conv, ok := normalizedTypes.Load(t); ok {
+		conv, _ := conv.(reflect.Type) // nil for types encoded as they are
+		return conv
+	}
+	conv := func() (conv reflect.Type) {
+		defer func() {
+			if recover() != nil {
+				conv = nil // reflect.StructOf rejected a field, e.g. an embedded type with methods
+			}
+		}()
+		conv, _ = convertType(t, map[reflect.Type]bool{})
+		return conv
+	}()
+	normalizedTypes.Store(t, conv)
+	return conv
+}
+
+// convertType returns the normalized type of t and whether it differs from t. It panics if t
+// carries contexts or times but cannot be converted; visiting holds the types being converted.
+func convertType(t reflect.Type, visiting map[reflect.Type]bool) (reflect.Type, bool) {
+	if t == contextType || t == timeType {
+		return reflect.TypeFor[string](), true
+	}
+	// pointers are left to the check of their element, since *time.Time has the methods of time.Time
+	if t.Kind() != reflect.Pointer && (t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		reflect.PointerTo(t).Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)) {
+		return t, false // custom encodings are kept
+	}
+	if visiting[t] {
+		return t, false // a recursive type; its conversion is decided where the recursion started
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if elem, changed := convertType(t.Elem(), visiting); changed {
+			return reflect.PointerTo(elem), true
+		}
+	case reflect.Slice:
+		if elem, changed := convertType(t.Elem(), visiting); changed {
+			return reflect.SliceOf(elem), true
+		}
+	case reflect.Array:
+		if elem, changed := convertType(t.Elem(), visiting); changed {
+			return reflect.ArrayOf(t.Len(), elem), true
+		}
+	case reflect.Map:
+		if elem, changed := convertType(t.Elem(), visiting); changed {
+			return reflect.MapOf(t.Key(), elem), true
+		}
+	case reflect.Struct:
+		return convertStruct(t, visiting)
+	}
+	return t, false
+}
+
+// convertStruct implements convertType for structs. Unexported fields, which encoding/json skips,
+// are left out of the converted type.
+func convertStruct(t reflect.Type, visiting map[reflect.Type]bool) (reflect.Type, bool) {
+	changed := false
+	fields := make([]reflect.StructField, 0, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		conv, fieldChanged := convertType(f.Type, visiting)
+		if fieldChanged {
+			changed = true
+			if f.Anonymous && f.Type != contextType {
+				panic("keygen: context in an embedded struct") // its fields would no longer be promoted
+			}
+			f.Type, f.Anonymous = conv, false
+		}
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		f.Index, f.Offset = nil, 0
+		fields = append(fields, f)
+	}
+	if !changed {
+		return t, false
+	}
+	if hasRecursion(t) {
+		panic("keygen: context or time in a recursive type")
+	}
+	return reflect.StructOf(fields), true
+}
+
+// hasRecursion reports whether struct type t refers to itself through its fields.
+func hasRecursion(t reflect.Type) bool {
+	var refers func(u reflect.Type, seen map[reflect.Type]bool) bool
+	refers = func(u reflect.Type, seen map[reflect.Type]bool) bool {
+		if seen[u] {
+			return false
+		}
+		seen[u] = true
+		switch u.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			return u.Elem() == t || refers(u.Elem(), seen)
+		case reflect.Struct:
+			for i := range u.NumField() {
+				if ft := u.Field(i).Type; ft == t || refers(ft, seen) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return refers(t, map[reflect.Type]bool{})
+}
+
+// convertValue copies rv into a value of its normalized type conv, replacing contexts and times by their keys.
+func (b Builder) convertValue(rv reflect.Value, conv reflect.Type) reflect.Value {
+	if rv.Type() == conv {
+		return rv
+	}
+	out := reflect.New(conv).Elem()
+	if rv.Type() == timeType {
+		out.SetString(timeKey(rv.Interface().(time.Time)))
+		return out
+	}
+	switch rv.Kind() {
+	case reflect.Interface: // a context.Context field
+		if rv.IsNil() {
+			out.SetString("nil")
+		} else {
+			out.SetString(b.contextKey(rv.Interface().(context.Context)))
+		}
+	case reflect.Pointer:
+		if !rv.IsNil() {
+			ptr := reflect.New(conv.Elem())
+			ptr.Elem().Set(b.convertValue(rv.Elem(), conv.Elem()))
+			out.Set(ptr)
+		}
+	case reflect.Slice:
+		if !rv.IsNil() {
+			out.Set(reflect.MakeSlice(conv, rv.Len(), rv.Len()))
+			for i := range rv.Len() {
+				out.Index(i).Set(b.convertValue(rv.Index(i), conv.Elem()))
+			}
+		}
+	case reflect.Array:
+		for i := range rv.Len() {
+			out.Index(i).Set(b.convertValue(rv.Index(i), conv.Elem()))
+		}
+	case reflect.Map:
+		if !rv.IsNil() {
+			out.Set(reflect.MakeMapWithSize(conv, rv.Len()))
+			for iter := rv.MapRange(); iter.Next(); {
+				out.SetMapIndex(iter.Key(), b.convertValue(iter.Value(), conv.Elem()))
+			}
+		}
+	case reflect.Struct:
+		for i := range conv.NumField() {
+			f := conv.Field(i)
+			src, _ := rv.Type().FieldByName(f.Name)
+			out.Field(i).Set(b.convertValue(rv.FieldByIndex(src.Index), f.Type))
+		}
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestTinyLFUKeepsHotEntriesDuringScan(t *testing.T) {
+	fn := func(key int) (int, error) {
+		return key, nil
+	}
+
+	h := fcache.NewHandleComparable(fn, &fcache.Config{
+		TTL:             time.Minute,
+		Capacity:        2,
+		AdmissionPolicy: fcache.NewTinyLFU(2),
+	}, &fcache.Hooks{})
+
+	// Make keys 1 and 2 hot
+	for i := 0; i < 5; i++ {
+		h.Call(1)
+		h.Call(2)
+	}
+
+	// A scan of one-off keys must not displace the hot entries
+	for key := 100; key < 110; key++ {
+		if v, err := h.Call(key); err != nil || v != key {
+			t.Fatalf("scan call(%d) = (%d, %v); want (%d, nil)", key, v, err, key)
+		}
+	}
+
+	if !h.Contains(1) || !h.Contains(2) {
+		t.Errorf("hot entries evicted by scan: contains 1=%v 2=%v", h.Contains(1), h.Contains(2))
+	}
+	if h.Contains(109) {
+		t.Error("one-off scan key was admitted into the full cache")
+	}
+}
@@ -0,0 +1,53 @@
+package test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestAsyncHooksDoNotBlockCaller(t *testing.T) {
+	fn := func(key int) (int, error) {
+		return key, nil
+	}
+
+	var mu sync.Mutex
+	var events []string
+	record := func(name string) func(arg any) error {
+		return func(arg any) error {
+			time.Sleep(20 * time.Millisecond) // slow hook, e.g. a remote write
+			mu.Lock()
+			events = append(events, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	cache := fcache.NewCachedFunction(fn, &fcache.Config{
+		TTL:        time.Minute,
+		Capacity:   10,
+		AsyncHooks: true,
+	}, &fcache.Hooks{
+		OnExecute: record("execute"),
+		OnDone:    record("done"),
+		OnSet:     record("set"),
+	})
+
+	start := time.Now()
+	if v, err := cache(1); err != nil || v != 1 {
+		t.Fatalf("call = (%d, %v); want (1, nil)", v, err)
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("call took %v; hooks should not block the caller", elapsed)
+	}
+
+	// Hooks for the same key run in order on the background worker
+	time.Sleep(150 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 3 || events[0] != "execute" || events[1] != "done" || events[2] != "set" {
+		t.Errorf("events = %v; want [execute done set]", events)
+	}
+}
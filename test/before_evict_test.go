@@ -0,0 +1,83 @@
+package test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+var errDirty = errors.New("not persisted yet")
+
+func TestBeforeEvictVetoKeepsEntry(t *testing.T) {
+	var mu sync.Mutex
+	var flushed []int
+	h := fcache.NewHandle(func(n int) (int, error) { return n, nil }, &fcache.Config{
+		Capacity: 2,
+		BeforeEvict: func(key string, val int) error {
+			if val == 1 {
+				return errDirty // the least recently used entry refuses
+			}
+			mu.Lock()
+			flushed = append(flushed, val)
+			mu.Unlock()
+			return nil
+		},
+	}, nil)
+
+	h.Call(1)
+	h.Call(2)
+	if _, err := h.Call(3); err != nil {
+		t.Fatalf("Call(3) = %v; want the next candidate evicted", err)
+	}
+	if !h.Contains(1) || h.Contains(2) || !h.Contains(3) {
+		t.Errorf("Contains(1, 2, 3) = %v, %v, %v; want the vetoed 1 kept and 2 evicted instead",
+			h.Contains(1), h.Contains(2), h.Contains(3))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(flushed) != 1 || flushed[0] != 2 {
+		t.Errorf("BeforeEvict let %v go; want [2]", flushed)
+	}
+}
+
+func TestBeforeEvictVetoingAllSurfacesError(t *testing.T) {
+	var evictions int
+	h := fcache.NewHandle(func(n int) (int, error) { return n, nil }, &fcache.Config{
+		Capacity:    2,
+		BeforeEvict: func(string, int) error { return errDirty },
+	}, &fcache.Hooks{
+		OnEvict: func(fcache.HookContext) error { evictions++; return nil },
+	})
+
+	h.Call(1)
+	h.Call(2)
+	v, err := h.Call(3)
+	if v != 3 {
+		t.Errorf("Call(3) = %d; want the computed 3 alongside the error", v)
+	}
+	if !errors.Is(err, fcache.ErrCacheFull) || !errors.Is(err, errDirty) {
+		t.Fatalf("Call(3) error = %v; want ErrCacheFull wrapping the veto", err)
+	}
+	if h.Contains(3) || h.Stats().Entries != 2 {
+		t.Errorf("Contains(3) = %v, Len = %d; want 3 not stored and both entries kept", h.Contains(3), h.Stats().Entries)
+	}
+
+	// Shrinking stops at the vetoed entries
+	h.SetCapacity(1)
+	if h.Stats().Entries != 2 || evictions != 0 {
+		t.Errorf("after SetCapacity(1): Len = %d, evictions = %d; want 2, 0", h.Stats().Entries, evictions)
+	}
+}
+
+func TestBeforeEvictWrongTypePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewHandle did not panic for a BeforeEvict of the wrong type")
+		}
+	}()
+	fcache.NewHandle(func(n int) (int, error) { return n, nil }, &fcache.Config{
+		BeforeEvict: func(string, string) error { return nil },
+	}, nil)
+}
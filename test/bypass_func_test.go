@@ -0,0 +1,48 @@
+package test
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+// priceQuery carries a force-refresh flag that is not part of the cache key.
+type priceQuery struct {
+	Symbol       string
+	ForceRefresh bool `json:"-"`
+}
+
+func TestBypassFuncForcesRefresh(t *testing.T) {
+	var version atomic.Int32
+	price := fcache.NewCachedFunction(func(q priceQuery) (int32, error) {
+		return version.Add(1), nil
+	}, &fcache.Config{
+		BypassFunc: func(q priceQuery) bool { return q.ForceRefresh },
+	}, nil)
+
+	if v, _ := price(priceQuery{Symbol: "ABC"}); v != 1 {
+		t.Fatalf("first call = %d; want 1", v)
+	}
+	if v, _ := price(priceQuery{Symbol: "ABC"}); v != 1 {
+		t.Errorf("second call = %d; want the cached 1", v)
+	}
+	if v, _ := price(priceQuery{Symbol: "ABC", ForceRefresh: true}); v != 2 {
+		t.Errorf("forced call = %d; want a recomputed 2", v)
+	}
+	// the fresh result replaced the entry
+	if v, _ := price(priceQuery{Symbol: "ABC"}); v != 2 {
+		t.Errorf("call after the refresh = %d; want the refreshed 2", v)
+	}
+}
+
+func TestBypassFuncWrongTypePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("a BypassFunc of the wrong type did not panic at construction")
+		}
+	}()
+	fcache.NewCachedFunction(func(q priceQuery) (int, error) {
+		return 0, nil
+	}, &fcache.Config{BypassFunc: func(s string) bool { return false }}, nil)
+}
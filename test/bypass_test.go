@@ -0,0 +1,42 @@
+package test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestSetBypassSkipsStore(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+
+	fn := func(key int) (int, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		return key, nil
+	}
+
+	h := fcache.NewHandle(fn, &fcache.Config{
+		TTL:      time.Minute,
+		Capacity: 10,
+	}, &fcache.Hooks{})
+
+	h.Call(1) // call #1, stored
+
+	h.SetBypass(true)
+	h.Call(1) // call #2
+	h.Call(2) // call #3, not stored
+	h.SetBypass(false)
+
+	h.Call(1) // served from the entry stored before the bypass
+	h.Call(2) // call #4
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 4 {
+		t.Errorf("underlying called %d times; want 4", calls)
+	}
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+// greeter depends on a cached function through the Cache interface.
+type greeter struct {
+	names fcache.Cache[int, string]
+}
+
+func (g *greeter) greet(id int) string {
+	name, err := g.names.Call(id)
+	if err != nil {
+		return "hello, stranger"
+	}
+	return "hello, " + name
+}
+
+// stubNames is a test double for fcache.Cache.
+type stubNames map[int]string
+
+func (s stubNames) Call(id int) (string, error) {
+	if name, ok := s[id]; ok {
+		return name, nil
+	}
+	return "", errors.New("unknown id")
+}
+func (s stubNames) Invalidate(int) error { return nil }
+func (s stubNames) Contains(id int) bool { _, ok := s[id]; return ok }
+func (s stubNames) Clear() int           { return 0 }
+
+func TestCacheInterfaceCanBeMocked(t *testing.T) {
+	g := &greeter{names: stubNames{1: "ada"}}
+	if got := g.greet(1); got != "hello, ada" {
+		t.Errorf("greet(1) = %q; want hello, ada", got)
+	}
+	if got := g.greet(2); got != "hello, stranger" {
+		t.Errorf("greet(2) = %q; want hello, stranger", got)
+	}
+}
+
+func TestNewCacheInvalidate(t *testing.T) {
+	var calls atomic.Int32
+	c := fcache.NewCache(func(id int) (string, error) {
+		calls.Add(1)
+		return "name", nil
+	}, nil, nil)
+	g := &greeter{names: c}
+
+	g.greet(1)
+	g.greet(1)
+	if err := c.Invalidate(1); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	if c.Contains(1) {
+		t.Error("entry still cached after Invalidate")
+	}
+	g.greet(1)
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d; want 2 (recomputed after Invalidate)", got)
+	}
+}
+
+func TestInvalidateUnkeyableArgument(t *testing.T) {
+	h := fcache.NewHandle(func(arg any) (int, error) { return 0, nil }, nil, nil)
+	if err := h.Invalidate(func() {}); !errors.Is(err, fcache.ErrKeyGeneration) {
+		t.Errorf("Invalidate(func) = %v; want ErrKeyGeneration", err)
+	}
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+var errDegraded = errors.New("degraded result")
+
+// degradedFetch returns a partial result with an error for positive keys and a bare error otherwise.
+func degradedFetch(calls *atomic.Int32) func(int) ([]string, error) {
+	return func(key int) ([]string, error) {
+		calls.Add(1)
+		if key <= 0 {
+			return nil, errDegraded
+		}
+		return []string{"partial"}, errDegraded
+	}
+}
+
+func TestCacheOnErrorReplaysValueAndError(t *testing.T) {
+	var calls atomic.Int32
+	cache := fcache.NewCachedFunction(degradedFetch(&calls), &fcache.Config{CacheOnError: true}, &fcache.Hooks{})
+
+	for i := 0; i < 3; i++ {
+		v, err := cache(1)
+		if !errors.Is(err, errDegraded) || len(v) != 1 || v[0] != "partial" {
+			t.Errorf("cache(1) = %v, %v; want [partial], %v", v, err, errDegraded)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d; want 1 (partial result cached)", got)
+	}
+}
+
+func TestCacheOnErrorSkipsZeroValues(t *testing.T) {
+	var calls atomic.Int32
+	cache := fcache.NewCachedFunction(degradedFetch(&calls), &fcache.Config{CacheOnError: true}, &fcache.Hooks{})
+
+	cache(0)
+	cache(0)
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d; want 2 (zero value with an error is not cached)", got)
+	}
+}
+
+func TestErrorsNotCachedByDefault(t *testing.T) {
+	var calls atomic.Int32
+	cache := fcache.NewCachedFunction(degradedFetch(&calls), nil, &fcache.Hooks{})
+
+	v, err := cache(1)
+	if !errors.Is(err, errDegraded) || v != nil {
+		t.Errorf("cache(1) = %v, %v; want nil, %v", v, err, errDegraded)
+	}
+	cache(1)
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d; want 2", got)
+	}
+}
@@ -0,0 +1,138 @@
+package test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	var calls atomic.Int32
+	var failing atomic.Bool
+	failing.Store(true)
+
+	handle := fcache.NewHandle(func(key int) (int, error) {
+		calls.Add(1)
+		if failing.Load() {
+			return 0, errors.New("backend down")
+		}
+		return key, nil
+	}, &fcache.Config{
+		BreakerThreshold: 3,
+		BreakerCooldown:  50 * time.Millisecond,
+	}, &fcache.Hooks{})
+
+	for i := 0; i < 3; i++ {
+		if _, err := handle.Call(i); err == nil || errors.Is(err, fcache.ErrCircuitOpen) {
+			t.Fatalf("call %d: err = %v; want the backend error", i, err)
+		}
+	}
+	if m := handle.Metrics(); m.BreakerState != "open" || m.BreakerTrips != 1 {
+		t.Fatalf("breaker = %q with %d trips; want open with 1", m.BreakerState, m.BreakerTrips)
+	}
+
+	// While open, misses fail fast without calling the backend.
+	if _, err := handle.Call(10); !errors.Is(err, fcache.ErrCircuitOpen) {
+		t.Errorf("err while open = %v; want ErrCircuitOpen", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("calls = %d; want 3 (no calls while open)", got)
+	}
+
+	// A failed half-open probe reopens the breaker.
+	time.Sleep(60 * time.Millisecond)
+	if _, err := handle.Call(10); err == nil || errors.Is(err, fcache.ErrCircuitOpen) {
+		t.Errorf("probe err = %v; want the backend error", err)
+	}
+	if m := handle.Metrics(); m.BreakerState != "open" || m.BreakerTrips != 2 {
+		t.Errorf("breaker after failed probe = %q with %d trips; want open with 2", m.BreakerState, m.BreakerTrips)
+	}
+
+	// A successful probe closes it.
+	failing.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	if v, err := handle.Call(10); err != nil || v != 10 {
+		t.Errorf("probe = %d, %v; want 10, nil", v, err)
+	}
+	if m := handle.Metrics(); m.BreakerState != "closed" {
+		t.Errorf("breaker after successful probe = %q; want closed", m.BreakerState)
+	}
+}
+
+func TestCircuitBreakerServesHitsWhileOpen(t *testing.T) {
+	var failing atomic.Bool
+	cache := fcache.NewCachedFunction(func(key int) (int, error) {
+		if failing.Load() {
+			return 0, errors.New("backend down")
+		}
+		return key, nil
+	}, &fcache.Config{BreakerThreshold: 1, BreakerCooldown: time.Minute}, &fcache.Hooks{})
+
+	cache(1)
+	failing.Store(true)
+	cache(2) // opens the breaker
+
+	if v, err := cache(1); err != nil || v != 1 {
+		t.Errorf("cached hit while open = %d, %v; want 1, nil", v, err)
+	}
+}
+
+func TestCircuitBreakerResetsOnSuccess(t *testing.T) {
+	handle := fcache.NewHandle(func(key int) (int, error) {
+		if key%2 == 0 {
+			return 0, errors.New("even keys fail")
+		}
+		return key, nil
+	}, &fcache.Config{BreakerThreshold: 2}, &fcache.Hooks{})
+
+	// failures are interleaved with successes, so they are never consecutive
+	for i := 0; i < 10; i++ {
+		handle.Call(i)
+	}
+	if m := handle.Metrics(); m.BreakerState != "closed" || m.BreakerTrips != 0 {
+		t.Errorf("breaker = %q with %d trips; want closed with 0", m.BreakerState, m.BreakerTrips)
+	}
+}
+
+func TestCircuitBreakerProbeRejectedByConcurrencyLimit(t *testing.T) {
+	release := make(chan struct{})
+	var hang atomic.Bool
+	hang.Store(true)
+	handle := fcache.NewHandle(func(key int) (int, error) {
+		if hang.Load() {
+			<-release
+		}
+		return key, nil
+	}, &fcache.Config{
+		BreakerThreshold:        1,
+		BreakerCooldown:         10 * time.Millisecond,
+		MaxConcurrentExecutions: 1,
+		ConcurrencyFailFast:     true,
+		ExecutionTimeout:        10 * time.Millisecond,
+	}, &fcache.Hooks{})
+
+	// the timed-out call trips the breaker and keeps the only slot
+	if _, err := handle.Call(1); !errors.Is(err, fcache.ErrExecutionTimeout) {
+		t.Fatalf("err = %v; want ErrExecutionTimeout", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := handle.Call(2); !errors.Is(err, fcache.ErrConcurrencyLimit) {
+		t.Fatalf("probe err = %v; want ErrConcurrencyLimit", err)
+	}
+
+	// once the slot is free, the next call probes instead of finding the breaker stuck half-open
+	hang.Store(false)
+	close(release)
+	if !waitFor(func() bool {
+		v, err := handle.Call(3)
+		return err == nil && v == 3
+	}) {
+		t.Fatalf("calls still fail after the slot was freed; breaker = %q", handle.Metrics().BreakerState)
+	}
+	if m := handle.Metrics(); m.BreakerState != "closed" {
+		t.Errorf("breaker after successful probe = %q; want closed", m.BreakerState)
+	}
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache/internal/core"
+)
+
+func TestCleanupReleasesLockBetweenBatches(t *testing.T) {
+	const n = 50000
+	s := core.NewStorage[string, int](core.Config{
+		TTL:                      time.Millisecond,
+		Capacity:                 n,
+		CleanupBatchSize:         100,
+		DisableBackgroundCleanup: true,
+	})
+	for i := 0; i < n; i++ {
+		s.Set(strconv.Itoa(i), i)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	done := make(chan int)
+	go func() {
+		done <- s.PurgeExpired()
+	}()
+
+	// Len takes the read lock, so it can only observe a partially purged storage
+	// if the sweep releases the write lock between batches.
+	partial := false
+	var removed int
+loop:
+	for {
+		select {
+		case removed = <-done:
+			break loop
+		default:
+			if l := s.Len(); l > 0 && l < n {
+				partial = true
+			}
+		}
+	}
+
+	if removed != n {
+		t.Errorf("PurgeExpired removed %d; want %d", removed, n)
+	}
+	if s.Len() != 0 {
+		t.Errorf("Len after purge = %d; want 0", s.Len())
+	}
+	if !partial {
+		t.Error("readers never ran during the sweep: the lock was held for the whole cleanup")
+	}
+}
+
+func TestCleanupBatchKeepsRefreshedEntries(t *testing.T) {
+	s := core.NewStorage[string, int](core.Config{
+		TTL:                      20 * time.Millisecond,
+		Capacity:                 10,
+		CleanupBatchSize:         1,
+		DisableBackgroundCleanup: true,
+	})
+	s.Set("a", 1)
+	s.Set("b", 2)
+	time.Sleep(30 * time.Millisecond)
+	s.Set("c", 3)
+
+	if removed := s.PurgeExpired(); removed != 2 {
+		t.Errorf("PurgeExpired removed %d; want 2", removed)
+	}
+	if _, ok := s.Get("c"); !ok {
+		t.Error("fresh entry was removed")
+	}
+}
@@ -0,0 +1,43 @@
+package test
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestDisabledBackgroundCleanupSpawnsNoGoroutine(t *testing.T) {
+	fn := func(key int) (int, error) {
+		return key, nil
+	}
+
+	before := runtime.NumGoroutine()
+
+	h := fcache.NewHandle(fn, &fcache.Config{
+		TTL:                      20 * time.Millisecond,
+		Capacity:                 100,
+		CleanupInterval:          time.Millisecond,
+		DisableBackgroundCleanup: true,
+	}, &fcache.Hooks{})
+
+	// Store several entries; none of them may start the cleanup goroutine
+	for i := 0; i < 10; i++ {
+		if _, err := h.Call(i); err != nil {
+			t.Fatalf("call %d error: %v", i, err)
+		}
+	}
+
+	if after := runtime.NumGoroutine(); after != before {
+		t.Errorf("goroutines after calls = %d; want %d", after, before)
+	}
+
+	// Expired entries are still removed on demand
+	time.Sleep(30 * time.Millisecond)
+	h.PurgeExpired()
+
+	if after := runtime.NumGoroutine(); after != before {
+		t.Errorf("goroutines after purge = %d; want %d", after, before)
+	}
+}
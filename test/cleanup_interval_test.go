@@ -0,0 +1,31 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache/internal/core"
+)
+
+func TestCleanupIntervalDefaultsToShortTTL(t *testing.T) {
+	s := core.NewStorage[int, int](core.Config{TTL: 10 * time.Millisecond})
+	for i := 0; i < 100; i++ {
+		s.Set(i, i)
+	}
+
+	// With the old fixed one-minute interval, the expired entries would stay until the next minute
+	if !waitFor(func() bool { return s.Len() == 0 }) {
+		t.Errorf("Len = %d a second after a 10ms TTL; want expired entries swept", s.Len())
+	}
+}
+
+func TestExplicitCleanupIntervalIsKept(t *testing.T) {
+	s := core.NewStorage[int, int](core.Config{TTL: 10 * time.Millisecond, CleanupInterval: time.Hour})
+	for i := 0; i < 100; i++ {
+		s.Set(i, i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := s.Len(); n != 100 {
+		t.Errorf("Len = %d; want 100 expired entries left until the hourly sweep", n)
+	}
+}
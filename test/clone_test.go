@@ -0,0 +1,44 @@
+package test
+
+import (
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestCloneFuncIsolatesInflightResults(t *testing.T) {
+	// Function that returns a fresh map after a delay, so concurrent callers share one execution
+	fn := func(key int) (map[string]int, error) {
+		time.Sleep(50 * time.Millisecond)
+		return map[string]int{"key": key}, nil
+	}
+
+	cache := fcache.NewCachedFunction(fn, &fcache.Config{
+		TTL:       time.Second,
+		Capacity:  100,
+		CloneFunc: func(m map[string]int) map[string]int { return maps.Clone(m) },
+	}, &fcache.Hooks{})
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m, err := cache(1)
+			if err != nil {
+				t.Errorf("goroutine %d error: %v", i, err)
+				return
+			}
+			// Each caller mutates its own copy
+			m["caller"] = i
+			if len(m) != 2 || m["caller"] != i {
+				t.Errorf("goroutine %d sees foreign mutation: %v", i, m)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
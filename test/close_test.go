@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestContextCancelStopsCleanupGoroutine(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	before := runtime.NumGoroutine()
+	var calls atomic.Int32
+	cached := fcache.NewCachedFunctionWithContext(ctx, func(key int) (int, error) {
+		calls.Add(1)
+		return key, nil
+	}, &fcache.Config{CleanupInterval: time.Millisecond}, nil)
+
+	cached(1)
+	cached(1)
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("calls before cancel = %d; want 1", n)
+	}
+	if runtime.NumGoroutine() <= before {
+		t.Fatal("no cleanup goroutine started by the first store")
+	}
+
+	cancel()
+	if !waitFor(func() bool { return runtime.NumGoroutine() <= before }) {
+		t.Fatalf("goroutines after cancel = %d; want at most %d", runtime.NumGoroutine(), before)
+	}
+
+	// The function still works, without caching
+	if v, err := cached(1); v != 1 || err != nil {
+		t.Errorf("call after cancel = (%d, %v); want (1, nil)", v, err)
+	}
+	cached(1)
+	if n := calls.Load(); n != 3 {
+		t.Errorf("calls after cancel = %d; want every call executed (3)", n)
+	}
+	if runtime.NumGoroutine() > before {
+		t.Error("a call after cancel restarted the cleanup goroutine")
+	}
+}
+
+func TestCloseDropsEntriesAndStopsWorkers(t *testing.T) {
+	var cleared atomic.Int32
+	h := fcache.NewHandle(func(key int) (int, error) { return key, nil }, &fcache.Config{
+		CleanupInterval: time.Millisecond,
+		AsyncHooks:      true,
+	}, &fcache.Hooks{
+		OnRemove: func(hc fcache.HookContext) error {
+			if hc.Reason == fcache.ReasonClear {
+				cleared.Add(1)
+			}
+			return nil
+		},
+		OnSet: func(arg any) error { return nil },
+	})
+	h.Call(1)
+	h.Call(2)
+	if !waitFor(func() bool { return h.DebugState().HookWorkers > 0 }) {
+		t.Fatal("async hook workers did not start")
+	}
+
+	h.Close()
+	if h.Contains(1) || h.Contains(2) {
+		t.Error("entries survived Close")
+	}
+	if !waitFor(func() bool {
+		s := h.DebugState()
+		return !s.CleanupRunning && s.CleanupGoroutines == 0 && s.HookWorkers == 0
+	}) {
+		t.Fatalf("DebugState after Close = %+v; want no background goroutines", h.DebugState())
+	}
+	// the workers ran the queued hooks before exiting
+	if n := cleared.Load(); n != 2 {
+		t.Errorf("OnRemove calls with ReasonClear = %d; want 2", n)
+	}
+
+	// Later calls are computed but not stored, and hooks run inline
+	if v, err := h.Call(3); v != 3 || err != nil {
+		t.Errorf("Call after Close = (%d, %v); want (3, nil)", v, err)
+	}
+	if h.Contains(3) {
+		t.Error("a result was stored after Close")
+	}
+	if s := h.DebugState(); s.CleanupGoroutines != 0 || s.HookWorkers != 0 {
+		t.Errorf("DebugState after a call = %+v; want no background goroutines", s)
+	}
+	h.Close() // idempotent
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestComparableCacheMemoizes(t *testing.T) {
+	type point struct{ X, Y int }
+
+	var mu sync.Mutex
+	calls := 0
+
+	fn := func(p point) (int, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		return p.X + p.Y, nil
+	}
+
+	cache := fcache.NewCachedFunctionComparable(fn, &fcache.Config{
+		TTL:      time.Minute,
+		Capacity: 10,
+	}, &fcache.Hooks{})
+
+	cache(point{1, 2}) // call #1
+	cache(point{1, 2})
+	cache(point{2, 1}) // call #2
+	if v, err := cache(point{2, 1}); err != nil || v != 3 {
+		t.Errorf("cache(point{2, 1}) = (%d, %v); want (3, nil)", v, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 2 {
+		t.Errorf("underlying called %d times; want 2", calls)
+	}
+}
+
+func TestComparableCacheDistinguishesScalarTypes(t *testing.T) {
+	fn := func(arg any) (any, error) {
+		return arg, nil
+	}
+
+	// Values of different types with the same text must not share an entry
+	cache := fcache.NewCachedFunctionComparable(fn, nil, nil)
+	for _, arg := range []any{1, "1", true, "b:true"} {
+		if v, err := cache(arg); err != nil || v != arg {
+			t.Errorf("cache(%#v) = (%#v, %v); want (%#v, nil)", arg, v, err, arg)
+		}
+	}
+}
+
+func TestComparableCacheRejectsNonComparableDynamicValues(t *testing.T) {
+	fn := func(arg any) (int, error) {
+		return 1, nil
+	}
+
+	cache := fcache.NewCachedFunctionComparable(fn, nil, nil)
+	if _, err := cache([]int{1, 2}); !errors.Is(err, fcache.ErrBuildKey) {
+		t.Errorf("cache([]int) error = %v; want ErrBuildKey", err)
+	}
+}
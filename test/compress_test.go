@@ -0,0 +1,55 @@
+package test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestCompressStoresValuesTransparently(t *testing.T) {
+	fn := func(n int) ([]byte, error) {
+		return bytes.Repeat([]byte("fcache "), n), nil
+	}
+
+	h := fcache.NewHandle(fn, &fcache.Config{
+		TTL:      time.Minute,
+		Capacity: 10,
+		Compress: true,
+	}, &fcache.Hooks{})
+
+	want := bytes.Repeat([]byte("fcache "), 1000)
+	for i := 0; i < 2; i++ {
+		got, err := h.Call(1000)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("call %d returned %d bytes, err %v; want %d bytes", i, len(got), err, len(want))
+		}
+	}
+
+	m := h.Metrics()
+	if m.Hits != 1 || m.Misses != 1 {
+		t.Errorf("hits/misses = %d/%d; want 1/1", m.Hits, m.Misses)
+	}
+	if m.UncompressedBytes != uint64(len(want)) {
+		t.Errorf("UncompressedBytes = %d; want %d", m.UncompressedBytes, len(want))
+	}
+	if ratio := m.CompressionRatio(); ratio <= 0 || ratio > 0.1 {
+		t.Errorf("CompressionRatio = %.3f; want a small positive ratio for repetitive data", ratio)
+	}
+}
+
+func TestCompressSupportsStringValues(t *testing.T) {
+	type body string
+	fn := func(s string) (body, error) {
+		return body(strings.Repeat(s, 100)), nil
+	}
+
+	cache := fcache.NewCachedFunction(fn, &fcache.Config{Compress: true}, nil)
+	for i := 0; i < 2; i++ {
+		if got, err := cache("ab"); err != nil || got != body(strings.Repeat("ab", 100)) {
+			t.Errorf("call %d = (%q, %v)", i, got, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestConfigReportsResolvedDefaults(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) { return key, nil }, &fcache.Config{
+		TTL: 30 * time.Second,
+	}, nil)
+
+	cfg := h.Config()
+	if cfg.TTL != 30*time.Second {
+		t.Errorf("TTL = %v; want the configured 30s", cfg.TTL)
+	}
+	if cfg.Capacity != 1000 {
+		t.Errorf("Capacity = %d; want the default 1000", cfg.Capacity)
+	}
+	if cfg.CleanupInterval != 30*time.Second {
+		t.Errorf("CleanupInterval = %v; want the TTL-derived default 30s", cfg.CleanupInterval)
+	}
+
+	h.SetTTL(time.Hour)
+	h.SetCapacity(50)
+	if cfg := h.Config(); cfg.TTL != time.Hour || cfg.Capacity != 50 {
+		t.Errorf("after SetTTL/SetCapacity, Config() = {TTL: %v, Capacity: %d}; want {1h0m0s, 50}", cfg.TTL, cfg.Capacity)
+	}
+
+	// The result is a copy
+	cfg.Capacity = 1
+	if got := h.Config().Capacity; got != 50 {
+		t.Errorf("Capacity after modifying the returned config = %d; want 50", got)
+	}
+}
+
+func TestConfigSharedAcrossCaches(t *testing.T) {
+	shared := &fcache.Config{Capacity: 10}
+	fn := func(key int) (int, error) { return key, nil }
+
+	first := fcache.NewHandle(fn, shared, nil)
+	// Reuse the config for a cache with short-lived entries; the first cache's defaults must not leak into it
+	shared.TTL = 100 * time.Millisecond
+	second := fcache.NewHandle(fn, shared, nil)
+
+	if got := first.Config().TTL; got != 5*time.Minute {
+		t.Errorf("first cache TTL = %v; want the default 5m0s", got)
+	}
+	cfg := second.Config()
+	if cfg.TTL != 100*time.Millisecond {
+		t.Errorf("second cache TTL = %v; want 100ms", cfg.TTL)
+	}
+	if cfg.CleanupInterval != 100*time.Millisecond {
+		t.Errorf("second cache CleanupInterval = %v; want 100ms, derived from its own TTL", cfg.CleanupInterval)
+	}
+	if cfg.Capacity != 10 {
+		t.Errorf("second cache Capacity = %d; want 10", cfg.Capacity)
+	}
+
+	first.SetCapacity(3)
+	if got := second.Config().Capacity; got != 10 {
+		t.Errorf("second cache Capacity after resizing the first = %d; want 10", got)
+	}
+}
+
+func TestConfigRoundTrips(t *testing.T) {
+	fn := func(key int) (int, error) { return key, nil }
+	for name, opts := range map[string]*fcache.Config{
+		"default":   nil,
+		"no expire": {NoExpire: true},
+		"features": {
+			AsyncHooks:       true,
+			OnFull:           fcache.FullBlock,
+			BreakerThreshold: 3,
+			MaxBytes:         1 << 20,
+		},
+	} {
+		cfg := fcache.NewHandle(fn, opts, nil).Config()
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("%s: Config().Validate() = %v; want nil", name, err)
+			continue
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s: NewCachedFunction with the resolved config panicked: %v", name, r)
+				}
+			}()
+			if v, err := fcache.NewCachedFunction(fn, &cfg, nil)(7); v != 7 || err != nil {
+				t.Errorf("%s: cache built from the resolved config returned (%d, %v)", name, v, err)
+			}
+		}()
+	}
+}
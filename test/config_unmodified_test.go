@@ -0,0 +1,50 @@
+package test
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+// TestConstructorsLeaveConfigUnmodified checks that no constructor writes its defaults into the
+// Config passed by the caller.
+func TestConstructorsLeaveConfigUnmodified(t *testing.T) {
+	fn := func(key int) (int, error) { return key, nil }
+	constructors := map[string]func(cfg *fcache.Config){
+		"NewCachedFunction":           func(cfg *fcache.Config) { fcache.NewCachedFunction(fn, cfg, nil) },
+		"NewCachedFunctionComparable": func(cfg *fcache.Config) { fcache.NewCachedFunctionComparable(fn, cfg, nil) },
+		"NewHandle":                   func(cfg *fcache.Config) { fcache.NewHandle(fn, cfg, nil) },
+		"NewHandleComparable":         func(cfg *fcache.Config) { fcache.NewHandleComparable(fn, cfg, nil) },
+		"New":                         func(cfg *fcache.Config) { fcache.New(fn, fcache.WithConfig(cfg)) },
+		"Wrap2": func(cfg *fcache.Config) {
+			fcache.Wrap2(func(key int) (int, string, error) { return key, "", nil }, cfg, nil)
+		},
+		"WrapReader": func(cfg *fcache.Config) {
+			fcache.WrapReader(func(key int) (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader("")), nil
+			}, 1024, cfg, nil)
+		},
+	}
+
+	for name, construct := range constructors {
+		t.Run(name, func(t *testing.T) {
+			cfg := &fcache.Config{}
+			construct(cfg)
+			if !reflect.DeepEqual(*cfg, fcache.Config{}) {
+				t.Errorf("zero Config after construction = %+v; want all fields still zero", *cfg)
+			}
+
+			// HardTTL used to be copied into TTL
+			cfg = &fcache.Config{HardTTL: time.Minute, SoftTTL: time.Second}
+			construct(cfg)
+			if cfg.TTL != 0 || cfg.Capacity != 0 || cfg.CleanupInterval != 0 {
+				t.Errorf("Config after construction = {TTL: %v, Capacity: %d, CleanupInterval: %v}; want zero fields untouched",
+					cfg.TTL, cfg.Capacity, cfg.CleanupInterval)
+			}
+		})
+	}
+}
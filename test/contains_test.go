@@ -0,0 +1,38 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestContainsReportsValidEntriesOnly(t *testing.T) {
+	fn := func(key int) (int, error) {
+		return key, nil
+	}
+
+	h := fcache.NewHandle(fn, &fcache.Config{
+		TTL:                      30 * time.Millisecond,
+		Capacity:                 10,
+		DisableBackgroundCleanup: true, // keep the expired entry in the map
+	}, &fcache.Hooks{})
+
+	if h.Contains(1) {
+		t.Error("Contains(1) before the first call = true; want false")
+	}
+
+	h.Call(1)
+	if !h.Contains(1) {
+		t.Error("Contains(1) after the call = false; want true")
+	}
+
+	// Expired but not yet removed from storage
+	time.Sleep(40 * time.Millisecond)
+	if h.Contains(1) {
+		t.Error("Contains(1) after expiry = true; want false")
+	}
+	if n := h.PurgeExpired(); n != 1 {
+		t.Errorf("expired entry should still be present for cleanup; purged %d", n)
+	}
+}
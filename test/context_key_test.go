@@ -0,0 +1,102 @@
+package test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+type tenantKey struct{}
+
+func withTenant(tenant string) context.Context {
+	return context.WithValue(context.Background(), tenantKey{}, tenant)
+}
+
+func tenantOf(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+func TestContextKeyFuncPartitionsByTenant(t *testing.T) {
+	var calls atomic.Int32
+	cache := fcache.NewCachedFunction(func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		return "data for " + tenantOf(ctx), nil
+	}, &fcache.Config{ContextKeyFunc: tenantOf}, &fcache.Hooks{})
+
+	a, _ := cache(withTenant("a"))
+	b, _ := cache(withTenant("b"))
+	if a != "data for a" || b != "data for b" {
+		t.Errorf("results = %q, %q; want each tenant's own data", a, b)
+	}
+	cache(withTenant("a"))
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d; want 2 (one per tenant)", got)
+	}
+}
+
+func TestContextsShareKeyByDefault(t *testing.T) {
+	var calls atomic.Int32
+	cache := fcache.NewCachedFunction(func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		return "data for " + tenantOf(ctx), nil
+	}, nil, &fcache.Hooks{})
+
+	cache(withTenant("a"))
+	if v, _ := cache(withTenant("b")); v != "data for a" {
+		t.Errorf("cache(b) = %q; want the placeholder-keyed result %q", v, "data for a")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d; want 1", got)
+	}
+}
+
+type contextRequest struct {
+	ID  int
+	Ctx context.Context
+}
+
+func TestContextFieldsUsePlaceholder(t *testing.T) {
+	var calls atomic.Int32
+	cache := fcache.NewCachedFunction(func(req contextRequest) (int, error) {
+		calls.Add(1)
+		return req.ID, nil
+	}, nil, &fcache.Hooks{})
+
+	ctx, cancel := context.WithCancel(withTenant("a"))
+	defer cancel()
+	for _, req := range []contextRequest{
+		{ID: 1, Ctx: context.Background()},
+		{ID: 1, Ctx: ctx},
+		{ID: 1},
+	} {
+		if v, err := cache(req); v != 1 || err != nil {
+			t.Fatalf("cache(%+v) = (%d, %v); want (1, nil)", req, v, err)
+		}
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d; want 2: contexts share the placeholder, a nil context is keyed apart", got)
+	}
+	if v, _ := cache(contextRequest{ID: 2, Ctx: ctx}); v != 2 {
+		t.Errorf("cache(ID 2) = %d; want 2, keyed by the other fields", v)
+	}
+}
+
+func TestContextFieldsUseContextKeyFunc(t *testing.T) {
+	var calls atomic.Int32
+	cache := fcache.NewCachedFunction(func(reqs []*contextRequest) (string, error) {
+		calls.Add(1)
+		return "data for " + tenantOf(reqs[0].Ctx), nil
+	}, &fcache.Config{ContextKeyFunc: tenantOf}, &fcache.Hooks{})
+
+	cache([]*contextRequest{{ID: 1, Ctx: withTenant("a")}})
+	if v, _ := cache([]*contextRequest{{ID: 1, Ctx: withTenant("b")}}); v != "data for b" {
+		t.Errorf("cache(tenant b) = %q; want the nested contexts partitioned by tenant", v)
+	}
+	cache([]*contextRequest{{ID: 1, Ctx: withTenant("a")}})
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d; want 2 (one per tenant)", got)
+	}
+}
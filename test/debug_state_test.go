@@ -0,0 +1,74 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(cond func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return true
+}
+
+func TestDebugStateCleanupLifecycle(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) { return key, nil }, &fcache.Config{
+		CleanupInterval: time.Millisecond,
+	}, nil)
+
+	if s := h.DebugState(); s.CleanupRunning || s.CleanupGoroutines != 0 {
+		t.Fatalf("DebugState before any store = %+v; want no cleanup", s)
+	}
+
+	h.Call(1)
+	h.Call(2)
+	if s := h.DebugState(); !s.CleanupRunning || s.CleanupGoroutines != 1 {
+		t.Fatalf("DebugState after stores = %+v; want one running cleanup goroutine", s)
+	}
+
+	// Emptying the cache stops the goroutine, and storing again starts exactly one new one
+	h.Clear()
+	if s := h.DebugState(); s.CleanupRunning {
+		t.Errorf("DebugState after Clear = %+v; want cleanup stopped", s)
+	}
+	if !waitFor(func() bool { return h.DebugState().CleanupGoroutines == 0 }) {
+		t.Fatalf("cleanup goroutine still alive after Clear: %+v", h.DebugState())
+	}
+	h.Call(3)
+	if s := h.DebugState(); !s.CleanupRunning || s.CleanupGoroutines != 1 {
+		t.Errorf("DebugState after restart = %+v; want one running cleanup goroutine", s)
+	}
+}
+
+func TestDebugStateDisabledCleanup(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) { return key, nil }, &fcache.Config{
+		DisableBackgroundCleanup: true,
+	}, nil)
+	h.Call(1)
+	if s := h.DebugState(); s.CleanupRunning || s.CleanupGoroutines != 0 {
+		t.Errorf("DebugState = %+v; want no cleanup goroutine", s)
+	}
+}
+
+func TestDebugStateHookWorkers(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) { return key, nil }, &fcache.Config{
+		AsyncHooks:       true,
+		AsyncHookWorkers: 2,
+	}, &fcache.Hooks{OnSet: func(any) error { return nil }})
+
+	if n := h.DebugState().HookWorkers; n != 0 {
+		t.Errorf("HookWorkers before any hook = %d; want 0 (started lazily)", n)
+	}
+	h.Call(1)
+	if n := h.DebugState().HookWorkers; n != 2 {
+		t.Errorf("HookWorkers = %d; want 2", n)
+	}
+}
@@ -60,3 +60,33 @@ func TestConcurrentCallsAreDeduplicated(t *testing.T) {
 	}
 	mu.Unlock()
 }
+
+func TestDeduplicatedSavesMetric(t *testing.T) {
+	handle := fcache.NewHandle(func(key int) (int, error) {
+		time.Sleep(100 * time.Millisecond)
+		return key * 3, nil
+	}, &fcache.Config{
+		TTL:      time.Second,
+		Capacity: 100,
+	}, &fcache.Hooks{})
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handle.Call(4)
+		}()
+	}
+	wg.Wait()
+
+	m := handle.Metrics()
+	if m.Misses != 1 {
+		t.Errorf("Misses = %d; want 1", m.Misses)
+	}
+	// Every other call either waited on the in-flight call or, if it arrived late, hit the cache.
+	if m.DeduplicatedSaves == 0 || m.DeduplicatedSaves+m.Hits != n-1 {
+		t.Errorf("DeduplicatedSaves = %d, Hits = %d; want them to add up to %d", m.DeduplicatedSaves, m.Hits, n-1)
+	}
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestDisableDedupRunsEveryMiss(t *testing.T) {
+	const callers = 8
+	var calls, joins atomic.Int32
+	var started sync.WaitGroup
+	started.Add(callers)
+	release := make(chan struct{})
+	h := fcache.NewHandle(func(key int) (int, error) {
+		calls.Add(1) // the side effect every caller must trigger
+		started.Done()
+		<-release
+		return key, nil
+	}, &fcache.Config{DisableDedup: true}, &fcache.Hooks{
+		OnInflightJoin: func(any) error { joins.Add(1); return nil },
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := h.Call(1); v != 1 || err != nil {
+				t.Errorf("Call(1) = (%d, %v); want (1, nil)", v, err)
+			}
+		}()
+	}
+	// Every concurrent caller is inside the function at once; deduplicated, only one would be
+	waitTimeout(t, &started, time.Second)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != callers {
+		t.Errorf("function ran %d times; want once per caller (%d)", n, callers)
+	}
+	if n := joins.Load(); n != 0 {
+		t.Errorf("OnInflightJoin fired %d times; want 0", n)
+	}
+
+	// The result is still cached
+	h.Call(1)
+	if n := calls.Load(); n != callers {
+		t.Errorf("function ran %d times after completion; want a hit", n)
+	}
+}
+
+// waitTimeout waits for wg, failing the test if it takes longer than d.
+func waitTimeout(t *testing.T, wg *sync.WaitGroup, d time.Duration) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(d):
+		t.Fatal("timed out waiting for concurrent callers")
+	}
+}
@@ -0,0 +1,34 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+func TestDisableMetricsKeepsCountersAtZero(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{Capacity: 1, DisableMetrics: true}, nil)
+
+	h.Call(1)
+	h.Call(1)
+	h.Call(2) // evicts 1
+	if m := h.Metrics(); m.Hits != 0 || m.Misses != 0 || m.Evictions != 0 || m.LatencyAvg != 0 {
+		t.Errorf("Metrics() = %+v; want zero counters", m)
+	}
+	if v, _ := h.Scoped("tenant").Call(3); v != 3 || h.Scoped("tenant").Metrics().Misses != 0 {
+		t.Error("a Scoped view of a cache without metrics counted its calls")
+	}
+}
+
+func TestMetricsCountedByDefault(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, nil, nil)
+	h.Call(1)
+	h.Call(1)
+	if m := h.Metrics(); m.Hits != 1 || m.Misses != 1 {
+		t.Errorf("Metrics() = %+v; want 1 hit and 1 miss", m)
+	}
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestDisabledCallsThroughEveryTime(t *testing.T) {
+	var calls atomic.Int32
+	h := fcache.NewHandle(func(key int) (int, error) {
+		calls.Add(1)
+		return key * 2, nil
+	}, &fcache.Config{Disabled: true}, nil)
+
+	for i := 0; i < 3; i++ {
+		if v, err := h.Call(1); err != nil || v != 2 {
+			t.Fatalf("Call(1) = (%d, %v); want (2, nil)", v, err)
+		}
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("function called %d times; want 3", n)
+	}
+	if h.Contains(1) || h.Stats().Entries != 0 {
+		t.Error("a disabled cache stored a result")
+	}
+	if v, _ := h.Scoped("tenant").Call(1); v != 2 || calls.Load() != 4 {
+		t.Error("a Scoped view of a disabled cache did not call through")
+	}
+
+	h.SetBypass(false) // caching can be switched on at runtime
+	h.Call(1)
+	h.Call(1)
+	if n := calls.Load(); n != 5 {
+		t.Errorf("function called %d times after enabling; want 5", n)
+	}
+}
+
+func TestDisabledKeepsDeduplication(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	cached := fcache.NewCachedFunction(func(key int) (int, error) {
+		calls.Add(1)
+		<-release
+		return key, nil
+	}, &fcache.Config{Disabled: true}, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cached(1)
+		}()
+	}
+	time.Sleep(30 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("function called %d times for concurrent calls; want 1", n)
+	}
+}
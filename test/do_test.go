@@ -0,0 +1,73 @@
+package test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestDoUsesPerCallProducer(t *testing.T) {
+	fn := func(key int) (string, error) {
+		return "wrapped", nil
+	}
+
+	h := fcache.NewHandle(fn, &fcache.Config{
+		TTL:      time.Minute,
+		Capacity: 10,
+	}, &fcache.Hooks{})
+
+	v, err := h.Do(1, func() (string, error) { return "producer", nil })
+	if err != nil || v != "producer" {
+		t.Fatalf("Do = (%q, %v); want (producer, nil)", v, err)
+	}
+
+	// The produced value is shared with Call
+	if v, _ := h.Call(1); v != "producer" {
+		t.Errorf("Call after Do = %q; want producer", v)
+	}
+	if v, _ := h.Do(1, func() (string, error) { return "other", nil }); v != "producer" {
+		t.Errorf("Do on a cached key = %q; want producer", v)
+	}
+}
+
+func TestDoFirstProducerWins(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) { return 0, nil }, &fcache.Config{
+		TTL:      time.Minute,
+		Capacity: 10,
+	}, &fcache.Hooks{})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.Do(1, func() (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+
+	// Joins the in-flight call; its own producer never runs
+	wg.Add(1)
+	var second int
+	go func() {
+		defer wg.Done()
+		second, _ = h.Do(1, func() (int, error) {
+			t.Error("second producer ran")
+			return 2, nil
+		})
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if second != 1 {
+		t.Errorf("second Do = %d; want first producer's result 1", second)
+	}
+}
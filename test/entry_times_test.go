@@ -0,0 +1,56 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestEntryCreatedAndLastAccess(t *testing.T) {
+	clock := newFakeClock()
+	h := fcache.NewHandle(func(key int) (int, error) { return key, nil }, &fcache.Config{
+		TTL:   time.Minute,
+		Clock: clock,
+	}, nil)
+	inserted := clock.Now()
+	h.Call(1)
+
+	item := func() fcache.StorageItem[int] {
+		t.Helper()
+		stats := h.Stats()
+		if len(stats.Items) != 1 {
+			t.Fatalf("Stats has %d items; want 1", len(stats.Items))
+		}
+		return stats.Items[0]
+	}
+	if it := item(); !it.Created.Equal(inserted) || !it.LastAccess.Equal(inserted) {
+		t.Errorf("after insert: Created %v, LastAccess %v; want both %v", it.Created, it.LastAccess, inserted)
+	}
+
+	// A hit moves LastAccess but, without sliding TTL, not the expiration timestamp
+	clock.Advance(20 * time.Second)
+	h.Call(1)
+	hit := clock.Now()
+	if it := item(); !it.Created.Equal(inserted) || !it.LastAccess.Equal(hit) || !it.Timestamp.Equal(inserted) {
+		t.Errorf("after hit: Created %v, LastAccess %v, Timestamp %v; want %v, %v, %v",
+			it.Created, it.LastAccess, it.Timestamp, inserted, hit, inserted)
+	}
+	if d, _ := h.TTLRemaining(1); d != 40*time.Second {
+		t.Errorf("TTLRemaining = %v after a hit; want 40s, unchanged by the access", d)
+	}
+
+	// Inspecting the entry is not an access
+	clock.Advance(5 * time.Second)
+	h.Contains(1)
+	h.Range(func(string, int, time.Duration) bool { return true })
+	if it := item(); !it.LastAccess.Equal(hit) {
+		t.Errorf("LastAccess = %v after Contains and Range; want %v", it.LastAccess, hit)
+	}
+
+	// An overwrite keeps the creation time
+	h.Set(1, 10)
+	if it := item(); !it.Created.Equal(inserted) || !it.Timestamp.Equal(clock.Now()) {
+		t.Errorf("after overwrite: Created %v, Timestamp %v; want %v, %v", it.Created, it.Timestamp, inserted, clock.Now())
+	}
+}
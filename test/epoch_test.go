@@ -0,0 +1,77 @@
+package test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func newCountingHandle(calls *atomic.Int32, cfg *fcache.Config) *fcache.Handle[int, int] {
+	return fcache.NewHandle(func(key int) (int, error) {
+		return int(calls.Add(1)), nil
+	}, cfg, nil)
+}
+
+func TestBumpEpochForcesRecompute(t *testing.T) {
+	var calls atomic.Int32
+	h := newCountingHandle(&calls, nil)
+
+	first, _ := h.Call(1)
+	if again, _ := h.Call(1); again != first {
+		t.Fatalf("second call = %d; want cached %d", again, first)
+	}
+	if epoch := h.BumpEpoch(); epoch != 1 {
+		t.Errorf("BumpEpoch = %d; want 1", epoch)
+	}
+	if h.Contains(1) {
+		t.Error("entry of the previous epoch still reported as cached")
+	}
+	if v, _ := h.Call(1); v == first {
+		t.Errorf("call after BumpEpoch = %d; want a recomputed value", v)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d; want 2", got)
+	}
+}
+
+func TestSetEpoch(t *testing.T) {
+	var calls atomic.Int32
+	h := newCountingHandle(&calls, nil)
+
+	h.SetEpoch(7)
+	h.Call(1)
+	h.SetEpoch(7) // same epoch: entries stay valid
+	h.Call(1)
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d after re-setting the same epoch; want 1", got)
+	}
+	h.SetEpoch(8)
+	h.Call(1)
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d after a new epoch; want 2", got)
+	}
+}
+
+func TestBumpEpochOrphansAreCleanedUp(t *testing.T) {
+	var calls atomic.Int32
+	var removed atomic.Int32
+	h := fcache.NewHandle(func(key int) (int, error) {
+		return int(calls.Add(1)), nil
+	}, &fcache.Config{TTL: time.Hour, CleanupInterval: time.Millisecond}, &fcache.Hooks{
+		OnRemove: func(hc fcache.HookContext) error {
+			if hc.Reason == fcache.ReasonTTL {
+				removed.Add(1)
+			}
+			return nil
+		},
+	})
+	for i := 0; i < 10; i++ {
+		h.Call(i)
+	}
+	h.BumpEpoch()
+	if !waitFor(func() bool { return removed.Load() == 10 }) {
+		t.Errorf("%d orphaned entries removed by cleanup; want 10", removed.Load())
+	}
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestEqualSkipsOnSetForUnchangedResults(t *testing.T) {
+	clock := newFakeClock()
+	var status atomic.Value
+	status.Store("ok")
+	var sets atomic.Int32
+	h := fcache.NewHandle(func(string) (string, error) {
+		return status.Load().(string), nil
+	}, &fcache.Config{
+		TTL:        time.Minute,
+		Clock:      clock,
+		BypassFunc: func(string) bool { return true }, // poll: every call recomputes
+		Equal:      func(old, new string) bool { return old == new },
+	}, &fcache.Hooks{
+		OnSet: func(arg any) error { sets.Add(1); return nil },
+	})
+
+	h.Call("service")
+	for i := 0; i < 5; i++ {
+		clock.Advance(10 * time.Second)
+		if v, err := h.Call("service"); v != "ok" || err != nil {
+			t.Fatalf("poll %d = (%q, %v); want (ok, nil)", i, v, err)
+		}
+	}
+	if n := sets.Load(); n != 1 {
+		t.Errorf("OnSet fired %d times for identical results; want 1", n)
+	}
+	// The unchanged entry's TTL was restarted by the last poll
+	if d, ok := h.TTLRemaining("service"); !ok || d != time.Minute {
+		t.Errorf("TTLRemaining = (%v, %v); want a full minute", d, ok)
+	}
+	if stats := h.Stats(); len(stats.Items) != 1 || !stats.Items[0].Timestamp.Equal(clock.Now()) {
+		t.Errorf("entry timestamp not extended to the last poll: %+v", stats.Items)
+	}
+
+	// A changed result replaces the entry as usual
+	status.Store("degraded")
+	h.Call("service")
+	if n := sets.Load(); n != 2 {
+		t.Errorf("OnSet fired %d times after a change; want 2", n)
+	}
+	if stats := h.Stats(); stats.Items[0].Value != "degraded" {
+		t.Errorf("stored value = %q; want degraded", stats.Items[0].Value)
+	}
+}
+
+func TestEqualExtendsStaleEntry(t *testing.T) {
+	clock := newFakeClock()
+	var sets atomic.Int32
+	h := fcache.NewHandle(func(key int) (int, error) { return key, nil }, &fcache.Config{
+		TTL:                      time.Minute,
+		Clock:                    clock,
+		ServeStaleOnError:        true, // keeps expired entries to compare against
+		DisableBackgroundCleanup: true,
+		Equal:                    func(old, new int) bool { return old == new },
+	}, &fcache.Hooks{
+		OnSet: func(arg any) error { sets.Add(1); return nil },
+	})
+
+	h.Call(1)
+	clock.Advance(2 * time.Minute)
+	h.Call(1) // expired: recomputed, equal to the kept value
+	if n := sets.Load(); n != 1 {
+		t.Errorf("OnSet fired %d times; want 1", n)
+	}
+	if !h.Contains(1) {
+		t.Error("the expired entry was not extended")
+	}
+}
+
+func TestEqualWrongTypePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Equal of the wrong type did not panic")
+		}
+	}()
+	fcache.NewHandle(func(key int) (int, error) { return key, nil }, &fcache.Config{
+		Equal: func(old, new string) bool { return old == new },
+	}, nil)
+}
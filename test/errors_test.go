@@ -0,0 +1,101 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestSentinelErrorsMatchWithErrorsIs(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	fn := func(key int) (int, error) {
+		panic(errBoom)
+	}
+
+	cache := fcache.NewCachedFunction(fn, &fcache.Config{
+		TTL:      time.Minute,
+		Capacity: 10,
+	}, &fcache.Hooks{})
+
+	_, err := cache(1)
+	if !errors.Is(err, fcache.ErrPanic) {
+		t.Fatalf("errors.Is(err, ErrPanic) = false; err = %v", err)
+	}
+	// The original panic value stays in the chain
+	if !errors.Is(err, errBoom) {
+		t.Errorf("errors.Is(err, errBoom) = false; err = %v", err)
+	}
+
+	var fe *fcache.Error
+	if !errors.As(err, &fe) {
+		t.Fatalf("errors.As(err, *fcache.Error) = false")
+	}
+	if fe.Fields["panic"] != errBoom {
+		t.Errorf("Fields[panic] = %v; want %v", fe.Fields["panic"], errBoom)
+	}
+}
+
+func TestBuildKeyErrorMatchesWithErrorsIs(t *testing.T) {
+	fn := func(arg func()) (int, error) {
+		return 1, nil
+	}
+
+	cache := fcache.NewCachedFunction(fn, nil, nil)
+
+	_, err := cache(func() {})
+	if !errors.Is(err, fcache.ErrBuildKey) {
+		t.Errorf("errors.Is(err, ErrBuildKey) = false; err = %v", err)
+	}
+	if !errors.Is(err, fcache.ErrMarshallJSON) {
+		t.Errorf("errors.Is(err, ErrMarshallJSON) = false; err = %v", err)
+	}
+
+	var fe *fcache.Error
+	if !errors.As(err, &fe) || fe.Fields["value"] == nil {
+		t.Errorf("expected *fcache.Error carrying the offending value, got %v", err)
+	}
+}
+
+type jobSpec struct {
+	Name     string
+	Callback func() // funcs cannot be encoded into a key
+}
+
+func TestKeyGenerationErrorIsDistinct(t *testing.T) {
+	calls := 0
+	cache := fcache.NewCachedFunction(func(spec jobSpec) (string, error) {
+		calls++
+		return spec.Name, nil
+	}, nil, nil)
+
+	_, err := cache(jobSpec{Name: "a", Callback: func() {}})
+	if !errors.Is(err, fcache.ErrKeyGeneration) {
+		t.Errorf("errors.Is(err, ErrKeyGeneration) = false; err = %v", err)
+	}
+	if !errors.Is(err, fcache.ErrBuildKey) {
+		t.Errorf("errors.Is(err, ErrBuildKey) = false; err = %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("function called %d times; want 0", calls)
+	}
+}
+
+func TestFallbackOnKeyError(t *testing.T) {
+	calls := 0
+	cache := fcache.NewCachedFunction(func(spec jobSpec) (string, error) {
+		calls++
+		return spec.Name, nil
+	}, &fcache.Config{FallbackOnKeyError: true}, nil)
+
+	for i := 0; i < 2; i++ {
+		if v, err := cache(jobSpec{Name: "a", Callback: func() {}}); err != nil || v != "a" {
+			t.Errorf("cache = %q, %v; want a, nil", v, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d; want 2 (uncached)", calls)
+	}
+}
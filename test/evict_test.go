@@ -0,0 +1,79 @@
+package test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+func TestOnEvictReceivesValue(t *testing.T) {
+	var mu sync.Mutex
+	var evicted []any
+
+	h := &fcache.Hooks{
+		OnEvict: func(hc fcache.HookContext) error {
+			mu.Lock()
+			evicted = append(evicted, hc.Value)
+			mu.Unlock()
+			return nil
+		},
+	}
+	cache := fcache.NewCachedFunction(func(key int) (string, error) {
+		return "conn-" + string(rune('a'+key)), nil
+	}, &fcache.Config{Capacity: 2}, h)
+
+	cache(0)
+	cache(1)
+	cache(2) // evicts 0
+	cache(3) // evicts 1
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []any{"conn-a", "conn-b"}; !reflect.DeepEqual(evicted, want) {
+		t.Errorf("evicted values = %v; want %v", evicted, want)
+	}
+}
+
+func TestEvictReturnsLeastRecentlyUsed(t *testing.T) {
+	evictHooks := 0
+	h := &fcache.Hooks{
+		OnEvict: func(hc fcache.HookContext) error {
+			evictHooks++
+			return nil
+		},
+	}
+	handle := fcache.NewHandle(func(key int) (int, error) {
+		return key * 10, nil
+	}, &fcache.Config{Capacity: 10}, h)
+
+	for i := 1; i <= 4; i++ {
+		handle.Call(i)
+	}
+	handle.Call(1) // 1 becomes most recently used: LRU order is now 2, 3, 4, 1
+
+	got := handle.Evict(2)
+	if want := []int{20, 30}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Evict(2) = %v; want %v", got, want)
+	}
+	if handle.Contains(2) || handle.Contains(3) {
+		t.Error("evicted entries are still cached")
+	}
+	if !handle.Contains(4) || !handle.Contains(1) {
+		t.Error("entries not selected for eviction were removed")
+	}
+
+	if got := handle.Evict(10); !reflect.DeepEqual(got, []int{40, 10}) {
+		t.Errorf("Evict(10) = %v; want [40 10]", got)
+	}
+	if got := handle.Evict(1); len(got) != 0 {
+		t.Errorf("Evict on empty cache = %v; want none", got)
+	}
+	if evictHooks != 0 {
+		t.Errorf("OnEvict called %d times for manual evictions; want 0", evictHooks)
+	}
+	if m := handle.Metrics(); m.Evictions != 0 {
+		t.Errorf("Metrics.Evictions = %d after manual evictions; want 0", m.Evictions)
+	}
+}
@@ -0,0 +1,193 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+	"github.com/osmike/fcache/internal/core"
+)
+
+// fillAndTouch inserts keys 1..3 into a storage of capacity 3, reads key 1, and inserts key 4.
+func fillAndTouch(policy fcache.EvictionPolicy) *core.Storage[int, int] {
+	s := core.NewStorage[int, int](core.Config{
+		TTL:                      time.Minute,
+		Capacity:                 3,
+		EvictionPolicy:           policy,
+		DisableBackgroundCleanup: true,
+	})
+	for i := 1; i <= 3; i++ {
+		s.Set(i, i)
+	}
+	s.Get(1)
+	s.Set(4, 4)
+	return s
+}
+
+func TestEvictionLRU(t *testing.T) {
+	s := fillAndTouch(fcache.EvictionLRU)
+	if s.Contains(2) {
+		t.Error("key 2, the least recently used, was not evicted")
+	}
+	for _, key := range []int{1, 3, 4} {
+		if !s.Contains(key) {
+			t.Errorf("key %d was evicted; want only key 2 evicted", key)
+		}
+	}
+}
+
+func TestEvictionFIFO(t *testing.T) {
+	s := fillAndTouch(fcache.EvictionFIFO)
+	if s.Contains(1) {
+		t.Error("key 1, the first inserted, was not evicted despite the hit")
+	}
+	for _, key := range []int{2, 3, 4} {
+		if !s.Contains(key) {
+			t.Errorf("key %d was evicted; want only key 1 evicted", key)
+		}
+	}
+
+	// Overwriting keeps the insertion position
+	s.Set(2, 20)
+	s.Set(5, 5)
+	if s.Contains(2) {
+		t.Error("overwritten key 2 was not evicted next in FIFO order")
+	}
+}
+
+func TestEvictionRandom(t *testing.T) {
+	evictedKeys := make(map[int]bool)
+	for run := 0; run < 50; run++ {
+		s := fillAndTouch(fcache.EvictionRandom)
+		if n := s.Len(); n != 3 {
+			t.Fatalf("Len = %d; want capacity 3", n)
+		}
+		if !s.Contains(4) {
+			t.Fatal("the new entry was evicted")
+		}
+		for key := 1; key <= 3; key++ {
+			if !s.Contains(key) {
+				evictedKeys[key] = true
+			}
+		}
+	}
+	if len(evictedKeys) < 2 {
+		t.Errorf("evicted keys over 50 runs = %v; want a varying victim", evictedKeys)
+	}
+}
+
+func TestEvictionPolicyThroughHandle(t *testing.T) {
+	var evicted []int
+	h := fcache.NewHandle(func(key int) (int, error) { return key, nil }, &fcache.Config{
+		Capacity:       2,
+		EvictionPolicy: fcache.EvictionFIFO,
+	}, &fcache.Hooks{
+		OnEvict: func(hc fcache.HookContext) error {
+			evicted = append(evicted, hc.Value.(int))
+			return nil
+		},
+	})
+	h.Call(1)
+	h.Call(2)
+	h.Call(1) // a hit doesn't protect key 1 under FIFO
+	h.Call(3)
+	if len(evicted) != 1 || evicted[0] != 1 {
+		t.Errorf("evicted = %v; want [1]", evicted)
+	}
+}
+
+// newSLRU returns a storage of capacity 5 under SLRU, whose protected segment holds 4 entries.
+func newSLRU() *core.Storage[int, int] {
+	return core.NewStorage[int, int](core.Config{
+		TTL:                      time.Minute,
+		Capacity:                 5,
+		EvictionPolicy:           fcache.EvictionSLRU,
+		DisableBackgroundCleanup: true,
+	})
+}
+
+func TestEvictionSLRUResistsScan(t *testing.T) {
+	s := newSLRU()
+	for key := 1; key <= 3; key++ {
+		s.Set(key, key)
+		s.Get(key) // the second access promotes the key
+	}
+	for key := 100; key < 120; key++ {
+		s.Set(key, key)
+	}
+	for key := 1; key <= 3; key++ {
+		if !s.Contains(key) {
+			t.Errorf("hot key %d was evicted by the scan", key)
+		}
+	}
+	if !s.Contains(118) || !s.Contains(119) || s.Contains(117) {
+		t.Error("the scan did not keep only its two newest keys in the probationary segment")
+	}
+	if n := s.Len(); n != 5 {
+		t.Errorf("Len = %d; want capacity 5", n)
+	}
+}
+
+func TestEvictionSLRUEvictsProbationFirst(t *testing.T) {
+	s := fillAndTouch(fcache.EvictionSLRU)
+	// key 1 was promoted by its hit; the oldest probationary entry goes first
+	if s.Contains(2) {
+		t.Error("key 2, the least recently used probationary entry, was not evicted")
+	}
+	for _, key := range []int{1, 3, 4} {
+		if !s.Contains(key) {
+			t.Errorf("key %d was evicted; want only key 2 evicted", key)
+		}
+	}
+}
+
+func TestEvictionSLRUDemotesOverflow(t *testing.T) {
+	s := newSLRU()
+	for key := 1; key <= 5; key++ {
+		s.Set(key, key)
+	}
+	for key := 1; key <= 5; key++ {
+		s.Get(key) // promoting key 5 overflows the protected segment and demotes key 1
+	}
+	s.Set(6, 6)
+	if s.Contains(1) {
+		t.Error("demoted key 1 was not evicted")
+	}
+	for key := 2; key <= 6; key++ {
+		if !s.Contains(key) {
+			t.Errorf("key %d was evicted; want only key 1 evicted", key)
+		}
+	}
+
+	// A hit on a demoted entry promotes it again
+	s.Set(1, 1)
+	s.Get(1)
+	s.Set(7, 7)
+	if !s.Contains(1) {
+		t.Error("re-promoted key 1 was evicted")
+	}
+}
+
+func TestEvictionSLRUClearAndDelete(t *testing.T) {
+	s := newSLRU()
+	for key := 1; key <= 5; key++ {
+		s.Set(key, key)
+		s.Get(key)
+	}
+	s.Delete(3)
+	s.Clear()
+	for key := 10; key < 20; key++ {
+		s.Set(key, key)
+		if key%2 == 0 {
+			s.Get(key)
+		}
+	}
+	if n := s.Len(); n != 5 {
+		t.Fatalf("Len = %d; want capacity 5", n)
+	}
+	for _, key := range []int{12, 14, 16, 18} {
+		if !s.Contains(key) {
+			t.Errorf("promoted key %d was evicted", key)
+		}
+	}
+}
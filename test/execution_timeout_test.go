@@ -0,0 +1,70 @@
+package test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestExecutionTimeoutReleasesWaiters(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	defer close(release)
+
+	fn := func(key int) (int, error) {
+		if calls.Add(1) == 1 {
+			<-release // the first execution hangs well past the timeout
+		}
+		return key, nil
+	}
+
+	cache := fcache.NewCachedFunction(fn, &fcache.Config{
+		ExecutionTimeout: 50 * time.Millisecond,
+	}, &fcache.Hooks{})
+
+	var wg sync.WaitGroup
+	errsCh := make(chan error, 5)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache(1)
+			errsCh <- err
+		}()
+	}
+	wg.Wait()
+	close(errsCh)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("callers waited %v; want about the 50ms timeout", elapsed)
+	}
+	for err := range errsCh {
+		if !errors.Is(err, fcache.ErrExecutionTimeout) {
+			t.Errorf("err = %v; want ErrExecutionTimeout", err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d; want 1 (waiters share the timed-out call)", got)
+	}
+
+	// The in-flight marker is cleared, so a later call retries.
+	v, err := cache(1)
+	if err != nil || v != 1 {
+		t.Errorf("retry = %d, %v; want 1, nil", v, err)
+	}
+}
+
+func TestExecutionTimeoutNotHitByFastCalls(t *testing.T) {
+	cache := fcache.NewCachedFunction(func(key int) (int, error) {
+		return key * 2, nil
+	}, &fcache.Config{ExecutionTimeout: time.Second}, &fcache.Hooks{})
+
+	if v, err := cache(21); err != nil || v != 42 {
+		t.Errorf("cache(21) = %d, %v; want 42, nil", v, err)
+	}
+}
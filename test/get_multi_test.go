@@ -0,0 +1,37 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestGetMultiReturnsFoundAndMissing(t *testing.T) {
+	fn := func(key int) (int, error) {
+		return key * 2, nil
+	}
+
+	h := fcache.NewHandleComparable(fn, &fcache.Config{
+		TTL:      time.Minute,
+		Capacity: 3,
+	}, &fcache.Hooks{})
+
+	h.Call(1)
+	h.Call(2)
+	h.Call(3)
+
+	found, missing := h.GetMulti([]int{1, 4, 3})
+	if len(found) != 2 || found[1] != 2 || found[3] != 6 {
+		t.Errorf("found = %v; want map[1:2 3:6]", found)
+	}
+	if len(missing) != 1 || missing[0] != 4 {
+		t.Errorf("missing = %v; want [4]", missing)
+	}
+
+	// GetMulti refreshed 1 and 3, so inserting 4 evicts 2
+	h.Call(4)
+	if !h.Contains(1) || h.Contains(2) || !h.Contains(3) {
+		t.Errorf("GetMulti did not update LRU order: contains 1=%v 2=%v 3=%v", h.Contains(1), h.Contains(2), h.Contains(3))
+	}
+}
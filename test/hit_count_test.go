@@ -0,0 +1,51 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+	"github.com/osmike/fcache/internal/core"
+)
+
+func TestStorageHitCount(t *testing.T) {
+	s := core.NewStorage[string, int](core.Config{
+		TTL:                      time.Minute,
+		Capacity:                 10,
+		DisableBackgroundCleanup: true,
+	})
+	s.Set("hot", 1)
+	s.Set("cold", 2)
+	for i := 0; i < 3; i++ {
+		s.Get("hot")
+	}
+	s.Contains("hot") // not a hit
+
+	hits := map[int]uint64{}
+	for _, item := range s.Stats().Items {
+		hits[item.Value] = item.Hits
+	}
+	if hits[1] != 3 || hits[2] != 0 {
+		t.Errorf("hits = %v; want hot 3 and cold 0", hits)
+	}
+
+	s.Set("hot", 1) // an overwrite keeps the count
+	s.Get("hot")
+	if item := s.Stats().Items[0]; item.Hits != 4 {
+		t.Errorf("hits after an overwrite and a Get = %d; want 4", item.Hits)
+	}
+}
+
+func TestCacheHitCountInStats(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, nil, nil)
+	h.Call(1) // a miss: stored with no hits
+	h.Call(1)
+	h.Call(1)
+
+	items := h.Stats().Items
+	if len(items) != 1 || items[0].Hits != 2 {
+		t.Errorf("Stats items = %+v; want one entry with 2 hits", items)
+	}
+}
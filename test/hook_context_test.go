@@ -0,0 +1,43 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestContextHooksReceiveKeyAndResult(t *testing.T) {
+	errOdd := errors.New("odd")
+	fn := func(key int) (int, error) {
+		if key%2 != 0 {
+			return 0, errOdd
+		}
+		return key * 10, nil
+	}
+
+	var set, get, done []fcache.HookContext
+	cache := fcache.NewCachedFunction(fn, &fcache.Config{
+		TTL:      time.Minute,
+		Capacity: 10,
+	}, &fcache.Hooks{
+		OnSetContext:  func(hc fcache.HookContext) error { set = append(set, hc); return nil },
+		OnGetContext:  func(hc fcache.HookContext) error { get = append(get, hc); return nil },
+		OnDoneContext: func(hc fcache.HookContext) error { done = append(done, hc); return nil },
+	})
+
+	cache(2) // miss: OnDone + OnSet
+	cache(2) // hit: OnGet
+	cache(3) // error: OnDone only
+
+	if len(set) != 1 || set[0].Key == "" || set[0].Arg != 2 || set[0].Value != 20 {
+		t.Errorf("OnSetContext events = %+v; want one event with key, arg 2, value 20", set)
+	}
+	if len(get) != 1 || get[0].Key != set[0].Key || get[0].Value != 20 {
+		t.Errorf("OnGetContext events = %+v; want one hit with the stored key and value", get)
+	}
+	if len(done) != 2 || done[1].Arg != 3 || !errors.Is(done[1].Err, errOdd) {
+		t.Errorf("OnDoneContext events = %+v; want second event carrying the function error", done)
+	}
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestHookTimeoutAbandonsSlowHook(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	var mu sync.Mutex
+	var logged []error
+	cache := fcache.NewCachedFunction(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{HookTimeout: 20 * time.Millisecond}, &fcache.Hooks{
+		OnSet: func(arg any) error {
+			<-release // a hook stuck on a dead dependency
+			return nil
+		},
+		LogError: func(err error) {
+			mu.Lock()
+			logged = append(logged, err)
+			mu.Unlock()
+		},
+	})
+
+	start := time.Now()
+	if v, err := cache(1); err != nil || v != 1 {
+		t.Fatalf("call = (%d, %v); want (1, nil)", v, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("call took %v; the stuck hook should have been abandoned", elapsed)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(logged) != 1 || !errors.Is(logged[0], fcache.ErrHookTimeout) {
+		t.Errorf("LogError received %v; want one ErrHookTimeout", logged)
+	}
+}
+
+func TestHookTimeoutFastHookRunsToCompletion(t *testing.T) {
+	var calls int
+	var logged error
+	cache := fcache.NewCachedFunction(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{HookTimeout: time.Second}, &fcache.Hooks{
+		OnSet:    func(arg any) error { calls++; return errors.New("hook failed") },
+		LogError: func(err error) { logged = err },
+	})
+
+	cache(1)
+	// the cache waited for the hook, so its effects and error are visible once the call returns
+	if calls != 1 {
+		t.Errorf("OnSet ran %d times; want 1", calls)
+	}
+	if logged == nil || errors.Is(logged, fcache.ErrHookTimeout) {
+		t.Errorf("LogError received %v; want the hook's own error", logged)
+	}
+}
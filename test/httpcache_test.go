@@ -0,0 +1,166 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+	"github.com/osmike/fcache/httpcache"
+)
+
+// countingServer serves the request count and the Accept header, using the status and
+// Cache-Control value from the query string.
+func countingServer(t *testing.T, hits *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		if cc := r.URL.Query().Get("cc"); cc != "" {
+			w.Header().Set("Cache-Control", cc)
+		}
+		if r.URL.Query().Get("missing") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		fmt.Fprintf(w, "%d %s", n, r.Header.Get("Accept"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, client *http.Client, url string, header ...string) string {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read %s: %v", url, err)
+	}
+	return string(b)
+}
+
+func newCachingClient(opts *fcache.Config, keyHeaders ...string) *http.Client {
+	return &http.Client{Transport: httpcache.NewTransport(nil, opts, nil, keyHeaders...)}
+}
+
+func TestTransportCachesGetResponses(t *testing.T) {
+	var hits atomic.Int32
+	srv := countingServer(t, &hits)
+	client := newCachingClient(&fcache.Config{TTL: time.Minute})
+
+	first := get(t, client, srv.URL+"/a")
+	if again := get(t, client, srv.URL+"/a"); again != first {
+		t.Errorf("second GET = %q; want cached %q", again, first)
+	}
+	get(t, client, srv.URL+"/b")
+	if got := hits.Load(); got != 2 {
+		t.Errorf("server hits = %d; want 2 (one per URL)", got)
+	}
+}
+
+func TestTransportKeysByHeaders(t *testing.T) {
+	var hits atomic.Int32
+	srv := countingServer(t, &hits)
+	client := newCachingClient(nil, "Accept")
+
+	j := get(t, client, srv.URL, "Accept", "application/json")
+	x := get(t, client, srv.URL, "Accept", "text/xml")
+	if j == x {
+		t.Errorf("responses for different Accept headers are both %q", j)
+	}
+	if again := get(t, client, srv.URL, "Accept", "application/json"); again != j {
+		t.Errorf("GET with same Accept = %q; want cached %q", again, j)
+	}
+}
+
+func TestTransportSkipsUncacheableResponses(t *testing.T) {
+	for _, query := range []string{"?cc=no-store", "?cc=max-age=0,%20no-store", "?missing=1"} {
+		var hits atomic.Int32
+		srv := countingServer(t, &hits)
+		client := newCachingClient(nil)
+
+		get(t, client, srv.URL+query)
+		get(t, client, srv.URL+query)
+		if got := hits.Load(); got != 2 {
+			t.Errorf("%s: server hits = %d; want 2 (not cached)", query, got)
+		}
+	}
+}
+
+func TestTransportBypassesOtherMethodsAndNoStoreRequests(t *testing.T) {
+	var hits atomic.Int32
+	srv := countingServer(t, &hits)
+	client := newCachingClient(nil)
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Post(srv.URL, "text/plain", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		get(t, client, srv.URL, "Cache-Control", "no-store")
+	}
+	if got := hits.Load(); got != 4 {
+		t.Errorf("server hits = %d; want 4 (nothing cached)", got)
+	}
+}
+
+func TestTransportSharedFetchOutlivesCancelledCaller(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		fmt.Fprint(w, "shared")
+	}))
+	t.Cleanup(srv.Close)
+	client := newCachingClient(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		_, err := client.Do(req)
+		first <- err
+	}()
+	if !waitFor(func() bool { return hits.Load() == 1 }) {
+		t.Fatal("the first request never reached the server")
+	}
+	second := make(chan string, 1)
+	go func() {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			second <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		second <- string(b)
+	}()
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller err = %v; want context.Canceled", err)
+	}
+	close(release)
+	if body := <-second; body != "shared" {
+		t.Errorf("waiting caller got %q; want the shared response", body)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server hits = %d; want 1 (one shared round trip)", got)
+	}
+}
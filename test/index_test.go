@@ -0,0 +1,85 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+type indexedUser struct {
+	ID    int
+	Email string
+}
+
+func newUserIndex(t *testing.T, cfg *fcache.Config) *fcache.Handle[int, indexedUser] {
+	t.Helper()
+	emails := map[int]string{1: "ann@example.com", 2: "bob@example.com", 3: "cid@example.com"}
+	cfg.IndexFunc = func(u indexedUser) string { return u.Email }
+	return fcache.NewHandle(func(id int) (indexedUser, error) {
+		return indexedUser{ID: id, Email: emails[id]}, nil
+	}, cfg, nil)
+}
+
+func TestLookupByIndex(t *testing.T) {
+	h := newUserIndex(t, &fcache.Config{Capacity: 2})
+	h.Call(1)
+	h.Call(2)
+
+	if u, ok := h.LookupByIndex("bob@example.com"); !ok || u.ID != 2 {
+		t.Errorf("LookupByIndex(bob) = (%+v, %v); want user 2", u, ok)
+	}
+	if _, ok := h.LookupByIndex("cid@example.com"); ok {
+		t.Error("LookupByIndex(cid) found an uncached user")
+	}
+
+	// An overwrite moves the entry to its new attribute
+	h.Set(1, indexedUser{ID: 1, Email: "ann@new.example.com"})
+	if _, ok := h.LookupByIndex("ann@example.com"); ok {
+		t.Error("LookupByIndex found the overwritten attribute")
+	}
+	if u, ok := h.LookupByIndex("ann@new.example.com"); !ok || u.ID != 1 {
+		t.Errorf("LookupByIndex(new ann) = (%+v, %v); want user 1", u, ok)
+	}
+
+	// Evictions and invalidations drop their entries from the index
+	h.Call(3) // evicts the least recently used user 2
+	if _, ok := h.LookupByIndex("bob@example.com"); ok {
+		t.Error("LookupByIndex found an evicted user")
+	}
+	h.Invalidate(3)
+	if _, ok := h.LookupByIndex("cid@example.com"); ok {
+		t.Error("LookupByIndex found an invalidated user")
+	}
+	h.Clear()
+	if _, ok := h.LookupByIndex("ann@new.example.com"); ok {
+		t.Error("LookupByIndex found a user after Clear")
+	}
+}
+
+func TestLookupByIndexExpiry(t *testing.T) {
+	clock := newFakeClock()
+	h := newUserIndex(t, &fcache.Config{TTL: time.Minute, Clock: clock, DisableBackgroundCleanup: true})
+	h.Call(1)
+	clock.Advance(2 * time.Minute)
+	if _, ok := h.LookupByIndex("ann@example.com"); ok {
+		t.Error("LookupByIndex found an expired user")
+	}
+	h.PurgeExpired()
+	h.Call(1)
+	if u, ok := h.LookupByIndex("ann@example.com"); !ok || u.ID != 1 {
+		t.Errorf("LookupByIndex after recompute = (%+v, %v); want user 1", u, ok)
+	}
+}
+
+func TestLookupByIndexScoped(t *testing.T) {
+	h := newUserIndex(t, &fcache.Config{})
+	a, b := h.Scoped("a"), h.Scoped("b")
+	a.Call(1)
+	if _, ok := b.LookupByIndex("ann@example.com"); ok {
+		t.Error("view b found an entry of view a")
+	}
+	if _, ok := a.LookupByIndex("ann@example.com"); !ok {
+		t.Error("view a did not find its own entry")
+	}
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestOnInflightJoinFiresForJoiners(t *testing.T) {
+	var executes atomic.Int32
+	var mu sync.Mutex
+	var joins []fcache.InflightJoinEvent
+
+	release := make(chan struct{})
+	cached := fcache.NewCachedFunction(func(key int) (int, error) {
+		<-release
+		return key, nil
+	}, nil, &fcache.Hooks{
+		OnExecute: func(arg any) error {
+			executes.Add(1)
+			return nil
+		},
+		OnInflightJoin: func(arg any) error {
+			mu.Lock()
+			joins = append(joins, arg.(fcache.InflightJoinEvent))
+			mu.Unlock()
+			return nil
+		},
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		cached(7) // the leader
+	}()
+	waitFor(func() bool { return executes.Load() == 1 })
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cached(7)
+		}()
+	}
+	waitFor(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(joins) == 3
+	})
+	close(release)
+	wg.Wait()
+
+	if n := executes.Load(); n != 1 {
+		t.Errorf("OnExecute fired %d times; want 1, for the leader", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(joins) != 3 {
+		t.Fatalf("OnInflightJoin fired %d times; want 3", len(joins))
+	}
+	seen := map[int]bool{}
+	for _, ev := range joins {
+		if ev.Arg != 7 || ev.Key == "" {
+			t.Errorf("join event = %+v; want the argument 7 and its key", ev)
+		}
+		seen[ev.Waiters] = true
+	}
+	for n := 1; n <= 3; n++ {
+		if !seen[n] {
+			t.Errorf("no join reported %d waiters; got %+v", n, joins)
+		}
+	}
+}
+
+func TestOnInflightJoinNotFiredForHits(t *testing.T) {
+	var joins atomic.Int32
+	cached := fcache.NewCachedFunction(func(key int) (int, error) {
+		return key, nil
+	}, nil, &fcache.Hooks{
+		OnInflightJoin: func(arg any) error {
+			joins.Add(1)
+			return nil
+		},
+	})
+	cached(1)
+	cached(1)
+	time.Sleep(10 * time.Millisecond)
+	if n := joins.Load(); n != 0 {
+		t.Errorf("OnInflightJoin fired %d times for sequential calls; want 0", n)
+	}
+}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+// TestInflightCompletionWindow hammers fresh keys with callers arriving while the leader completes.
+// A caller that missed the store just before the leader stored its result must be served the stored
+// value or join the in-flight call, never run the function a second time.
+func TestInflightCompletionWindow(t *testing.T) {
+	const rounds, callers = 300, 16
+	var calls [rounds]atomic.Int32
+	h := fcache.NewHandle(func(key int) (int, error) {
+		calls[key].Add(1)
+		return key, nil
+	}, &fcache.Config{Capacity: rounds}, nil)
+
+	for key := 0; key < rounds; key++ {
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				for range i % 4 {
+					runtime.Gosched() // stagger the arrivals around the completion
+				}
+				if v, err := h.Call(key); v != key || err != nil {
+					t.Errorf("Call(%d) = (%d, %v)", key, v, err)
+				}
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+	}
+	for key := range calls {
+		if n := calls[key].Load(); n != 1 {
+			t.Errorf("key %d computed %d times; want 1", key, n)
+		}
+	}
+}
+
+// TestMissRacingCompletionIsServedFromStore forces the window deterministically: the OnRemove hook of
+// an expired entry runs in the caller after its lookup missed and before it looks for an in-flight call,
+// and meanwhile another caller computes and stores the key.
+func TestMissRacingCompletionIsServedFromStore(t *testing.T) {
+	clock := newFakeClock()
+	var calls atomic.Int32
+	var h *fcache.Handle[int, int]
+	var once sync.Once
+	h = fcache.NewHandle(func(key int) (int, error) {
+		calls.Add(1)
+		return key, nil
+	}, &fcache.Config{TTL: time.Minute, Clock: clock, DisableBackgroundCleanup: true}, &fcache.Hooks{
+		OnRemove: func(hc fcache.HookContext) error {
+			once.Do(func() {
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					h.Call(1) // completes the whole call while the first caller is between its miss and compute
+				}()
+				<-done
+			})
+			return nil
+		},
+	})
+
+	h.Set(1, 0)
+	clock.Advance(2 * time.Minute)
+	if v, err := h.Call(1); v != 1 || err != nil {
+		t.Fatalf("Call(1) = (%d, %v); want (1, nil)", v, err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("function ran %d times; want 1, the racing miss served from the store", n)
+	}
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+func TestInvalidateFuncRemovesMatchingEntries(t *testing.T) {
+	handle := fcache.NewHandle(func(key string) (string, error) {
+		return strings.ToUpper(key), nil
+	}, nil, &fcache.Hooks{})
+
+	for _, k := range []string{"user1:profile", "user1:orders", "user2:profile"} {
+		handle.Call(k)
+	}
+
+	removed := handle.InvalidateFunc(func(key string) bool {
+		return strings.Contains(key, "user1:")
+	})
+	if removed != 2 {
+		t.Errorf("InvalidateFunc removed %d; want 2", removed)
+	}
+	if handle.Contains("user1:profile") || handle.Contains("user1:orders") {
+		t.Error("matching entries are still cached")
+	}
+	if !handle.Contains("user2:profile") {
+		t.Error("non-matching entry was removed")
+	}
+}
+
+func TestInvalidateFuncOnScopedView(t *testing.T) {
+	handle := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, nil, &fcache.Hooks{})
+	a, b := handle.Scoped("a"), handle.Scoped("b")
+	for i := 0; i < 3; i++ {
+		a.Call(i)
+		b.Call(i)
+	}
+	handle.Call(0)
+
+	if removed := a.InvalidateFunc(func(string) bool { return true }); removed != 3 {
+		t.Errorf("InvalidateFunc on view a removed %d; want 3", removed)
+	}
+	if a.Contains(0) {
+		t.Error("tenant a still has entries")
+	}
+	if !b.Contains(0) || !handle.Contains(0) {
+		t.Error("InvalidateFunc on view a removed entries of other namespaces")
+	}
+
+	// keys are passed without the namespace prefix
+	var keys []string
+	b.InvalidateFunc(func(key string) bool {
+		keys = append(keys, key)
+		return false
+	})
+	for _, k := range keys {
+		if strings.Contains(k, "b:") {
+			t.Errorf("key %q passed with the namespace prefix", k)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestJSONLoggerWritesEvents(t *testing.T) {
+	fn := func(key string) (string, error) {
+		switch key {
+		case "boom":
+			panic("boom")
+		case "fail":
+			return "", errors.New("backend down")
+		}
+		return "v-" + key, nil
+	}
+
+	var buf bytes.Buffer
+	logger := fcache.NewJSONLogger(&buf)
+	hooks := logger.Hooks()
+	hooks.OnEvict = logger.Event("evict")
+	h := fcache.NewHandle(fn, &fcache.Config{TTL: time.Minute, Capacity: 1}, hooks)
+
+	h.Call("a")
+	h.Call("b") // evicts a
+	h.Call("fail")
+	h.Call("boom")
+
+	var lines []map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var line map[string]any
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", raw, err)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, line["time"].(string)); err != nil {
+			t.Errorf("invalid time in %q: %v", raw, err)
+		}
+		lines = append(lines, line)
+	}
+
+	find := func(event string, match func(map[string]any) bool) map[string]any {
+		t.Helper()
+		for _, line := range lines {
+			if line["event"] == event && match(line) {
+				return line
+			}
+		}
+		t.Fatalf("no %q event in:\n%s", event, buf.String())
+		return nil
+	}
+	keyed := func(line map[string]any) bool { return strings.Contains(line["key"].(string), "a") }
+
+	if line := find("remove", keyed); line["reason"] != "capacity" {
+		t.Errorf("remove reason = %v, want capacity", line["reason"])
+	}
+	find("evict", keyed)
+	find("error", func(line map[string]any) bool { return line["error"] == "backend down" && line["type"] == nil })
+
+	panicLine := find("error", func(line map[string]any) bool { return line["type"] == fcache.ErrPanic.Error() })
+	fields, ok := panicLine["fields"].(map[string]any)
+	if !ok || fields["panic"] != "boom" {
+		t.Errorf("panic fields = %v, want the panic value", panicLine["fields"])
+	}
+}
+
+func TestJSONLoggerHookDetails(t *testing.T) {
+	var buf bytes.Buffer
+	logger := fcache.NewJSONLogger(&buf)
+
+	_ = logger.Hook("join")(fcache.InflightJoinEvent{Key: "k", Waiters: 3})
+	_ = logger.Hook("custom")("secret argument")
+
+	out := buf.String()
+	dec := json.NewDecoder(strings.NewReader(out))
+	var join, custom map[string]any
+	if err := dec.Decode(&join); err != nil {
+		t.Fatal(err)
+	}
+	if err := dec.Decode(&custom); err != nil {
+		t.Fatal(err)
+	}
+	if join["key"] != "k" || join["fields"].(map[string]any)["waiters"] != float64(3) {
+		t.Errorf("join line = %v, want key and waiters", join)
+	}
+	if strings.Contains(out, "secret") || custom["event"] != "custom" || custom["key"] != nil {
+		t.Errorf("custom line = %v, want the event name only", custom)
+	}
+}
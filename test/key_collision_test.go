@@ -0,0 +1,121 @@
+package test
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+// opaqueID has only unexported fields, so all its values marshal to the same JSON object.
+type opaqueID struct {
+	id int
+}
+
+// query is a well-keyed argument type.
+type query struct {
+	Table string
+	Limit int
+}
+
+func TestCheckKeyCollisionReportsDistinctArgs(t *testing.T) {
+	collisions, err := fcache.CheckKeyCollision(opaqueID{1}, opaqueID{2}, opaqueID{1})
+	if err != nil {
+		t.Fatalf("CheckKeyCollision: %v", err)
+	}
+	if len(collisions) != 1 {
+		t.Fatalf("collisions = %v; want one shared key", collisions)
+	}
+	for key, args := range collisions {
+		if len(args) != 2 || args[0] != (opaqueID{1}) || args[1] != (opaqueID{2}) {
+			t.Errorf("key %q shared by %v; want [{1} {2}] (equal arguments listed once)", key, args)
+		}
+	}
+}
+
+func TestCheckKeyCollisionNoCollisions(t *testing.T) {
+	collisions, err := fcache.CheckKeyCollision(
+		query{"users", 10}, query{"users", 20}, query{"orders", 10}, query{"users", 10},
+	)
+	if err != nil || len(collisions) != 0 {
+		t.Errorf("CheckKeyCollision = %v, %v; want no collisions", collisions, err)
+	}
+}
+
+func TestCheckKeyCollisionKeyError(t *testing.T) {
+	_, err := fcache.CheckKeyCollision[any](1, func() {})
+	if !errors.Is(err, fcache.ErrKeyGeneration) {
+		t.Errorf("err = %v; want ErrKeyGeneration", err)
+	}
+}
+
+// event nests a time the key builder keys by instant.
+type event struct {
+	Name string
+	At   *time.Time
+}
+
+func TestCheckKeyCollisionMergesLikeKeys(t *testing.T) {
+	now := time.Now() // carries a monotonic reading, which Round(0) strips
+	elsewhere := now.Round(0).In(time.FixedZone("UTC+2", 2*60*60))
+	for name, check := range map[string]func() (int, error){
+		"time": func() (int, error) {
+			c, err := fcache.CheckKeyCollision(now, now.Round(0), elsewhere)
+			return len(c), err
+		},
+		"nested time": func() (int, error) {
+			c, err := fcache.CheckKeyCollision(event{"deploy", &now}, event{"deploy", &elsewhere})
+			return len(c), err
+		},
+		"NaN": func() (int, error) {
+			c, err := fcache.CheckKeyCollision(math.NaN(), math.Float64frombits(math.Float64bits(math.NaN())^1))
+			return len(c), err
+		},
+	} {
+		if n, err := check(); err != nil || n != 0 {
+			t.Errorf("%s: %d collisions, err %v; want none between arguments sharing a key on purpose", name, n, err)
+		}
+	}
+
+	later := now.Add(time.Second)
+	c, err := fcache.CheckKeyCollision(event{"deploy", &now}, event{"deploy", &later})
+	if err != nil || len(c) != 0 {
+		t.Errorf("distinct instants: %v, %v; want distinct keys", c, err)
+	}
+}
+
+// account is a Stringer whose String omits the ID, so distinct accounts with the same owner print the same.
+type account struct {
+	ID    int
+	Owner string
+}
+
+func (a account) String() string { return a.Owner }
+
+func TestCheckKeyCollisionFindsLossyStringer(t *testing.T) {
+	collisions, err := fcache.CheckKeyCollision(account{1, "ann"}, account{2, "ann"}, account{3, "bob"})
+	if err != nil {
+		t.Fatalf("CheckKeyCollision: %v", err)
+	}
+	if len(collisions) != 1 {
+		t.Fatalf("collisions = %v; want the two accounts of ann to share a key", collisions)
+	}
+	for _, args := range collisions {
+		if len(args) != 2 || args[0].ID != 1 || args[1].ID != 2 {
+			t.Errorf("colliding args = %v; want accounts 1 and 2", args)
+		}
+	}
+
+	// the collision is real: the second account is served the first one's entry
+	calls := 0
+	balance := fcache.NewCachedFunction(func(a account) (int, error) {
+		calls++
+		return a.ID * 100, nil
+	}, nil, nil)
+	balance(account{1, "ann"})
+	if v, _ := balance(account{2, "ann"}); v != 100 || calls != 1 {
+		t.Errorf("balance(account 2) = %d after %d calls; want the shared entry 100 after 1", v, calls)
+	}
+}
@@ -0,0 +1,75 @@
+package test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+type fieldsRequest struct {
+	ID        int
+	TenantID  string
+	RequestID string
+	Sent      time.Time
+}
+
+func TestKeyFieldsIgnoreOtherFields(t *testing.T) {
+	var calls atomic.Int32
+	cached := fcache.New(func(req fieldsRequest) (string, error) {
+		calls.Add(1)
+		return req.TenantID, nil
+	}, fcache.WithKeyFields("ID", "TenantID"))
+
+	cached(fieldsRequest{ID: 1, TenantID: "a", RequestID: "r1", Sent: time.Now()})
+	cached(fieldsRequest{ID: 1, TenantID: "a", RequestID: "r2", Sent: time.Now().Add(time.Second)})
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d; want 1, volatile fields must not affect the key", n)
+	}
+
+	cached(fieldsRequest{ID: 2, TenantID: "a"})
+	cached(fieldsRequest{ID: 1, TenantID: "b"})
+	if n := calls.Load(); n != 3 {
+		t.Errorf("calls = %d; want 3, each selected field splits the key", n)
+	}
+}
+
+func TestKeyFieldsThroughPointers(t *testing.T) {
+	var calls atomic.Int32
+	h := fcache.NewHandle(func(req *fieldsRequest) (int, error) {
+		calls.Add(1)
+		return req.ID, nil
+	}, &fcache.Config{KeyFields: []string{"ID"}}, nil)
+
+	h.Call(&fieldsRequest{ID: 7, RequestID: "r1"})
+	h.Call(&fieldsRequest{ID: 7, RequestID: "r2"})
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d; want 1", n)
+	}
+}
+
+func TestKeyFieldsUnknownFieldPanics(t *testing.T) {
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, fcache.ErrInvalidConfig) {
+			t.Fatalf("recovered %v; want ErrInvalidConfig", err)
+		}
+		var e *fcache.Error
+		if !errors.As(err, &e) || e.Fields["field"] != "KeyFields" {
+			t.Errorf("error fields = %v; want field KeyFields", e.Fields)
+		}
+	}()
+	fcache.New(func(req fieldsRequest) (int, error) { return req.ID, nil }, fcache.WithKeyFields("ID", "Missing"))
+}
+
+func TestKeyFieldsInterfaceArgumentFailsPerCall(t *testing.T) {
+	cached := fcache.New(func(req any) (int, error) { return 0, nil }, fcache.WithKeyFields("ID"))
+	if _, err := cached(fieldsRequest{ID: 1}); err != nil {
+		t.Errorf("cached(fieldsRequest) = %v; want nil", err)
+	}
+	if _, err := cached(struct{ Name string }{"x"}); !errors.Is(err, fcache.ErrKeyGeneration) {
+		t.Errorf("cached(struct without ID) = %v; want ErrKeyGeneration", err)
+	}
+}
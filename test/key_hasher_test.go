@@ -0,0 +1,56 @@
+package test
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+// snapshotKeyOf stores the result for arg in a cache configured with hasher and returns its key.
+func snapshotKeyOf(t *testing.T, hasher fcache.KeyHasher, arg string) string {
+	t.Helper()
+	h := fcache.NewHandle(func(s string) (int, error) { return len(s), nil }, &fcache.Config{KeyHasher: hasher}, nil)
+	h.Call(arg)
+	keys := h.SnapshotKeys()
+	if len(keys) != 1 {
+		t.Fatalf("SnapshotKeys() = %v; want one key", keys)
+	}
+	return keys[0]
+}
+
+func TestKeyHasherBase64SHA256(t *testing.T) {
+	arg := strings.Repeat("blob", 50)
+	sum := sha256.Sum256([]byte("s:" + arg)) // string arguments are keyed as "s:" + value
+	want := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	got := snapshotKeyOf(t, fcache.KeyHasher{Encode: base64.RawURLEncoding.EncodeToString}, arg)
+	if got != want {
+		t.Errorf("key = %q; want base64 SHA-256 %q", got, want)
+	}
+
+	// Short keys are not hashed
+	if got := snapshotKeyOf(t, fcache.KeyHasher{Encode: base64.RawURLEncoding.EncodeToString}, "id"); got != "s:id" {
+		t.Errorf("short key = %q; want s:id", got)
+	}
+}
+
+func TestKeyHasherCustomHashFunction(t *testing.T) {
+	arg := strings.Repeat("blob", 50)
+	sum := sha512.Sum512([]byte("s:" + arg))
+	if got, want := snapshotKeyOf(t, fcache.KeyHasher{New: sha512.New}, arg), hex.EncodeToString(sum[:]); got != want {
+		t.Errorf("key = %q; want hex SHA-512 %q", got, want)
+	}
+}
+
+func TestKeyHasherDefaultIsHexSHA256(t *testing.T) {
+	arg := strings.Repeat("blob", 50)
+	sum := sha256.Sum256([]byte("s:" + arg))
+	if got, want := snapshotKeyOf(t, fcache.KeyHasher{}, arg), hex.EncodeToString(sum[:]); got != want {
+		t.Errorf("key = %q; want hex SHA-256 %q", got, want)
+	}
+}
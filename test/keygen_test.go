@@ -0,0 +1,240 @@
+package test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache/internal/lib/keygen"
+)
+
+func buildKey(t *testing.T, v any) string {
+	t.Helper()
+	key, err := keygen.BuildKey(v)
+	if err != nil {
+		t.Fatalf("BuildKey(%#v) error: %v", v, err)
+	}
+	return key
+}
+
+func TestFloatKeysAreTypedAndExact(t *testing.T) {
+	if buildKey(t, 1) == buildKey(t, 1.0) {
+		t.Error("int(1) and float64(1.0) share a key")
+	}
+	if buildKey(t, float32(0.5)) != buildKey(t, 0.5) {
+		t.Error("float32(0.5) and float64(0.5) represent the same value but got different keys")
+	}
+	if buildKey(t, float32(0.1)) == buildKey(t, 0.1) {
+		t.Error("float32(0.1) and float64(0.1) are different values but share a key")
+	}
+	a, b := 0.1, 0.2
+	if buildKey(t, a+b) == buildKey(t, 0.3) {
+		t.Error("0.1+0.2 and 0.3 are different values but share a key")
+	}
+}
+
+func TestFloatKeysForSpecialValues(t *testing.T) {
+	nan := buildKey(t, math.NaN())
+	if nan != buildKey(t, math.NaN()) {
+		t.Error("NaN keys are not deterministic")
+	}
+
+	keys := map[string]string{
+		"NaN":  nan,
+		"+Inf": buildKey(t, math.Inf(1)),
+		"-Inf": buildKey(t, math.Inf(-1)),
+		"0":    buildKey(t, 0.0),
+		"-0":   buildKey(t, math.Copysign(0, -1)),
+	}
+	seen := make(map[string]string)
+	for name, key := range keys {
+		if other, ok := seen[key]; ok {
+			t.Errorf("%s and %s share key %q", name, other, key)
+		}
+		seen[key] = name
+	}
+}
+
+func TestTimeKeysIgnoreMonotonicClockAndLocation(t *testing.T) {
+	now := time.Now()
+	if buildKey(t, now) != buildKey(t, now.Round(0)) {
+		t.Error("time.Now() and its monotonic-stripped copy got different keys")
+	}
+	if buildKey(t, now) != buildKey(t, now.In(time.FixedZone("UTC+3", 3*60*60))) {
+		t.Error("the same instant in different locations got different keys")
+	}
+	if buildKey(t, now) == buildKey(t, now.Add(time.Nanosecond)) {
+		t.Error("different instants share a key")
+	}
+
+	stripped, moved := now.Round(0), now.In(time.FixedZone("UTC+3", 3*60*60))
+	if buildKey(t, &now) != buildKey(t, &stripped) || buildKey(t, &now) != buildKey(t, &moved) {
+		t.Error("pointers to the same instant got different keys")
+	}
+	if buildKey(t, &now) != buildKey(t, now) {
+		t.Error("a time and a pointer to it got different keys")
+	}
+	var nilTime *time.Time
+	if buildKey(t, nilTime) != buildKey(t, nil) {
+		t.Error("a nil *time.Time is not keyed like nil")
+	}
+
+	type window struct {
+		From  time.Time
+		To    *time.Time
+		Marks []time.Time
+	}
+	key := func(from, to time.Time) string {
+		return buildKey(t, window{From: from, To: &to, Marks: []time.Time{from, to}})
+	}
+	later := now.Add(time.Hour)
+	if key(now, later) != key(now.Round(0), later.In(time.FixedZone("UTC-5", -5*60*60))) {
+		t.Error("time fields of the same instants got different keys")
+	}
+	if key(now, later) == key(now, later.Add(time.Nanosecond)) {
+		t.Error("time fields of different instants share a key")
+	}
+}
+
+// request is a pointer-keyed argument type.
+type request struct {
+	ID   int
+	Name string
+}
+
+// label implements fmt.Stringer on its pointer type.
+type label struct{ name string }
+
+func (l *label) String() string { return l.name }
+
+func TestNilPointerKeys(t *testing.T) {
+	untyped := buildKey(t, nil)
+	for name, v := range map[string]any{
+		"*request": (*request)(nil),
+		"*string":  (*string)(nil),
+		"*label":   (*label)(nil), // String must not be called on the nil receiver
+	} {
+		if got := buildKey(t, v); got != untyped {
+			t.Errorf("nil %s key = %q; want %q like an untyped nil", name, got, untyped)
+		}
+	}
+	if buildKey(t, (*request)(nil)) == buildKey(t, &request{}) {
+		t.Error("a nil *request and a pointer to a zero request share a key")
+	}
+}
+
+func TestPointersToEqualValuesShareKey(t *testing.T) {
+	a, b := &request{ID: 1, Name: "x"}, &request{ID: 1, Name: "x"}
+	if buildKey(t, a) != buildKey(t, b) {
+		t.Error("pointers to equal values got different keys")
+	}
+	if buildKey(t, a) != buildKey(t, *a) {
+		t.Error("a pointer and the value it points to got different keys")
+	}
+	if buildKey(t, a) == buildKey(t, &request{ID: 2, Name: "x"}) {
+		t.Error("pointers to different values share a key")
+	}
+	s := "abc"
+	if buildKey(t, &s) != buildKey(t, s) {
+		t.Error("*string and string got different keys")
+	}
+}
+
+// tag implements fmt.Stringer on its value type.
+type tag struct{ name string }
+
+func (t tag) String() string { return t.name }
+
+func TestPointerReceiverStringerKeys(t *testing.T) {
+	// label has only unexported fields, so without String all label values would marshal to {}
+	if buildKey(t, label{name: "a"}) == buildKey(t, label{name: "b"}) {
+		t.Error("label values with different names share a key")
+	}
+	if buildKey(t, label{name: "a"}) != buildKey(t, &label{name: "a"}) {
+		t.Error("a pointer-receiver Stringer value and a pointer to it got different keys")
+	}
+	if buildKey(t, tag{name: "a"}) != buildKey(t, &tag{name: "a"}) {
+		t.Error("a value-receiver Stringer value and a pointer to it got different keys")
+	}
+	if buildKey(t, tag{name: "a"}) == buildKey(t, tag{name: "b"}) {
+		t.Error("tag values with different names share a key")
+	}
+}
+
+// marshalledKey is the key of a composite value as built by marshalling it to JSON: the JSON itself,
+// or its hex SHA-256 if it is longer than 100 bytes or the value is a map.
+func marshalledKey(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, isMap := v.(map[string]any); isMap || len(data) > 100 {
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	}
+	return string(data)
+}
+
+func TestStreamedCompositeKeysMatchMarshalledJSON(t *testing.T) {
+	type item struct {
+		ID   int
+		Name string
+	}
+	long := make([]int, 1000)
+	for i := range long {
+		long[i] = i
+	}
+	values := []any{
+		[]int{1, 2, 3},
+		[]string{},
+		item{ID: 1, Name: "a"},
+		item{ID: 2, Name: strings.Repeat("n", 80)}, // just over 100 bytes of JSON
+		[]string{strings.Repeat("x", 97)},          // exactly 101 bytes
+		[]string{strings.Repeat("x", 96)},          // exactly 100 bytes, not hashed
+		long,
+		map[string]any{"a": 1},
+		map[string]any{},
+		[]item{{ID: 1, Name: "<b>"}},
+	}
+	for _, v := range values {
+		if got, want := buildKey(t, v), marshalledKey(t, v); got != want {
+			t.Errorf("BuildKey(%.40v) = %q; want %q", v, got, want)
+		}
+	}
+}
+
+func TestContextFieldKeys(t *testing.T) {
+	type request struct {
+		Ctx  context.Context `json:"ctx"`
+		Name string          `json:"name"`
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if a, b := buildKey(t, request{ctx, "x"}), buildKey(t, &request{context.Background(), "x"}); a != b {
+		t.Errorf("keys of a context field differ by context: %q, %q", a, b)
+	}
+	if got, want := buildKey(t, request{ctx, "x"}), `{"ctx":"context","name":"x"}`; got != want {
+		t.Errorf("key = %q; want %q", got, want)
+	}
+
+	// Fields tagged "-" are omitted, contexts included
+	type omitted struct {
+		ID  int
+		Ctx context.Context `json:"-"`
+	}
+	if got := buildKey(t, omitted{ID: 1, Ctx: ctx}); got != `{"ID":1}` {
+		t.Errorf("key = %q; want the context omitted", got)
+	}
+
+	// Types without context fields keep their keys
+	type plain struct{ ID int }
+	if got := buildKey(t, plain{ID: 1}); got != `{"ID":1}` {
+		t.Errorf("key = %q; want the plain JSON", got)
+	}
+}
@@ -0,0 +1,40 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestMetricsLatency(t *testing.T) {
+	handle := fcache.NewHandle(func(ms int) (int, error) {
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+		return ms, nil
+	}, nil, &fcache.Hooks{})
+
+	if m := handle.Metrics(); m.LatencyAvg != 0 || m.LatencyP50 != 0 || m.LatencyP99 != 0 {
+		t.Fatalf("latency before any call = %v/%v/%v; want zero", m.LatencyAvg, m.LatencyP50, m.LatencyP99)
+	}
+
+	// 19 executions of about 10ms and one of about 60ms; hits must not be measured
+	for i := 0; i < 19; i++ {
+		handle.Do(i, func() (int, error) {
+			time.Sleep(10 * time.Millisecond)
+			return i, nil
+		})
+		handle.Do(i, func() (int, error) { return i, nil })
+	}
+	handle.Call(60)
+
+	m := handle.Metrics()
+	if m.LatencyAvg < 10*time.Millisecond || m.LatencyAvg > 30*time.Millisecond {
+		t.Errorf("LatencyAvg = %v; want between 10ms and 30ms", m.LatencyAvg)
+	}
+	if m.LatencyP50 < 9*time.Millisecond || m.LatencyP50 > 20*time.Millisecond {
+		t.Errorf("LatencyP50 = %v; want about 10ms", m.LatencyP50)
+	}
+	if m.LatencyP99 < 50*time.Millisecond {
+		t.Errorf("LatencyP99 = %v; want about 60ms", m.LatencyP99)
+	}
+}
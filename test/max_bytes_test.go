@@ -0,0 +1,150 @@
+package test
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+// document is a struct value sized by the default estimator through its string and slice fields.
+type document struct {
+	ID    int
+	Body  string
+	Lines []string
+}
+
+// liveKeys returns the keys in [0, n) that are still cached.
+func liveKeys[V any](h *fcache.Handle[int, V], n int) []int {
+	var live []int
+	for i := 0; i < n; i++ {
+		if h.Contains(i) {
+			live = append(live, i)
+		}
+	}
+	return live
+}
+
+func TestMaxBytesEstimatesStringSizes(t *testing.T) {
+	handle := fcache.NewHandle(func(key int) (string, error) {
+		return strings.Repeat("x", 1000), nil
+	}, &fcache.Config{
+		MaxBytes:              5000,
+		MaxBytesCheckInterval: time.Nanosecond,
+	}, &fcache.Hooks{})
+
+	for i := 0; i < 20; i++ {
+		handle.Call(i)
+	}
+
+	live := liveKeys(handle, 20)
+	if len(live) == 0 || len(live) > 5 {
+		t.Fatalf("live entries = %v; want 1 to 5 entries of ~1000 bytes within 5000 bytes", live)
+	}
+	if live[len(live)-1] != 19 {
+		t.Errorf("live entries = %v; want the most recently used ones", live)
+	}
+	if m := handle.Metrics(); m.Evictions != uint64(20-len(live)) {
+		t.Errorf("Evictions = %d; want %d", m.Evictions, 20-len(live))
+	}
+}
+
+func TestMaxBytesEstimatesStructs(t *testing.T) {
+	small := fcache.NewHandle(func(key int) (document, error) {
+		return document{ID: key, Body: "short"}, nil
+	}, &fcache.Config{MaxBytes: 20000, MaxBytesCheckInterval: time.Nanosecond}, &fcache.Hooks{})
+	large := fcache.NewHandle(func(key int) (document, error) {
+		return document{ID: key, Body: strings.Repeat("x", 1000), Lines: []string{strings.Repeat("y", 1000)}}, nil
+	}, &fcache.Config{MaxBytes: 20000, MaxBytesCheckInterval: time.Nanosecond}, &fcache.Hooks{})
+
+	for i := 0; i < 50; i++ {
+		small.Call(i)
+		large.Call(i)
+	}
+	if n := len(liveKeys(small, 50)); n != 50 {
+		t.Errorf("small documents: %d live entries; want all 50 within the budget", n)
+	}
+	if n := len(liveKeys(large, 50)); n == 0 || n > 10 {
+		t.Errorf("large documents: %d live entries; want 1 to 10 entries of over 2000 bytes within 20000 bytes", n)
+	}
+}
+
+func TestCostFuncOverridesEstimator(t *testing.T) {
+	handle := fcache.NewHandle(func(key int) (string, error) {
+		return strings.Repeat("x", 1000), nil
+	}, &fcache.Config{
+		MaxBytes:              3,
+		CostFunc:              func(string) int64 { return 1 }, // count entries instead of bytes
+		MaxBytesCheckInterval: time.Nanosecond,
+	}, &fcache.Hooks{})
+
+	for i := 0; i < 10; i++ {
+		handle.Call(i)
+	}
+	if live := liveKeys(handle, 10); len(live) != 3 || live[0] != 7 {
+		t.Errorf("live entries = %v; want [7 8 9]", live)
+	}
+}
+
+func TestCostFuncWrongTypePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for a CostFunc of the wrong type")
+		}
+	}()
+	fcache.NewCachedFunction(func(key int) (string, error) { return "", nil }, &fcache.Config{
+		MaxBytes: 1,
+		CostFunc: func(int) int64 { return 1 },
+	}, nil)
+}
+
+func TestCostFuncRunsOncePerStore(t *testing.T) {
+	var calls atomic.Int32
+	handle := fcache.NewHandle(func(key int) (string, error) {
+		return "value", nil
+	}, &fcache.Config{
+		MaxBytes:              3,
+		CostFunc:              func(string) int64 { calls.Add(1); return 1 },
+		MaxBytesCheckInterval: time.Nanosecond,
+	}, nil)
+
+	for i := 0; i < 10; i++ {
+		handle.Call(i)
+		handle.Call(i) // hits are not measured
+	}
+	if got := calls.Load(); got != 10 {
+		t.Errorf("CostFunc ran %d times; want once per stored value (10)", got)
+	}
+}
+
+func TestMaxBytesBackgroundSweep(t *testing.T) {
+	clock := newFakeClock()
+	handle := fcache.NewHandle(func(key int) (string, error) {
+		return "value", nil
+	}, &fcache.Config{
+		MaxBytes:              3,
+		CostFunc:              func(string) int64 { return 1 },
+		MaxBytesCheckInterval: time.Minute,
+		Clock:                 clock,
+	}, nil)
+
+	// only the first write is checked within the interval, leaving the cache over budget
+	for i := 0; i < 10; i++ {
+		handle.Call(i)
+	}
+	if n := len(liveKeys(handle, 10)); n != 10 {
+		t.Fatalf("%d live entries before the sweep; want 10", n)
+	}
+
+	// without further writes, the sweep on the clock's ticker enforces the budget
+	deadline := time.Now().Add(5 * time.Second)
+	for len(liveKeys(handle, 10)) > 3 && time.Now().Before(deadline) {
+		clock.Advance(time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+	if live := liveKeys(handle, 10); len(live) != 3 || live[0] != 7 {
+		t.Errorf("live entries after the sweep = %v; want [7 8 9]", live)
+	}
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+// concurrencyProbe is a backend that records the highest number of simultaneous calls.
+type concurrencyProbe struct {
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (p *concurrencyProbe) fetch(key int) (int, error) {
+	n := p.running.Add(1)
+	defer p.running.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return key, nil
+}
+
+func TestMaxConcurrentExecutionsBoundsBackend(t *testing.T) {
+	var probe concurrencyProbe
+	cache := fcache.NewCachedFunction(probe.fetch, &fcache.Config{MaxConcurrentExecutions: 3}, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(key int) {
+			defer wg.Done()
+			if v, err := cache(key); err != nil || v != key {
+				t.Errorf("cache(%d) = %d, %v", key, v, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if peak := probe.peak.Load(); peak > 3 {
+		t.Errorf("backend saw %d concurrent calls; want at most 3", peak)
+	}
+}
+
+func TestConcurrencyFailFast(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	cache := fcache.NewCachedFunction(func(key int) (int, error) {
+		if key == 1 {
+			close(started)
+			<-release
+		}
+		return key, nil
+	}, &fcache.Config{MaxConcurrentExecutions: 1, ConcurrencyFailFast: true, BreakerThreshold: 1}, nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache(1)
+	}()
+	<-started
+
+	if _, err := cache(2); !errors.Is(err, fcache.ErrConcurrencyLimit) {
+		t.Errorf("cache(2) while the slot is taken = %v; want ErrConcurrencyLimit", err)
+	}
+	close(release)
+	<-done
+
+	// The rejection was neither cached nor counted as a backend failure by the breaker
+	if v, err := cache(2); err != nil || v != 2 {
+		t.Errorf("cache(2) after the slot freed up = %d, %v; want 2, nil", v, err)
+	}
+}
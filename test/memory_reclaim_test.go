@@ -0,0 +1,78 @@
+package test
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestMemoryPressureReclaimEvictsOverLimit(t *testing.T) {
+	clock := newFakeClock()
+	var evicted atomic.Uint64
+	handle := fcache.NewHandle(func(key int) ([]byte, error) {
+		return make([]byte, 1024), nil
+	}, &fcache.Config{
+		Capacity:              1000,
+		MemoryPressureReclaim: true,
+		MemoryLimit:           1, // the heap is always over this limit
+		MemoryCheckInterval:   time.Minute,
+		Clock:                 clock,
+	}, &fcache.Hooks{
+		OnEvict: func(hc fcache.HookContext) error {
+			evicted.Add(1)
+			return nil
+		},
+	})
+
+	for i := 0; i < 100; i++ {
+		handle.Call(i)
+	}
+	// writes never read the heap; only the background check reclaims
+	if m := handle.Metrics(); m.Evictions != 0 {
+		t.Fatalf("Evictions = %d before the heap check; want 0", m.Evictions)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for handle.Metrics().Evictions == 0 && time.Now().Before(deadline) {
+		clock.Advance(time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+
+	m := handle.Metrics()
+	if m.Evictions == 0 {
+		t.Fatal("no entries reclaimed while the heap is over the limit")
+	}
+	if !waitFor(func() bool { return evicted.Load() == handle.Metrics().Evictions }) {
+		t.Errorf("OnEvict called %d times; want %d", evicted.Load(), handle.Metrics().Evictions)
+	}
+	if handle.Contains(0) {
+		t.Error("least recently used entry survived reclaiming")
+	}
+}
+
+func TestMemoryPressureReclaimUnderLimit(t *testing.T) {
+	clock := newFakeClock()
+	handle := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{
+		Capacity:              1000,
+		MemoryPressureReclaim: true,
+		MemoryLimit:           math.MaxUint64,
+		MemoryCheckInterval:   time.Minute,
+		Clock:                 clock,
+	}, &fcache.Hooks{})
+
+	for i := 0; i < 100; i++ {
+		handle.Call(i)
+	}
+	for i := 0; i < 10; i++ {
+		clock.Advance(time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+	if m := handle.Metrics(); m.Evictions != 0 {
+		t.Errorf("Evictions = %d under the memory limit; want 0", m.Evictions)
+	}
+}
@@ -0,0 +1,134 @@
+package test
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+func TestScopedNamespacesAreIsolated(t *testing.T) {
+	var calls atomic.Int32
+	tenant := "" // the tenant whose view is executing, for the test function to report
+	handle := fcache.NewHandle(func(key int) (string, error) {
+		calls.Add(1)
+		return tenant, nil
+	}, &fcache.Config{Capacity: 10}, &fcache.Hooks{})
+
+	a := handle.Scoped("tenant-a")
+	b := handle.Scoped("tenant-b")
+
+	tenant = "a"
+	if v, _ := a.Call(1); v != "a" {
+		t.Errorf("a.Call(1) = %q; want a", v)
+	}
+	tenant = "b"
+	if v, _ := b.Call(1); v != "b" {
+		t.Errorf("b.Call(1) = %q; want b, not tenant a's entry", v)
+	}
+	if v, _ := a.Call(1); v != "a" {
+		t.Errorf("a.Call(1) again = %q; want a from cache", v)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d; want 2", got)
+	}
+	if handle.Contains(1) {
+		t.Error("namespaced entry is visible from the base keyspace")
+	}
+	if handle.Scoped("tenant-a") != a {
+		t.Error("Scoped returned a new view for an existing namespace")
+	}
+}
+
+func TestScopedEvictOnlyEvictsNamespace(t *testing.T) {
+	handle := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{Capacity: 10}, &fcache.Hooks{})
+	a := handle.Scoped("tenant-a")
+	b := handle.Scoped("tenant-b")
+
+	// tenant b's entries are the least recently used
+	b.Call(1)
+	b.Call(2)
+	a.Call(3)
+	a.Call(4)
+
+	if got := a.Evict(1); len(got) != 1 || got[0] != 3 {
+		t.Errorf("a.Evict(1) = %v; want [3]", got)
+	}
+	if !b.Contains(1) || !b.Contains(2) {
+		t.Error("evicting from tenant a removed tenant b's entries")
+	}
+	if got := a.Evict(5); len(got) != 1 || got[0] != 4 {
+		t.Errorf("a.Evict(5) = %v; want [4]", got)
+	}
+	if n := handle.Stats().Entries; n != 2 {
+		t.Errorf("Stats().Entries = %d after evicting tenant a; want 2", n)
+	}
+}
+
+func TestScopedViewsShareBypass(t *testing.T) {
+	var calls atomic.Int32
+	handle := fcache.NewHandle(func(key int) (int, error) {
+		calls.Add(1)
+		return key, nil
+	}, &fcache.Config{Capacity: 10}, &fcache.Hooks{})
+	a := handle.Scoped("tenant-a")
+	b := handle.Scoped("tenant-b")
+	a.Call(1)
+
+	handle.SetBypass(true)
+	a.Call(1)
+	a.Call(2)
+	if got := calls.Load(); got != 3 {
+		t.Errorf("calls = %d; want 3 (the view bypasses the store with its cache)", got)
+	}
+
+	handle.SetBypass(false)
+	if a.Contains(2) {
+		t.Error("a view stored a result while the cache was bypassed")
+	}
+	b.SetBypass(true) // switches the cache and every view
+	handle.Call(3)
+	a.Call(1)
+	if got := calls.Load(); got != 5 {
+		t.Errorf("calls = %d; want 5 (bypass set on a view applies to all)", got)
+	}
+}
+
+func TestNamespacePrefixCannotBleed(t *testing.T) {
+	handle := fcache.NewHandle(func(key string) (string, error) {
+		return key, nil
+	}, nil, &fcache.Hooks{})
+
+	// "a" + ":b" and "a:" + "b" must not produce the same key
+	handle.Scoped("a").Call(":b")
+	if handle.Scoped("a:").Contains("b") {
+		t.Error("entry of namespace a is visible in namespace a:")
+	}
+}
+
+func TestConfigNamespace(t *testing.T) {
+	var calls atomic.Int32
+	fn := func(key int) (int, error) {
+		calls.Add(1)
+		return key, nil
+	}
+	cache := fcache.New(fn, fcache.WithNamespace("orders"))
+	cache(1)
+	cache(1)
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d; want 1", got)
+	}
+}
+
+func TestNamespaceRequiresStringKeys(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for Namespace on a comparable-key cache")
+		}
+	}()
+	fcache.NewHandleComparable(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{Namespace: "x"}, nil)
+}
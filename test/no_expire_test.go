@@ -0,0 +1,77 @@
+package test
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestNoExpireKeepsEntriesPastTTL(t *testing.T) {
+	var calls atomic.Int32
+
+	fn := func(key int) (int, error) {
+		calls.Add(1)
+		return key * 10, nil
+	}
+
+	h := fcache.NewHandle(fn, &fcache.Config{
+		Capacity:        100,
+		CleanupInterval: 10 * time.Millisecond,
+		NoExpire:        true,
+	}, &fcache.Hooks{})
+	h.SetTTL(20 * time.Millisecond) // a TTL in the config is rejected with NoExpire, and ignored at runtime
+	cache := h.Call
+
+	cache(1)
+	time.Sleep(100 * time.Millisecond) // well past the TTL and several cleanup ticks
+
+	v, err := cache(1)
+	if err != nil || v != 10 {
+		t.Fatalf("cache(1) = %d, %v; want 10, nil", v, err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls with NoExpire = %d; want 1", got)
+	}
+}
+
+func TestNoExpireStillEvictsByCapacity(t *testing.T) {
+	var calls atomic.Int32
+
+	fn := func(key int) (int, error) {
+		calls.Add(1)
+		return key, nil
+	}
+
+	cache := fcache.NewCachedFunction(fn, &fcache.Config{
+		Capacity: 2,
+		NoExpire: true,
+	}, &fcache.Hooks{})
+
+	cache(1)
+	cache(2)
+	cache(3) // evicts 1
+	cache(1)
+
+	if got := calls.Load(); got != 4 {
+		t.Errorf("calls = %d; want 4", got)
+	}
+}
+
+func TestNoExpireSkipsBackgroundCleanup(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	cache := fcache.NewCachedFunction(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{NoExpire: true}, &fcache.Hooks{})
+
+	for i := 0; i < 10; i++ {
+		cache(i)
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("goroutines = %d after NoExpire sets; want at most %d", after, before)
+	}
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/osmike/fcache"
+	"github.com/osmike/fcache/internal/lib/keygen"
+)
+
+func TestNormalizeSlicesSharesEntryForSets(t *testing.T) {
+	var calls atomic.Int32
+	sum := fcache.NewCachedFunction(func(ids []int) (int, error) {
+		calls.Add(1)
+		total := 0
+		for _, id := range ids {
+			total += id
+		}
+		return total, nil
+	}, &fcache.Config{NormalizeSlices: true}, nil)
+
+	sum([]int{3, 1, 2})
+	sum([]int{1, 2, 3})
+	sum([]int{2, 3, 1})
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d; want 1 for permutations of one set", got)
+	}
+	sum([]int{1, 2, 4})
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d; want 2 for a different set", got)
+	}
+}
+
+func TestNormalizeSlicesOffByDefault(t *testing.T) {
+	var calls atomic.Int32
+	join := fcache.NewCachedFunction(func(parts []string) (int, error) {
+		calls.Add(1)
+		return len(parts), nil
+	}, nil, nil)
+
+	join([]string{"a", "b"})
+	join([]string{"b", "a"})
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d; want 2, since order matters by default", got)
+	}
+}
+
+func TestSortSlicesKeys(t *testing.T) {
+	b := keygen.Builder{SortSlices: true}
+	key := func(v any) string {
+		t.Helper()
+		k, err := b.BuildKey(v)
+		if err != nil {
+			t.Fatalf("BuildKey(%v): %v", v, err)
+		}
+		return k
+	}
+
+	if key([2]string{"y", "x"}) != key([]string{"x", "y"}) {
+		t.Error("an array and a slice of the same strings got different keys")
+	}
+	if key([]float64{0.5, -1}) != key([]float64{-1, 0.5}) {
+		t.Error("permuted float slices got different keys")
+	}
+	if key([]byte{2, 1}) == key([]byte{1, 2}) {
+		t.Error("byte slices were sorted; want them keyed as data")
+	}
+	if key([][]int{{2}, {1}}) == key([][]int{{1}, {2}}) {
+		t.Error("a slice of slices was sorted; want only ordered elements sorted")
+	}
+	input := []int{2, 1}
+	key(input)
+	if input[0] != 2 {
+		t.Error("BuildKey sorted the caller's slice in place")
+	}
+}
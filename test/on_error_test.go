@@ -0,0 +1,43 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestOnErrorFiresOnlyForFunctionErrors(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+	errHook := errors.New("hook failed")
+
+	fn := func(key int) (int, error) {
+		if key < 0 {
+			return 0, errFetch
+		}
+		return key, nil
+	}
+
+	var onError []error
+	var logged []error
+	cache := fcache.NewCachedFunction(fn, &fcache.Config{
+		TTL:      time.Minute,
+		Capacity: 10,
+	}, &fcache.Hooks{
+		OnSet:    func(arg any) error { return errHook },
+		OnError:  func(hc fcache.HookContext) error { onError = append(onError, hc.Err); return nil },
+		LogError: func(err error) { logged = append(logged, err) },
+	})
+
+	cache(1)  // hook error only
+	cache(-1) // function error
+
+	if len(onError) != 1 || !errors.Is(onError[0], errFetch) {
+		t.Errorf("OnError received %v; want only the function error", onError)
+	}
+	// LogError keeps receiving both kinds of errors
+	if len(logged) != 2 || !errors.Is(logged[0], errHook) || !errors.Is(logged[1], errFetch) {
+		t.Errorf("LogError received %v; want hook error then function error", logged)
+	}
+}
@@ -0,0 +1,114 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func newFullHandle(policy fcache.FullPolicy, cfg fcache.Config) *fcache.Handle[int, int] {
+	cfg.Capacity = 2
+	cfg.OnFull = policy
+	return fcache.NewHandle(func(key int) (int, error) {
+		return key * 10, nil
+	}, &cfg, &fcache.Hooks{})
+}
+
+func TestOnFullEvict(t *testing.T) {
+	h := newFullHandle(fcache.FullEvict, fcache.Config{})
+	for i := 1; i <= 3; i++ {
+		if _, err := h.Call(i); err != nil {
+			t.Fatalf("Call(%d): %v", i, err)
+		}
+	}
+	if h.Contains(1) || !h.Contains(3) {
+		t.Error("FullEvict did not replace the least recently used entry")
+	}
+}
+
+func TestOnFullReject(t *testing.T) {
+	h := newFullHandle(fcache.FullReject, fcache.Config{})
+	h.Call(1)
+	h.Call(2)
+
+	v, err := h.Call(3)
+	if !errors.Is(err, fcache.ErrCacheFull) || v != 30 {
+		t.Errorf("Call(3) = %d, %v; want the computed 30 with ErrCacheFull", v, err)
+	}
+	if h.Contains(3) || !h.Contains(1) || !h.Contains(2) {
+		t.Error("FullReject changed the cache contents")
+	}
+
+	// Existing keys are still served and can be overwritten
+	if v, err := h.Call(1); err != nil || v != 10 {
+		t.Errorf("Call(1) = %d, %v; want a cached 10", v, err)
+	}
+	if m := h.Metrics(); m.Evictions != 0 {
+		t.Errorf("Evictions = %d; want 0", m.Evictions)
+	}
+}
+
+func TestOnFullRejectReplacesExpiredEntries(t *testing.T) {
+	h := newFullHandle(fcache.FullReject, fcache.Config{TTL: 10 * time.Millisecond, DisableBackgroundCleanup: true})
+	h.Call(1)
+	h.Call(2)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := h.Call(3); err != nil {
+		t.Errorf("Call(3) with expired entries = %v; want stored", err)
+	}
+	if !h.Contains(3) {
+		t.Error("new entry not stored in place of expired ones")
+	}
+}
+
+func TestOnFullBlockWaitsForSpace(t *testing.T) {
+	h := newFullHandle(fcache.FullBlock, fcache.Config{OnFullTimeout: 5 * time.Second})
+	h.Call(1)
+	h.Call(2)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := h.Call(3)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Call(3) returned %v before space freed up", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	h.InvalidateFunc(func(key string) bool { return key == "1" })
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Call(3) = %v; want stored after space freed up", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Call(3) still blocked after space freed up")
+	}
+	if !h.Contains(3) {
+		t.Error("blocked entry not stored after space freed up")
+	}
+}
+
+func TestOnFullBlockTimesOut(t *testing.T) {
+	h := newFullHandle(fcache.FullBlock, fcache.Config{OnFullTimeout: 20 * time.Millisecond})
+	h.Call(1)
+	h.Call(2)
+
+	start := time.Now()
+	v, err := h.Call(3)
+	if !errors.Is(err, fcache.ErrCacheFull) || v != 30 {
+		t.Errorf("Call(3) = %d, %v; want the computed 30 with ErrCacheFull", v, err)
+	}
+	if waited := time.Since(start); waited < 20*time.Millisecond {
+		t.Errorf("Call(3) returned after %v; want to wait for OnFullTimeout", waited)
+	}
+	if h.Contains(3) {
+		t.Error("entry stored after the timeout")
+	}
+}
@@ -0,0 +1,140 @@
+package test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+// removals records OnRemove calls.
+type removals struct {
+	mu      sync.Mutex
+	reasons map[string]fcache.RemoveReason // reason by key
+	values  map[string]any                 // removed value by key
+}
+
+func (r *removals) hook(hc fcache.HookContext) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.reasons == nil {
+		r.reasons = make(map[string]fcache.RemoveReason)
+		r.values = make(map[string]any)
+	}
+	r.reasons[hc.Key] = hc.Reason
+	r.values[hc.Key] = hc.Value
+	return nil
+}
+
+func (r *removals) count(reason fcache.RemoveReason) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, got := range r.reasons {
+		if got == reason {
+			n++
+		}
+	}
+	return n
+}
+
+func newRemovalHandle(cfg *fcache.Config, r *removals) *fcache.Handle[int, int] {
+	return fcache.NewHandle(func(key int) (int, error) {
+		return key * 10, nil
+	}, cfg, &fcache.Hooks{OnRemove: r.hook})
+}
+
+func TestOnRemoveCapacity(t *testing.T) {
+	var r removals
+	h := newRemovalHandle(&fcache.Config{Capacity: 2}, &r)
+	h.Call(1)
+	h.Call(2)
+	h.Call(3)
+	if n := r.count(fcache.ReasonCapacity); n != 1 {
+		t.Fatalf("capacity removals = %d; want 1", n)
+	}
+	if v, ok := r.values["1"]; !ok || v != 10 {
+		t.Errorf("removed entries = %v; want key 1 with value 10", r.values)
+	}
+}
+
+func TestOnRemoveTTL(t *testing.T) {
+	var r removals
+	h := newRemovalHandle(&fcache.Config{TTL: 10 * time.Millisecond, DisableBackgroundCleanup: true}, &r)
+	h.Call(1)
+	h.Call(2)
+	time.Sleep(20 * time.Millisecond)
+
+	h.Call(1) // found expired by the lookup
+	h.PurgeExpired()
+	if n := r.count(fcache.ReasonTTL); n != 2 {
+		t.Errorf("ttl removals = %d; want 2 (lookup and purge)", n)
+	}
+}
+
+func TestOnRemoveManualAndClear(t *testing.T) {
+	var r removals
+	h := newRemovalHandle(nil, &r)
+	for i := 0; i < 4; i++ {
+		h.Call(i)
+	}
+
+	h.InvalidateFunc(func(key string) bool { return key == "0" })
+	if n := r.count(fcache.ReasonManual); n != 1 {
+		t.Errorf("manual removals = %d; want 1", n)
+	}
+	if n := h.Clear(); n != 3 {
+		t.Errorf("Clear = %d; want 3", n)
+	}
+	if n := r.count(fcache.ReasonClear); n != 3 {
+		t.Errorf("clear removals = %d; want 3", n)
+	}
+	if h.Contains(1) {
+		t.Error("entry survived Clear")
+	}
+
+	// The cache keeps working after Clear
+	if v, err := h.Call(1); err != nil || v != 10 {
+		t.Errorf("Call after Clear = %d, %v; want 10, nil", v, err)
+	}
+}
+
+func TestClearScopedView(t *testing.T) {
+	var r removals
+	h := newRemovalHandle(nil, &r)
+	a, b := h.Scoped("a"), h.Scoped("b")
+	a.Call(1)
+	a.Call(2)
+	b.Call(1)
+
+	if n := a.Clear(); n != 2 {
+		t.Errorf("Clear on view a = %d; want 2", n)
+	}
+	if !b.Contains(1) {
+		t.Error("Clear on view a removed an entry of view b")
+	}
+}
+
+func TestEvictDoesNotRunOnRemove(t *testing.T) {
+	var r removals
+	h := newRemovalHandle(nil, &r)
+	h.Call(1)
+	h.Evict(1)
+	if n := len(r.reasons); n != 0 {
+		t.Errorf("OnRemove called %d times for Evict; want 0", n)
+	}
+}
+
+func TestRemoveReasonString(t *testing.T) {
+	for reason, want := range map[fcache.RemoveReason]string{
+		fcache.ReasonCapacity: "capacity",
+		fcache.ReasonTTL:      "ttl",
+		fcache.ReasonManual:   "manual",
+		fcache.ReasonClear:    "clear",
+	} {
+		if got := reason.String(); got != want {
+			t.Errorf("%d.String() = %q; want %q", reason, got, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	var calls, evictions, sets atomic.Int32
+
+	cache := fcache.New(func(key int) (int, error) {
+		calls.Add(1)
+		return key * 2, nil
+	},
+		fcache.WithTTL(50*time.Millisecond),
+		fcache.WithCapacity(2),
+		fcache.WithHooks(&fcache.Hooks{
+			OnSet: func(arg any) error {
+				sets.Add(1)
+				return nil
+			},
+		}),
+		fcache.WithEviction(func(hc fcache.HookContext) error {
+			evictions.Add(1)
+			return nil
+		}),
+	)
+
+	if v, err := cache(1); err != nil || v != 2 {
+		t.Fatalf("cache(1) = %d, %v; want 2, nil", v, err)
+	}
+	cache(1)
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d; want 1", got)
+	}
+
+	cache(2)
+	cache(3) // capacity 2: evicts 1
+	if got := evictions.Load(); got != 1 {
+		t.Errorf("evictions = %d; want 1", got)
+	}
+	if got := sets.Load(); got != 3 {
+		t.Errorf("OnSet calls = %d; want 3 (WithEviction keeps other hooks)", got)
+	}
+
+	time.Sleep(80 * time.Millisecond)
+	cache(3)
+	if got := calls.Load(); got != 4 {
+		t.Errorf("calls after TTL = %d; want 4", got)
+	}
+}
+
+func TestNewOptionsOverrideConfig(t *testing.T) {
+	var calls atomic.Int32
+	cfg := &fcache.Config{Capacity: 1}
+
+	cache := fcache.New(func(key int) (int, error) {
+		calls.Add(1)
+		return key, nil
+	}, fcache.WithConfig(cfg), fcache.WithCapacity(10))
+
+	for i := 0; i < 5; i++ {
+		cache(i)
+	}
+	for i := 0; i < 5; i++ {
+		cache(i)
+	}
+	if got := calls.Load(); got != 5 {
+		t.Errorf("calls = %d; want 5 (WithCapacity overrides WithConfig)", got)
+	}
+	if cfg.Capacity != 1 {
+		t.Errorf("cfg.Capacity = %d; want the caller's config untouched", cfg.Capacity)
+	}
+}
+
+func TestNewCachedFunctionReadsHooksSetLater(t *testing.T) {
+	h := &fcache.Hooks{}
+	cache := fcache.NewCachedFunction(func(key int) (int, error) {
+		return key, nil
+	}, nil, h)
+
+	var executed atomic.Int32
+	h.OnExecute = func(arg any) error {
+		executed.Add(1)
+		return nil
+	}
+	cache(1)
+	if got := executed.Load(); got != 1 {
+		t.Errorf("OnExecute calls = %d; want 1 for a hook assigned after construction", got)
+	}
+}
+
+func TestWithEvictionLeavesCallerHooksUntouched(t *testing.T) {
+	h := &fcache.Hooks{}
+	fcache.New(func(key int) (int, error) {
+		return key, nil
+	}, fcache.WithHooks(h), fcache.WithEviction(func(hc fcache.HookContext) error { return nil }))
+	if h.OnEvict != nil {
+		t.Error("WithEviction set OnEvict on the caller's hooks")
+	}
+}
+
+func TestNewWithoutOptions(t *testing.T) {
+	cache := fcache.New(func(key string) (int, error) {
+		return len(key), nil
+	})
+	if v, err := cache("abc"); err != nil || v != 3 {
+		t.Errorf("cache(abc) = %d, %v; want 3, nil", v, err)
+	}
+}
@@ -0,0 +1,96 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestPropagatePanicsRepanicsOriginalValue(t *testing.T) {
+	calls := 0
+	fn := func(key int) (int, error) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		return key, nil
+	}
+
+	var logged error
+	cache := fcache.NewCachedFunction(fn, &fcache.Config{
+		TTL:             time.Minute,
+		Capacity:        10,
+		PropagatePanics: true,
+	}, &fcache.Hooks{
+		LogError: func(err error) { logged = err },
+	})
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recovered %v; want original panic value %q", r, "boom")
+			}
+		}()
+		cache(1)
+		t.Error("expected the call to panic")
+	}()
+
+	if !errors.Is(logged, fcache.ErrPanic) {
+		t.Errorf("LogError received %v; want ErrPanic", logged)
+	}
+
+	// The in-flight marker was cleared, so the key can be computed again
+	if v, err := cache(1); err != nil || v != 1 {
+		t.Errorf("call after panic = (%d, %v); want (1, nil)", v, err)
+	}
+}
+
+func TestPanicIsReturnedAsErrorByDefault(t *testing.T) {
+	fn := func(key int) (int, error) {
+		panic("boom")
+	}
+
+	cache := fcache.NewCachedFunction(fn, &fcache.Config{
+		TTL:      time.Minute,
+		Capacity: 10,
+	}, &fcache.Hooks{})
+
+	// Repeated calls must not block on a stale in-flight marker
+	for i := 0; i < 2; i++ {
+		if _, err := cache(1); !errors.Is(err, fcache.ErrPanic) {
+			t.Errorf("call %d error = %v; want ErrPanic", i, err)
+		}
+	}
+}
+
+func panickingLookup(key int) (int, error) {
+	panic("lookup failed")
+}
+
+func TestCaptureStackRecordsPanicFrame(t *testing.T) {
+	var logged error
+	cache := fcache.NewCachedFunction(panickingLookup, &fcache.Config{
+		TTL:          time.Minute,
+		Capacity:     10,
+		CaptureStack: true,
+	}, &fcache.Hooks{
+		LogError: func(err error) { logged = err },
+	})
+
+	_, err := cache(1)
+
+	var fe *fcache.Error
+	if !errors.As(err, &fe) {
+		t.Fatalf("expected *fcache.Error, got %v", err)
+	}
+	stack, _ := fe.Fields["stack"].(string)
+	if !strings.Contains(stack, "panickingLookup") {
+		t.Errorf("stack does not contain the user function frame:\n%s", stack)
+	}
+	if !strings.Contains(logged.Error(), "panickingLookup") {
+		t.Errorf("LogError value does not contain the stack: %v", logged)
+	}
+}
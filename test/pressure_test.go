@@ -0,0 +1,56 @@
+package test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestOnPressureFiresWhenEvictionsOutpaceHits(t *testing.T) {
+	fn := func(key int) (int, error) {
+		return key, nil
+	}
+
+	var mu sync.Mutex
+	var events []fcache.PressureEvent
+	h := fcache.NewHandleComparable(fn, &fcache.Config{
+		TTL:               time.Minute,
+		Capacity:          2,
+		PressureWindow:    time.Minute,
+		PressureThreshold: 2,
+	}, &fcache.Hooks{
+		OnPressure: func(arg any) error {
+			mu.Lock()
+			events = append(events, arg.(fcache.PressureEvent))
+			mu.Unlock()
+			return nil
+		},
+	})
+
+	// A healthy working set: hits, no evictions
+	h.Call(1)
+	h.Call(2)
+	for i := 0; i < 3; i++ {
+		h.Call(1)
+	}
+
+	// A scan larger than the capacity: evictions outnumber hits
+	for key := 10; key < 20; key++ {
+		h.Call(key)
+	}
+
+	if m := h.Metrics(); m.Evictions != 10 {
+		t.Errorf("Evictions = %d; want 10", m.Evictions)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 {
+		t.Fatalf("OnPressure fired %d times; want once per window", len(events))
+	}
+	if ev := events[0]; ev.Hits != 3 || ev.Evictions != 7 {
+		t.Errorf("event = %+v; want 7 evictions against 3 hits", ev)
+	}
+}
@@ -0,0 +1,56 @@
+package test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestPurgeExpiredReturnsRemovedCount(t *testing.T) {
+	fn := func(key int) (int, error) {
+		return key, nil
+	}
+
+	h := fcache.NewHandle(fn, &fcache.Config{
+		TTL:                      30 * time.Millisecond,
+		Capacity:                 100,
+		DisableBackgroundCleanup: true,
+	}, &fcache.Hooks{})
+
+	for i := 0; i < 5; i++ {
+		h.Call(i)
+	}
+
+	// Nothing has expired yet
+	if n := h.PurgeExpired(); n != 0 {
+		t.Errorf("purge before expiry removed %d; want 0", n)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	// Purge concurrently with regular calls; the total removed must match the expired entries
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	removed := 0
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			n := h.PurgeExpired()
+			mu.Lock()
+			removed += n
+			mu.Unlock()
+		}()
+		go func(i int) {
+			defer wg.Done()
+			h.Call(100 + i)
+		}(i)
+	}
+	wg.Wait()
+
+	if removed != 5 {
+		t.Errorf("purged %d entries in total; want 5", removed)
+	}
+}
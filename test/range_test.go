@@ -0,0 +1,100 @@
+package test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestRangeVisitsLiveEntriesInLRUOrder(t *testing.T) {
+	handle := fcache.NewHandleComparable(func(key int) (int, error) {
+		return key * 10, nil
+	}, &fcache.Config{Capacity: 10}, &fcache.Hooks{})
+
+	for i := 1; i <= 3; i++ {
+		handle.Call(i)
+	}
+	handle.Call(1) // most recently used
+
+	var keys, vals []int
+	handle.Range(func(key int, val int, age time.Duration) bool {
+		if age < 0 {
+			t.Errorf("age of %d = %v; want >= 0", key, age)
+		}
+		keys = append(keys, key)
+		vals = append(vals, val)
+		return true
+	})
+	if want := []int{1, 3, 2}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Range keys = %v; want %v", keys, want)
+	}
+	if want := []int{10, 30, 20}; !reflect.DeepEqual(vals, want) {
+		t.Errorf("Range values = %v; want %v", vals, want)
+	}
+	if m := handle.Metrics(); m.Hits != 1 {
+		t.Errorf("Hits = %d after Range; want 1", m.Hits)
+	}
+}
+
+func TestRangeStopsEarly(t *testing.T) {
+	handle := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{Capacity: 10}, &fcache.Hooks{})
+	for i := 0; i < 5; i++ {
+		handle.Call(i)
+	}
+
+	visited := 0
+	handle.Range(func(key string, val int, age time.Duration) bool {
+		visited++
+		return visited < 2
+	})
+	if visited != 2 {
+		t.Errorf("visited = %d; want 2", visited)
+	}
+}
+
+func TestRangeSkipsExpired(t *testing.T) {
+	handle := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{TTL: 30 * time.Millisecond, Capacity: 10, DisableBackgroundCleanup: true}, &fcache.Hooks{})
+
+	handle.Call(1)
+	time.Sleep(50 * time.Millisecond)
+	handle.Call(2)
+
+	var vals []int
+	handle.Range(func(key string, val int, age time.Duration) bool {
+		vals = append(vals, val)
+		return true
+	})
+	if !reflect.DeepEqual(vals, []int{2}) {
+		t.Errorf("Range values = %v; want [2]", vals)
+	}
+}
+
+func TestRangeConcurrentWithCalls(t *testing.T) {
+	handle := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{Capacity: 50}, &fcache.Hooks{})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				handle.Call(g*1000 + i)
+			}
+		}(g)
+	}
+	for i := 0; i < 100; i++ {
+		handle.Range(func(key string, val int, age time.Duration) bool {
+			return true
+		})
+	}
+	wg.Wait()
+}
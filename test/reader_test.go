@@ -0,0 +1,76 @@
+package test
+
+import (
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+// trackedBody is a stream that counts how often it is closed.
+type trackedBody struct {
+	io.Reader
+	closed *atomic.Int32
+}
+
+func (b trackedBody) Close() error {
+	b.closed.Add(1)
+	return nil
+}
+
+// openBody returns a stream source serving body and counting opens and closes.
+func openBody(body string, opened, closed *atomic.Int32) func(string) (io.ReadCloser, error) {
+	return func(string) (io.ReadCloser, error) {
+		opened.Add(1)
+		return trackedBody{strings.NewReader(body), closed}, nil
+	}
+}
+
+func TestWrapReaderCachesStreamBytes(t *testing.T) {
+	var opened, closed atomic.Int32
+	get := fcache.WrapReader(openBody("payload", &opened, &closed), 64, nil, nil)
+
+	for i := 0; i < 3; i++ {
+		rc, err := get("url")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil || string(b) != "payload" {
+			t.Errorf("read %d = %q, %v; want payload", i, b, err)
+		}
+	}
+	if got := opened.Load(); got != 1 {
+		t.Errorf("opened = %d; want 1 (bytes cached)", got)
+	}
+	if got := closed.Load(); got != 1 {
+		t.Errorf("closed = %d; want 1 (stream closed after buffering)", got)
+	}
+}
+
+func TestWrapReaderPassesThroughOversizedStreams(t *testing.T) {
+	var opened, closed atomic.Int32
+	body := strings.Repeat("x", 100)
+	get := fcache.WrapReader(openBody(body, &opened, &closed), 10, nil, nil)
+
+	for i := 0; i < 2; i++ {
+		rc, err := get("url")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		b, err := io.ReadAll(rc)
+		if err != nil || string(b) != body {
+			t.Errorf("read %d = %d bytes, %v; want the full %d bytes", i, len(b), err, len(body))
+		}
+		rc.Close()
+	}
+	if got := opened.Load(); got != 2 {
+		t.Errorf("opened = %d; want 2 (oversized streams not cached)", got)
+	}
+	if got := closed.Load(); got != 2 {
+		t.Errorf("closed = %d; want 2", got)
+	}
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+// finishes fails the test if f does not return within a second, e.g. because it deadlocked.
+func finishes(t *testing.T, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("call did not return: reentrant hook deadlocked")
+	}
+}
+
+func TestReentrantHooks(t *testing.T) {
+	var cache fcache.CachedFunc[int, int]
+	inHook := false // stops OnGet from recursing forever through its own hits
+	reenter := func(arg any) error {
+		if k := arg.(int); k < 100 && !inHook {
+			inHook = true
+			cache(k)       // same argument
+			cache(k + 100) // different argument, a miss
+			inHook = false
+		}
+		return nil
+	}
+
+	cases := map[string]*fcache.Hooks{
+		"OnSet":  {OnSet: reenter},
+		"OnGet":  {OnGet: reenter},
+		"OnDone": {OnDone: reenter},
+		"OnEvict": {OnEvict: func(hc fcache.HookContext) error {
+			if v := hc.Value.(int); v < 100 {
+				cache(v + 100)
+			}
+			return nil
+		}},
+	}
+	for name, h := range cases {
+		t.Run(name, func(t *testing.T) {
+			cache = fcache.NewCachedFunction(func(key int) (int, error) {
+				return key, nil
+			}, &fcache.Config{Capacity: 2}, h)
+
+			finishes(t, func() {
+				for i := 0; i < 4; i++ {
+					cache(i)
+					cache(i)
+				}
+			})
+		})
+	}
+}
+
+func TestReentrantOnError(t *testing.T) {
+	var cache fcache.CachedFunc[int, int]
+	h := &fcache.Hooks{
+		OnError: func(hc fcache.HookContext) error {
+			cache(hc.Arg.(int)) // retries the failing argument once more
+			return nil
+		},
+	}
+	calls := 0
+	cache = fcache.NewCachedFunction(func(key int) (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, errors.New("boom")
+		}
+		return key, nil
+	}, nil, h)
+
+	finishes(t, func() {
+		if _, err := cache(1); err == nil {
+			t.Error("first call succeeded; want its error")
+		}
+	})
+	if v, err := cache(1); err != nil || v != 1 {
+		t.Errorf("cache(1) = %d, %v; want 1, nil from the retry in OnError", v, err)
+	}
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+var errTransient = errors.New("transient")
+
+func TestRetrySucceedsAfterTransientErrors(t *testing.T) {
+	var calls atomic.Int32
+	var errorsLogged atomic.Int32
+
+	cache := fcache.NewCachedFunction(func(key int) (int, error) {
+		if calls.Add(1) < 3 {
+			return 0, errTransient
+		}
+		return key, nil
+	}, &fcache.Config{
+		Retry: fcache.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+	}, &fcache.Hooks{
+		OnError: func(hc fcache.HookContext) error {
+			errorsLogged.Add(1)
+			return nil
+		},
+	})
+
+	// concurrent callers share one retry sequence
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := cache(7); err != nil || v != 7 {
+				t.Errorf("cache(7) = %d, %v; want 7, nil", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := calls.Load(); got != 3 {
+		t.Errorf("calls = %d; want 3", got)
+	}
+	if got := errorsLogged.Load(); got != 0 {
+		t.Errorf("OnError called %d times; want 0 for a retried success", got)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	cache := fcache.NewCachedFunction(func(key int) (int, error) {
+		calls++
+		return 0, errTransient
+	}, &fcache.Config{Retry: fcache.RetryPolicy{MaxAttempts: 4}}, &fcache.Hooks{})
+
+	if _, err := cache(1); !errors.Is(err, errTransient) {
+		t.Errorf("err = %v; want %v", err, errTransient)
+	}
+	if calls != 4 {
+		t.Errorf("calls = %d; want 4", calls)
+	}
+}
+
+func TestRetrySkipsNonRetryableErrors(t *testing.T) {
+	permanent := errors.New("permanent")
+	calls := 0
+	cache := fcache.NewCachedFunction(func(key int) (int, error) {
+		calls++
+		return 0, permanent
+	}, &fcache.Config{
+		Retry: fcache.RetryPolicy{
+			MaxAttempts: 5,
+			Retryable:   func(err error) bool { return errors.Is(err, errTransient) },
+		},
+	}, &fcache.Hooks{})
+
+	if _, err := cache(1); !errors.Is(err, permanent) {
+		t.Errorf("err = %v; want %v", err, permanent)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d; want 1", calls)
+	}
+}
+
+func TestRetryBacksOff(t *testing.T) {
+	calls := 0
+	cache := fcache.NewCachedFunction(func(key int) (int, error) {
+		calls++
+		return 0, errTransient
+	}, &fcache.Config{
+		Retry: fcache.RetryPolicy{MaxAttempts: 3, Backoff: 20 * time.Millisecond, MaxBackoff: 30 * time.Millisecond},
+	}, &fcache.Hooks{})
+
+	start := time.Now()
+	cache(1)
+	// 20ms before the second attempt, then 40ms capped to 30ms before the third
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("elapsed = %v; want at least 50ms of backoff", elapsed)
+	}
+}
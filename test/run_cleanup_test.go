@@ -0,0 +1,69 @@
+package test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestRunCleanupCounts(t *testing.T) {
+	clock := newFakeClock()
+	h := fcache.NewHandle(func(key int) (int, error) { return key, nil }, &fcache.Config{
+		TTL:                      time.Minute,
+		Clock:                    clock,
+		DisableBackgroundCleanup: true,
+	}, nil)
+
+	for i := 0; i < 3; i++ {
+		h.Call(i)
+	}
+	clock.Advance(45 * time.Second)
+	for i := 3; i < 5; i++ {
+		h.Call(i)
+	}
+	if removed, remaining := h.RunCleanup(); removed != 0 || remaining != 5 {
+		t.Errorf("RunCleanup before expiry = (%d, %d); want (0, 5)", removed, remaining)
+	}
+
+	// Only the first three have been stored for over a minute
+	clock.Advance(30 * time.Second)
+	if removed, remaining := h.RunCleanup(); removed != 3 || remaining != 2 {
+		t.Errorf("RunCleanup = (%d, %d); want (3, 2)", removed, remaining)
+	}
+	if removed, remaining := h.RunCleanup(); removed != 0 || remaining != 2 {
+		t.Errorf("second RunCleanup = (%d, %d); want (0, 2)", removed, remaining)
+	}
+}
+
+func TestRunCleanupConcurrent(t *testing.T) {
+	clock := newFakeClock()
+	s := fcache.NewStore[int](&fcache.Config{TTL: time.Minute, Clock: clock, DisableBackgroundCleanup: true})
+	for i := 0; i < 100; i++ {
+		s.Set(strconv.Itoa(i), i)
+	}
+	clock.Advance(2 * time.Minute)
+
+	// Concurrent sweeps share out the expired entries, removing each once
+	var wg sync.WaitGroup
+	results := make(chan int, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			removed, _ := s.RunCleanup()
+			results <- removed
+		}()
+	}
+	wg.Wait()
+	close(results)
+	total := 0
+	for removed := range results {
+		total += removed
+	}
+	if total != 100 || s.Len() != 0 {
+		t.Errorf("sweeps removed %d in total, %d left; want 100, 0", total, s.Len())
+	}
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestSetCapacityEvictsOnShrink(t *testing.T) {
+	fn := func(key int) (int, error) {
+		return key, nil
+	}
+
+	h := fcache.NewHandleComparable(fn, &fcache.Config{
+		TTL:      time.Minute,
+		Capacity: 5,
+	}, &fcache.Hooks{})
+
+	for i := 1; i <= 5; i++ {
+		h.Call(i)
+	}
+
+	// Keep only the two most recently used entries
+	h.SetCapacity(2)
+	for i := 1; i <= 3; i++ {
+		if h.Contains(i) {
+			t.Errorf("Contains(%d) after shrink = true; want false", i)
+		}
+	}
+	if !h.Contains(4) || !h.Contains(5) {
+		t.Error("most recently used entries were evicted by shrink")
+	}
+
+	// Growing again allows more entries
+	h.SetCapacity(3)
+	h.Call(6)
+	if !h.Contains(4) || !h.Contains(5) || !h.Contains(6) {
+		t.Error("entries evicted after growing capacity")
+	}
+}
+
+func TestSetTTLAppliesToExistingEntries(t *testing.T) {
+	fn := func(key int) (int, error) {
+		return key, nil
+	}
+
+	h := fcache.NewHandleComparable(fn, &fcache.Config{
+		TTL:      time.Minute,
+		Capacity: 5,
+	}, &fcache.Hooks{})
+
+	h.Call(1)
+	time.Sleep(20 * time.Millisecond)
+
+	h.SetTTL(10 * time.Millisecond)
+	if h.Contains(1) {
+		t.Error("entry older than the new TTL is still valid")
+	}
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+var errBackendDown = errors.New("backend down")
+
+// flakyBackend returns a function yielding 1, 2, ... for every key while up, and errBackendDown otherwise.
+func flakyBackend(up *atomic.Bool) func(string) (int, error) {
+	var version atomic.Int32
+	return func(string) (int, error) {
+		if !up.Load() {
+			return 0, errBackendDown
+		}
+		return int(version.Add(1)), nil
+	}
+}
+
+func TestServeStaleOnErrorReturnsLastGoodValue(t *testing.T) {
+	var up atomic.Bool
+	up.Store(true)
+	clock := newFakeClock()
+	var logged atomic.Int32
+	h := fcache.NewHandle(flakyBackend(&up), &fcache.Config{
+		TTL:                      time.Minute,
+		Clock:                    clock,
+		ServeStaleOnError:        true,
+		DisableBackgroundCleanup: true,
+	}, &fcache.Hooks{
+		LogError: func(err error) { logged.Add(1) },
+	})
+
+	if v, err := h.Call("rate"); v != 1 || err != nil {
+		t.Fatalf("first call = (%d, %v); want (1, nil)", v, err)
+	}
+
+	// Expired: the call retries the backend, which fails, and gets the stale value
+	up.Store(false)
+	clock.Advance(2 * time.Minute)
+	if h.Contains("rate") {
+		t.Fatal("expired entry reported as valid")
+	}
+	if v, err := h.Call("rate"); v != 1 || err != nil {
+		t.Errorf("call with the backend down = (%d, %v); want the stale (1, nil)", v, err)
+	}
+	if n := h.Metrics().StaleServes; n != 1 {
+		t.Errorf("StaleServes = %d; want 1", n)
+	}
+	if logged.Load() == 0 {
+		t.Error("the failure was not reported to LogError")
+	}
+
+	// Once the backend recovers, the next call refreshes the entry
+	up.Store(true)
+	if v, err := h.Call("rate"); v != 2 || err != nil {
+		t.Errorf("call after recovery = (%d, %v); want (2, nil)", v, err)
+	}
+
+	// Beyond the stale window (the TTL by default) the error is returned
+	up.Store(false)
+	clock.Advance(2*time.Minute + time.Second)
+	if _, err := h.Call("rate"); !errors.Is(err, errBackendDown) {
+		t.Errorf("call past the stale window error = %v; want %v", err, errBackendDown)
+	}
+	if _, err := h.Call("other"); !errors.Is(err, errBackendDown) {
+		t.Errorf("call without a previous value error = %v; want %v", err, errBackendDown)
+	}
+}
+
+func TestServeStaleOnErrorOff(t *testing.T) {
+	var up atomic.Bool
+	up.Store(true)
+	clock := newFakeClock()
+	h := fcache.NewHandle(flakyBackend(&up), &fcache.Config{
+		TTL:   time.Minute,
+		Clock: clock,
+	}, nil)
+	h.Call("rate")
+	up.Store(false)
+	clock.Advance(2 * time.Minute)
+	if _, err := h.Call("rate"); !errors.Is(err, errBackendDown) {
+		t.Errorf("error = %v; want %v without ServeStaleOnError", err, errBackendDown)
+	}
+}
+
+func TestServeStaleOnErrorSkipsOrphanedEpoch(t *testing.T) {
+	var up atomic.Bool
+	up.Store(true)
+	h := fcache.NewHandle(flakyBackend(&up), &fcache.Config{ServeStaleOnError: true}, nil)
+	h.Call("rate")
+	up.Store(false)
+	h.BumpEpoch()
+	if _, err := h.Call("rate"); !errors.Is(err, errBackendDown) {
+		t.Errorf("error after BumpEpoch = %v; want %v, orphaned entries are not served", err, errBackendDown)
+	}
+}
+
+func TestServeStaleOnErrorWhileBreakerOpen(t *testing.T) {
+	var up atomic.Bool
+	up.Store(true)
+	clock := newFakeClock()
+	h := fcache.NewHandle(flakyBackend(&up), &fcache.Config{
+		TTL:               time.Minute,
+		StaleTTL:          time.Hour,
+		Clock:             clock,
+		ServeStaleOnError: true,
+		BreakerThreshold:  1,
+		BreakerCooldown:   time.Hour,
+	}, nil)
+	h.Call("rate")
+	up.Store(false)
+	if _, err := h.Call("fresh"); !errors.Is(err, errBackendDown) {
+		t.Fatalf("tripping call error = %v; want %v", err, errBackendDown)
+	}
+	clock.Advance(30 * time.Minute)
+	if v, err := h.Call("rate"); v != 1 || err != nil {
+		t.Errorf("call while the breaker is open = (%d, %v); want the stale (1, nil)", v, err)
+	}
+}
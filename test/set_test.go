@@ -0,0 +1,76 @@
+package test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestSetFreshInsert(t *testing.T) {
+	var calls atomic.Int32
+	h := fcache.NewHandle(func(key int) (string, error) {
+		calls.Add(1)
+		return "computed", nil
+	}, &fcache.Config{DisableBackgroundCleanup: true}, nil)
+
+	prev, existed, err := h.Set(1, "manual")
+	if err != nil || existed || prev != "" {
+		t.Fatalf("Set on an empty cache = (%q, %v, %v); want (\"\", false, nil)", prev, existed, err)
+	}
+	if val, err := h.Call(1); err != nil || val != "manual" {
+		t.Errorf("Call(1) = (%q, %v); want the set value", val, err)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("function called %d times; want 0, since Set filled the entry", n)
+	}
+}
+
+func TestSetOverwriteReturnsPrevious(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (string, error) {
+		return "computed", nil
+	}, &fcache.Config{DisableBackgroundCleanup: true}, nil)
+
+	h.Call(1)
+	prev, existed, err := h.Set(1, "first")
+	if err != nil || !existed || prev != "computed" {
+		t.Errorf("Set over a computed entry = (%q, %v, %v); want (\"computed\", true, nil)", prev, existed, err)
+	}
+	prev, existed, err = h.Set(1, "second")
+	if err != nil || !existed || prev != "first" {
+		t.Errorf("second Set = (%q, %v, %v); want (\"first\", true, nil)", prev, existed, err)
+	}
+	if val, _ := h.Call(1); val != "second" {
+		t.Errorf("Call(1) = %q; want \"second\"", val)
+	}
+}
+
+func TestSetIgnoresExpiredPrevious(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (string, error) {
+		return "computed", nil
+	}, &fcache.Config{TTL: 20 * time.Millisecond, DisableBackgroundCleanup: true}, nil)
+
+	h.Call(1)
+	time.Sleep(30 * time.Millisecond)
+	if prev, existed, _ := h.Set(1, "fresh"); existed || prev != "" {
+		t.Errorf("Set over an expired entry = (%q, %v); want (\"\", false)", prev, existed)
+	}
+}
+
+func TestSetFullCache(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{Capacity: 1, OnFull: fcache.FullReject, DisableBackgroundCleanup: true}, nil)
+
+	if _, _, err := h.Set(1, 1); err != nil {
+		t.Fatalf("Set(1) = %v; want nil", err)
+	}
+	if _, _, err := h.Set(2, 2); !errors.Is(err, fcache.ErrCacheFull) {
+		t.Errorf("Set(2) into a full cache = %v; want ErrCacheFull", err)
+	}
+	if prev, existed, err := h.Set(1, 10); err != nil || !existed || prev != 1 {
+		t.Errorf("overwrite in a full cache = (%d, %v, %v); want (1, true, nil)", prev, existed, err)
+	}
+}
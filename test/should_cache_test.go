@@ -0,0 +1,66 @@
+package test
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+func TestShouldCacheRejectsEmptyResults(t *testing.T) {
+	var calls atomic.Int32
+	var sets atomic.Int32
+
+	fn := func(key string) ([]byte, error) {
+		calls.Add(1)
+		if key == "empty" {
+			return nil, nil
+		}
+		return []byte(strings.ToUpper(key)), nil
+	}
+
+	cache := fcache.NewCachedFunction(fn, &fcache.Config{
+		ShouldCache: func(arg string, val []byte, err error) bool {
+			return len(val) > 0
+		},
+	}, &fcache.Hooks{
+		OnSet: func(arg any) error {
+			sets.Add(1)
+			return nil
+		},
+	})
+
+	for i := 0; i < 3; i++ {
+		if v, err := cache("empty"); err != nil || len(v) != 0 {
+			t.Errorf("cache(empty) = %q, %v; want empty, nil", v, err)
+		}
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("calls for a rejected result = %d; want 3", got)
+	}
+
+	cache("abc")
+	if v, _ := cache("abc"); string(v) != "ABC" {
+		t.Errorf("cache(abc) = %q; want ABC", v)
+	}
+	if got := calls.Load(); got != 4 {
+		t.Errorf("calls = %d; want 4 (accepted result is cached)", got)
+	}
+	if got := sets.Load(); got != 1 {
+		t.Errorf("OnSet called %d times; want 1, only for the stored result", got)
+	}
+}
+
+func TestShouldCacheWrongTypePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for a ShouldCache of the wrong type")
+		}
+	}()
+	fcache.NewCachedFunction(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{
+		ShouldCache: func(val int) bool { return true },
+	}, nil)
+}
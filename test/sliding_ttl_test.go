@@ -0,0 +1,51 @@
+package test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestSlidingTTLRefreshesOnHit(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+
+	fn := func(key int) (int, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		return key, nil
+	}
+
+	cache := fcache.NewCachedFunction(fn, &fcache.Config{
+		TTL:        80 * time.Millisecond,
+		Capacity:   100,
+		SlidingTTL: true,
+	}, &fcache.Hooks{})
+
+	cache(1) // call #1
+
+	// Keep reading the key more often than the TTL; each hit resets the clock
+	for i := 0; i < 4; i++ {
+		time.Sleep(40 * time.Millisecond)
+		cache(1)
+	}
+
+	mu.Lock()
+	if calls != 1 {
+		t.Errorf("calls with sliding TTL = %d; want 1", calls)
+	}
+	mu.Unlock()
+
+	// Stop reading and let the entry expire
+	time.Sleep(100 * time.Millisecond)
+	cache(1) // call #2
+
+	mu.Lock()
+	if calls != 2 {
+		t.Errorf("calls after idle expiry = %d; want 2", calls)
+	}
+	mu.Unlock()
+}
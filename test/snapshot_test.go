@@ -0,0 +1,54 @@
+package test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+func TestSnapshotKeysSorted(t *testing.T) {
+	h := fcache.NewHandle(func(key string) (int, error) { return len(key), nil }, nil, nil)
+	for _, key := range []string{"c", "a", "b"} {
+		h.Call(key)
+	}
+	if got, want := h.SnapshotKeys(), []string{"s:a", "s:b", "s:c"}; !slices.Equal(got, want) {
+		t.Errorf("SnapshotKeys = %v; want %v", got, want)
+	}
+}
+
+func TestSnapshotKeysScopedView(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) { return key, nil }, nil, nil)
+	tenant := h.Scoped("tenant")
+	tenant.Call(1)
+	h.Scoped("other").Call(2)
+
+	if got := tenant.SnapshotKeys(); !slices.Equal(got, []string{"1"}) {
+		t.Errorf("SnapshotKeys on view = %v; want [1] without the namespace prefix", got)
+	}
+}
+
+func TestDiffKeys(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) { return key, nil }, &fcache.Config{Capacity: 3}, nil)
+	h.Call(1)
+	h.Call(2)
+	h.Call(3)
+	before := h.SnapshotKeys()
+
+	h.Call(4) // evicts 1
+	h.InvalidateFunc(func(key string) bool { return key == "2" })
+	h.Call(5)
+
+	added, removed := fcache.DiffKeys(before, h.SnapshotKeys())
+	if !slices.Equal(added, []string{"4", "5"}) {
+		t.Errorf("added = %v; want [4 5]", added)
+	}
+	if !slices.Equal(removed, []string{"1", "2"}) {
+		t.Errorf("removed = %v; want [1 2]", removed)
+	}
+
+	added, removed = fcache.DiffKeys(before, before)
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("DiffKeys of identical snapshots = %v, %v; want nothing", added, removed)
+	}
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestSoftHardTTLRegions(t *testing.T) {
+	var version atomic.Int32
+	h := fcache.NewHandle(func(key int) (int32, error) {
+		return version.Add(1), nil
+	}, &fcache.Config{
+		SoftTTL:                  40 * time.Millisecond,
+		HardTTL:                  200 * time.Millisecond,
+		DisableBackgroundCleanup: true,
+	}, nil)
+
+	if v, _ := h.Call(1); v != 1 {
+		t.Fatalf("first call = %d; want 1", v)
+	}
+
+	// fresh: served from the cache, no refresh
+	if v, _ := h.Call(1); v != 1 || version.Load() != 1 {
+		t.Errorf("fresh call = %d after %d executions; want 1 after 1", v, version.Load())
+	}
+
+	// stale: the old value is served while a refresh runs in the background
+	time.Sleep(60 * time.Millisecond)
+	if v, _ := h.Call(1); v != 1 {
+		t.Errorf("stale call = %d; want the stale 1", v)
+	}
+	if !waitFor(func() bool { return version.Load() == 2 }) {
+		t.Fatalf("executions = %d; want a background refresh", version.Load())
+	}
+	if !waitFor(func() bool { v, _ := h.Call(1); return v == 2 }) {
+		t.Error("the refreshed value was never served")
+	}
+
+	// expired: past the hard TTL the call recomputes synchronously
+	time.Sleep(250 * time.Millisecond)
+	if v, _ := h.Call(1); v != 3 {
+		t.Errorf("call past the hard TTL = %d; want a recomputed 3", v)
+	}
+}
+
+func TestSoftTTLRefreshesOncePerKey(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	h := fcache.NewHandle(func(key int) (int, error) {
+		if calls.Add(1) > 1 {
+			<-release // hold the refresh in flight
+		}
+		return key, nil
+	}, &fcache.Config{
+		TTL:                      time.Second,
+		SoftTTL:                  10 * time.Millisecond,
+		DisableBackgroundCleanup: true,
+	}, nil)
+
+	h.Call(1)
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		h.Call(1)
+	}
+	close(release)
+	waitFor(func() bool { return calls.Load() >= 2 })
+	time.Sleep(10 * time.Millisecond)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("executions = %d; want the initial one and a single refresh", n)
+	}
+}
+
+func TestSoftTTLNotShorterThanHardIsIgnored(t *testing.T) {
+	var calls atomic.Int32
+	h := fcache.NewHandle(func(key int) (int, error) {
+		calls.Add(1)
+		return key, nil
+	}, &fcache.Config{
+		TTL:                      time.Second,
+		SoftTTL:                  time.Second,
+		DisableBackgroundCleanup: true,
+	}, nil)
+
+	h.Call(1)
+	time.Sleep(20 * time.Millisecond)
+	h.Call(1)
+	time.Sleep(20 * time.Millisecond)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("executions = %d; want 1, since SoftTTL is not shorter than the TTL", n)
+	}
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache/internal/core"
+)
+
+func statsValues(s *core.Storage[string, string]) []string {
+	stat := s.Stats()
+	values := make([]string, 0, len(stat.Items))
+	for _, item := range stat.Items {
+		values = append(values, item.Value)
+	}
+	return values
+}
+
+func TestStatsFollowsLRUOrder(t *testing.T) {
+	s := core.NewStorage[string, string](core.Config{
+		TTL:                      time.Minute,
+		Capacity:                 3,
+		DisableBackgroundCleanup: true,
+	})
+	s.Set("A", "A")
+	s.Set("B", "B")
+	s.Set("C", "C")
+	s.Get("A")
+	s.Set("D", "D") // evicts B, the least recently used
+
+	got := statsValues(s)
+	want := []string{"D", "A", "C"}
+	if len(got) != len(want) {
+		t.Fatalf("Stats items = %v; want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Stats items = %v; want %v", got, want)
+		}
+	}
+	if n := s.Stats().Entries; n != 3 || n != s.Len() {
+		t.Errorf("Stats().Entries = %d, Len() = %d; want 3", n, s.Len())
+	}
+	for _, v := range got {
+		if stored, ok := s.Get(v); !ok || stored != v {
+			t.Errorf("Stats item %q does not match the stored value %q", v, stored)
+		}
+	}
+}
+
+func TestStatsOverwriteKeepsOneItem(t *testing.T) {
+	s := core.NewStorage[string, string](core.Config{
+		TTL:                      time.Minute,
+		Capacity:                 3,
+		DisableBackgroundCleanup: true,
+	})
+	s.Set("A", "A1")
+	s.Set("B", "B")
+	s.Set("A", "A2") // moves A to the front without a second list node
+
+	got := statsValues(s)
+	if len(got) != 2 || got[0] != "A2" || got[1] != "B" {
+		t.Errorf("Stats items = %v; want [A2 B]", got)
+	}
+}
+
+func TestStatsConcurrentWithWrites(t *testing.T) {
+	s := core.NewStorage[string, string](core.Config{
+		TTL:                      time.Minute,
+		Capacity:                 16,
+		DisableBackgroundCleanup: true,
+	})
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := strconv.Itoa((w*500 + i) % 32)
+				s.Set(key, key)
+				s.Get(key)
+			}
+		}(w)
+	}
+	for i := 0; i < 200; i++ {
+		stat := s.Stats()
+		if stat.Entries > 16 || stat.Entries != len(stat.Items) {
+			t.Fatalf("Stats() = %d entries, %d items; want at most 16 and equal", stat.Entries, len(stat.Items))
+		}
+	}
+	wg.Wait()
+}
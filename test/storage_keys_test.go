@@ -0,0 +1,52 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache/internal/core"
+)
+
+type point struct {
+	X, Y int
+}
+
+func TestStorageWithIntKeys(t *testing.T) {
+	s := core.NewStorage[int, string](core.Config{
+		TTL:                      time.Minute,
+		Capacity:                 2,
+		DisableBackgroundCleanup: true,
+	})
+	s.Set(1, "one")
+	s.Set(2, "two")
+	s.Get(1)
+	s.Set(3, "three") // evicts 2, the least recently used
+
+	if v, ok := s.Get(1); !ok || v != "one" {
+		t.Errorf("Get(1) = %q, %v; want one, true", v, ok)
+	}
+	if _, ok := s.Get(2); ok {
+		t.Error("least recently used int key was not evicted")
+	}
+}
+
+func TestStorageWithStructKeys(t *testing.T) {
+	s := core.NewStorage[point, int](core.Config{
+		TTL:                      20 * time.Millisecond,
+		Capacity:                 10,
+		DisableBackgroundCleanup: true,
+	})
+	s.Set(point{1, 2}, 3)
+
+	if v, ok := s.Get(point{1, 2}); !ok || v != 3 {
+		t.Errorf("Get({1 2}) = %d, %v; want 3, true", v, ok)
+	}
+	if _, ok := s.Get(point{2, 1}); ok {
+		t.Error("Get({2 1}) found an entry for a different key")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if removed := s.PurgeExpired(); removed != 1 {
+		t.Errorf("PurgeExpired removed %d; want 1", removed)
+	}
+}
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestStoreCapacityLimitAndEviction(t *testing.T) {
+	s := fcache.NewStore[int](&fcache.Config{Capacity: 2})
+	defer s.Close()
+
+	s.Set("a", 1)
+	s.Set("b", 2)
+	// Access a to make b the least recently used
+	if v, ok := s.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = (%d, %v); want (1, true)", v, ok)
+	}
+	s.Set("c", 3)
+
+	if _, ok := s.Get("b"); ok {
+		t.Error("least recently used entry b was not evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if v, ok := s.Get(key); !ok || v != want {
+			t.Errorf("Get(%s) = (%d, %v); want (%d, true)", key, v, ok, want)
+		}
+	}
+	if n := s.Len(); n != 2 {
+		t.Errorf("Len = %d; want capacity 2", n)
+	}
+}
+
+func TestStoreDefaults(t *testing.T) {
+	s := fcache.NewStore[string](nil)
+	defer s.Close()
+	if c := s.Capacity(); c != 1000 {
+		t.Errorf("Capacity = %d; want the default 1000", c)
+	}
+	if ttl := s.TTL(); ttl != 5*time.Minute {
+		t.Errorf("TTL = %v; want the default 5m0s", ttl)
+	}
+	s.Set("k", "v")
+	if v, ok := s.Get("k"); !ok || v != "v" {
+		t.Errorf("Get(k) = (%q, %v); want (v, true)", v, ok)
+	}
+}
+
+func TestStoreEntriesExpireAfterTTL(t *testing.T) {
+	clock := newFakeClock()
+	s := fcache.NewStore[int](&fcache.Config{
+		TTL:                      time.Minute,
+		Clock:                    clock,
+		DisableBackgroundCleanup: true,
+	})
+
+	s.Set("k", 7)
+	clock.Advance(time.Minute) // exactly the TTL: still valid
+	if v, ok := s.Get("k"); !ok || v != 7 {
+		t.Fatalf("Get at the TTL = (%d, %v); want (7, true)", v, ok)
+	}
+	clock.Advance(time.Nanosecond)
+	if _, ok := s.Get("k"); ok {
+		t.Error("entry still served after its TTL")
+	}
+	if n := s.Len(); n != 0 {
+		t.Errorf("Len after an expired lookup = %d; want 0", n)
+	}
+}
+
+func TestStoreCleanupLifecycle(t *testing.T) {
+	clock := newFakeClock()
+	s := fcache.NewStore[int](&fcache.Config{
+		TTL:             time.Hour,
+		CleanupInterval: time.Minute,
+		Clock:           clock,
+	})
+
+	s.Set("k", 1)
+	if !waitFor(func() bool { return clock.Tickers() == 1 }) {
+		t.Fatal("the cleanup goroutine did not start a ticker on the clock")
+	}
+	clock.Advance(2 * time.Hour)
+	if !waitFor(func() bool { return s.Len() == 0 }) {
+		t.Fatal("background cleanup did not remove the expired entry")
+	}
+	if !waitFor(func() bool { return clock.Tickers() == 0 }) {
+		t.Error("cleanup goroutine still ticking after the store emptied")
+	}
+
+	s.Set("k", 2)
+	if !waitFor(func() bool { return clock.Tickers() == 1 }) {
+		t.Fatal("the cleanup goroutine did not restart on the next Set")
+	}
+	if n := s.Close(); n != 1 {
+		t.Errorf("Close removed %d entries; want 1", n)
+	}
+	if !waitFor(func() bool { return clock.Tickers() == 0 }) {
+		t.Error("cleanup goroutine still ticking after Close")
+	}
+	s.Set("k", 3)
+	if s.Contains("k") {
+		t.Error("a closed store kept a value")
+	}
+}
+
+func TestStoreRejectsInvalidConfig(t *testing.T) {
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, fcache.ErrInvalidConfig) {
+			t.Errorf("NewStore panicked with %v; want ErrInvalidConfig", err)
+		}
+	}()
+	fcache.NewStore[int](&fcache.Config{NoExpire: true, TTL: time.Minute})
+}
@@ -0,0 +1,75 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+type order struct {
+	ID     int
+	UserID int
+}
+
+func ordersHandle(cfg *fcache.Config) *fcache.Handle[int, order] {
+	cfg.TagFunc = func(id int, o order) []string {
+		return []string{fmt.Sprintf("user:%d", o.UserID)}
+	}
+	return fcache.NewHandle(func(id int) (order, error) {
+		return order{ID: id, UserID: id % 2}, nil
+	}, cfg, &fcache.Hooks{})
+}
+
+func TestInvalidateTagRemovesAllTaggedEntries(t *testing.T) {
+	handle := ordersHandle(&fcache.Config{})
+	handle.Call(1) // user 1
+	handle.Call(2) // user 0
+	handle.Call(3) // user 1
+
+	if removed := handle.InvalidateTag("user:1"); removed != 2 {
+		t.Errorf("InvalidateTag removed %d; want 2", removed)
+	}
+	if handle.Contains(1) || handle.Contains(3) {
+		t.Error("tagged entries are still cached")
+	}
+	if !handle.Contains(2) {
+		t.Error("entry with another tag was removed")
+	}
+	if removed := handle.InvalidateTag("user:1"); removed != 0 {
+		t.Errorf("second InvalidateTag removed %d; want 0", removed)
+	}
+}
+
+func TestTagIndexFollowsEvictionAndExpiry(t *testing.T) {
+	handle := ordersHandle(&fcache.Config{Capacity: 2, TTL: 40 * time.Millisecond})
+	handle.Call(1) // user 1
+	handle.Call(3) // user 1
+	handle.Call(5) // user 1, evicts 1
+
+	if removed := handle.InvalidateTag("user:1"); removed != 2 {
+		t.Errorf("InvalidateTag after eviction removed %d; want 2", removed)
+	}
+
+	handle.Call(7)
+	time.Sleep(60 * time.Millisecond)
+	handle.PurgeExpired()
+	if removed := handle.InvalidateTag("user:1"); removed != 0 {
+		t.Errorf("InvalidateTag after expiry removed %d; want 0", removed)
+	}
+}
+
+func TestInvalidateTagOnScopedView(t *testing.T) {
+	handle := ordersHandle(&fcache.Config{})
+	a, b := handle.Scoped("a"), handle.Scoped("b")
+	a.Call(1)
+	b.Call(1)
+
+	if removed := a.InvalidateTag("user:1"); removed != 1 {
+		t.Errorf("InvalidateTag on view a removed %d; want 1", removed)
+	}
+	if !b.Contains(1) {
+		t.Error("InvalidateTag on view a removed an entry of view b")
+	}
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache/internal/core"
+)
+
+func newTinyStorage(capacity int) *core.Storage[string, int] {
+	return core.NewStorage[string, int](core.Config{
+		TTL:                      time.Minute,
+		Capacity:                 capacity,
+		DisableBackgroundCleanup: true,
+	})
+}
+
+func TestStorageCapacityZeroUsesDefault(t *testing.T) {
+	s := newTinyStorage(0)
+	for i := 0; i < 1000; i++ {
+		s.Set(strconv.Itoa(i), i)
+	}
+	if n := s.Len(); n != 1000 {
+		t.Errorf("Len = %d; want default capacity 1000", n)
+	}
+}
+
+func TestStorageCapacityOne(t *testing.T) {
+	s := newTinyStorage(1)
+
+	s.Set("a", 1)
+	// Overwrite at capacity: must keep the just-written key
+	s.Set("a", 2)
+	if v, ok := s.Get("a"); !ok || v != 2 {
+		t.Errorf("Get(a) after overwrite = (%d, %v); want (2, true)", v, ok)
+	}
+	if n := s.Len(); n != 1 {
+		t.Errorf("Len after overwrite = %d; want 1", n)
+	}
+
+	// A new key replaces the previous one
+	s.Set("b", 3)
+	if _, ok := s.Get("a"); ok {
+		t.Error("Get(a) after inserting b = found; want evicted")
+	}
+	if v, ok := s.Get("b"); !ok || v != 3 {
+		t.Errorf("Get(b) = (%d, %v); want (3, true)", v, ok)
+	}
+}
+
+func TestStorageCapacityTwo(t *testing.T) {
+	s := newTinyStorage(2)
+
+	s.Set("a", 1)
+	s.Set("b", 2)
+	// Overwriting a moves it to the front, making b the LRU entry
+	s.Set("a", 10)
+	s.Set("c", 3)
+
+	if _, ok := s.Get("b"); ok {
+		t.Error("Get(b) = found; want evicted as least recently used")
+	}
+	if v, ok := s.Get("a"); !ok || v != 10 {
+		t.Errorf("Get(a) = (%d, %v); want (10, true)", v, ok)
+	}
+	if v, ok := s.Get("c"); !ok || v != 3 {
+		t.Errorf("Get(c) = (%d, %v); want (3, true)", v, ok)
+	}
+	if n := s.Len(); n != 2 {
+		t.Errorf("Len = %d; want 2", n)
+	}
+}
@@ -0,0 +1,156 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+// ttlEntry is a cached value carrying its own TTL, like a token with an expiry.
+type ttlEntry struct {
+	id  int
+	ttl time.Duration
+}
+
+func newTTLPrecedenceHandle(clock *fakeClock, precedence fcache.TTLPrecedence) *fcache.Handle[time.Duration, ttlEntry] {
+	calls := 0
+	return fcache.NewHandle(func(ttl time.Duration) (ttlEntry, error) {
+		calls++
+		return ttlEntry{id: calls, ttl: ttl}, nil
+	}, &fcache.Config{
+		TTL:                      time.Minute,
+		Clock:                    clock,
+		DisableBackgroundCleanup: true,
+		TTLFunc:                  func(v ttlEntry) time.Duration { return v.ttl },
+		TTLPrecedence:            precedence,
+	}, nil)
+}
+
+func TestTTLPrecedence(t *testing.T) {
+	const short, long = 10 * time.Second, time.Hour
+	for _, tc := range []struct {
+		precedence fcache.TTLPrecedence
+		// how long entries with the short and long TTLs (and the global TTL of a minute) live
+		shortLives, longLives time.Duration
+	}{
+		{fcache.TTLMin, short, time.Minute},
+		{fcache.TTLPerEntry, short, long},
+		{fcache.TTLGlobal, time.Minute, time.Minute},
+	} {
+		t.Run(tc.precedence.String(), func(t *testing.T) {
+			clock := newFakeClock()
+			h := newTTLPrecedenceHandle(clock, tc.precedence)
+			h.Call(short)
+			h.Call(long)
+			h.Call(0) // no TTL of its own: the global TTL applies in every mode
+
+			for _, check := range []struct {
+				arg   time.Duration
+				lives time.Duration
+			}{{short, tc.shortLives}, {long, tc.longLives}, {0, time.Minute}} {
+				if remaining, ok := h.TTLRemaining(check.arg); !ok || remaining != check.lives {
+					t.Errorf("TTLRemaining(%v) = %v, %v; want %v", check.arg, remaining, ok, check.lives)
+				}
+			}
+
+			clock.Advance(tc.shortLives + time.Second)
+			if h.Contains(short) {
+				t.Errorf("entry with TTL %v still cached after %v", short, tc.shortLives)
+			}
+			clock.Advance(tc.longLives - tc.shortLives)
+			if h.Contains(long) {
+				t.Errorf("entry with TTL %v still cached after %v", long, tc.longLives)
+			}
+			if v, _ := h.Call(long); v.id != 4 {
+				t.Errorf("expired entry served: id %d", v.id)
+			}
+		})
+	}
+}
+
+func TestTTLFuncCleanupFindsEntriesBehindLongerOnes(t *testing.T) {
+	clock := newFakeClock()
+	h := newTTLPrecedenceHandle(clock, fcache.TTLPerEntry)
+	h.Call(time.Hour) // oldest entry, valid for an hour
+	h.Call(time.Second)
+	h.Call(2 * time.Second)
+
+	clock.Advance(5 * time.Second)
+	if removed, remaining := h.RunCleanup(); removed != 2 || remaining != 1 {
+		t.Errorf("RunCleanup() = (%d, %d); want (2, 1)", removed, remaining)
+	}
+	if !h.Contains(time.Hour) {
+		t.Error("entry with the longer TTL was removed")
+	}
+}
+
+func TestTTLPrecedenceFollowsSetTTL(t *testing.T) {
+	clock := newFakeClock()
+	h := newTTLPrecedenceHandle(clock, fcache.TTLMin)
+	h.Call(30 * time.Second)
+
+	h.SetTTL(10 * time.Second) // now shorter than the entry's own TTL
+	clock.Advance(15 * time.Second)
+	if h.Contains(30 * time.Second) {
+		t.Error("entry outlived the global TTL lowered by SetTTL under TTLMin")
+	}
+}
+
+func TestTTLFuncCleanupInBatches(t *testing.T) {
+	clock := newFakeClock()
+	h := fcache.NewHandle(func(ttl time.Duration) (ttlEntry, error) {
+		return ttlEntry{ttl: ttl}, nil
+	}, &fcache.Config{
+		TTL:                      time.Minute,
+		Capacity:                 1000,
+		Clock:                    clock,
+		DisableBackgroundCleanup: true,
+		CleanupBatchSize:         2,
+		TTLFunc:                  func(v ttlEntry) time.Duration { return v.ttl },
+		TTLPrecedence:            fcache.TTLPerEntry,
+	}, nil)
+	// live entries interleaved with expiring ones, oldest first
+	for i := 1; i <= 20; i++ {
+		if i%2 == 0 {
+			h.Call(time.Duration(i) * time.Millisecond)
+		} else {
+			h.Call(time.Duration(i) * time.Hour)
+		}
+	}
+
+	clock.Advance(time.Second)
+	if removed, remaining := h.RunCleanup(); removed != 10 || remaining != 10 {
+		t.Errorf("RunCleanup() = (%d, %d); want (10, 10)", removed, remaining)
+	}
+
+	h.SetTTL(time.Second) // under TTLPerEntry, only entries without their own TTL follow it
+	clock.Advance(2 * time.Hour)
+	if removed, remaining := h.RunCleanup(); removed != 1 || remaining != 9 {
+		t.Errorf("RunCleanup() after 2h = (%d, %d); want (1, 9)", removed, remaining)
+	}
+}
+
+func TestTTLFuncFullCacheDropsExpiredEntries(t *testing.T) {
+	clock := newFakeClock()
+	h := fcache.NewHandle(func(ttl time.Duration) (ttlEntry, error) {
+		return ttlEntry{ttl: ttl}, nil
+	}, &fcache.Config{
+		TTL:                      time.Minute,
+		Capacity:                 2,
+		Clock:                    clock,
+		DisableBackgroundCleanup: true,
+		OnFull:                   fcache.FullReject,
+		TTLFunc:                  func(v ttlEntry) time.Duration { return v.ttl },
+	}, nil)
+	h.Call(time.Hour)       // oldest, still valid under TTLMin for a minute
+	h.Call(time.Second * 5) // expires first
+
+	clock.Advance(10 * time.Second)
+	if _, err := h.Call(time.Second); err != nil {
+		t.Fatalf("store into a full cache with an expired entry: %v", err)
+	}
+	if !h.Contains(time.Hour) {
+		t.Error("valid entry was dropped instead of the expired one")
+	}
+}
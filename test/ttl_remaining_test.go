@@ -0,0 +1,60 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestTTLRemainingDecreases(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{TTL: 200 * time.Millisecond, DisableBackgroundCleanup: true}, nil)
+
+	if d, ok := h.TTLRemaining(1); ok || d != 0 {
+		t.Errorf("TTLRemaining before the call = (%v, %v); want (0, false)", d, ok)
+	}
+
+	h.Call(1)
+	first, ok := h.TTLRemaining(1)
+	if !ok || first <= 0 || first > 200*time.Millisecond {
+		t.Fatalf("TTLRemaining after the call = (%v, %v); want (0, 200ms], true", first, ok)
+	}
+	time.Sleep(30 * time.Millisecond)
+	second, ok := h.TTLRemaining(1)
+	if !ok || second >= first {
+		t.Errorf("TTLRemaining 30ms later = (%v, %v); want less than %v, true", second, ok, first)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if d, ok := h.TTLRemaining(1); ok || d >= 0 {
+		t.Errorf("TTLRemaining after expiry = (%v, %v); want negative, false", d, ok)
+	}
+}
+
+func TestTTLRemainingSlidingRestartsOnHit(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{TTL: 200 * time.Millisecond, SlidingTTL: true, DisableBackgroundCleanup: true}, nil)
+
+	h.Call(1)
+	time.Sleep(50 * time.Millisecond)
+	before, _ := h.TTLRemaining(1)
+	h.Call(1) // a hit refreshes the timestamp
+	after, ok := h.TTLRemaining(1)
+	if !ok || after <= before {
+		t.Errorf("TTLRemaining after a hit = (%v, %v); want more than %v, true", after, ok, before)
+	}
+}
+
+func TestTTLRemainingNoExpire(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{NoExpire: true}, nil)
+
+	h.Call(1)
+	if d, ok := h.TTLRemaining(1); !ok || d < 24*time.Hour {
+		t.Errorf("TTLRemaining with NoExpire = (%v, %v); want a very long duration, true", d, ok)
+	}
+}
@@ -8,48 +8,184 @@ import (
 	"github.com/osmike/fcache"
 )
 
+// fakeClock is a Clock that only moves when advanced, firing its tickers on the way.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+// fakeTicker is a Ticker of a fakeClock.
+type fakeTicker struct {
+	c       chan time.Time
+	every   time.Duration
+	next    time.Time
+	stopped bool
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) fcache.Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{c: make(chan time.Time, 1), every: d, next: c.now.Add(d)}
+	c.tickers = append(c.tickers, t)
+	return stopper{t, c}
+}
+
+// Tickers returns the number of tickers that have not been stopped.
+func (c *fakeClock) Tickers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, t := range c.tickers {
+		if !t.stopped {
+			n++
+		}
+	}
+	return n
+}
+
+// Advance moves the clock forward, sending a tick to every ticker whose next tick is due.
+// Like time.Ticker, a ticker that is not read in time drops ticks.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.tickers {
+		for !t.stopped && !t.next.After(c.now) {
+			select {
+			case t.c <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.every)
+		}
+	}
+}
+
+// stopper stops a fakeTicker under its clock's lock.
+type stopper struct {
+	*fakeTicker
+	clock *fakeClock
+}
+
+func (s stopper) Stop() {
+	s.clock.mu.Lock()
+	s.stopped = true
+	s.clock.mu.Unlock()
+}
+
 func TestResultsExpireAfterTTL(t *testing.T) {
-	var mu sync.Mutex
+	clock := newFakeClock()
 	calls := 0
-
 	fn := func(key int) (int, error) {
-		mu.Lock()
 		calls++
-		mu.Unlock()
 		return key + 1, nil
 	}
 
 	cache := fcache.NewCachedFunction(fn, &fcache.Config{
-		TTL:      50 * time.Millisecond,
-		Capacity: 100,
+		TTL:                      time.Minute,
+		Capacity:                 100,
+		Clock:                    clock,
+		DisableBackgroundCleanup: true,
 	}, &fcache.Hooks{})
 
-	// First call: should invoke the underlying function
 	if v, _ := cache(7); v != 8 {
 		t.Fatal("unexpected value")
 	}
-	// Second call: should return cached value (not expired)
+	clock.Advance(time.Minute) // exactly the TTL: still valid
 	if v, _ := cache(7); v != 8 {
 		t.Fatal("unexpected value")
 	}
-
-	// Underlying function should be called only once before expiry
-	mu.Lock()
 	if calls != 1 {
 		t.Errorf("calls before expiry = %d; want 1", calls)
 	}
-	mu.Unlock()
-
-	// Wait for TTL to expire
-	time.Sleep(60 * time.Millisecond)
 
-	// After expiry, should invoke the underlying function again
+	clock.Advance(time.Nanosecond)
 	if v, _ := cache(7); v != 8 {
 		t.Fatal("unexpected value after expiry")
 	}
-	mu.Lock()
 	if calls != 2 {
 		t.Errorf("calls after expiry = %d; want 2", calls)
 	}
-	mu.Unlock()
+}
+
+func TestPurgeExpiredWithFakeClock(t *testing.T) {
+	clock := newFakeClock()
+	h := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{TTL: time.Hour, Clock: clock, DisableBackgroundCleanup: true}, nil)
+
+	h.Call(1)
+	clock.Advance(30 * time.Minute)
+	h.Call(2)
+	if n := h.PurgeExpired(); n != 0 {
+		t.Fatalf("PurgeExpired removed %d entries before any TTL elapsed; want 0", n)
+	}
+	if d, ok := h.TTLRemaining(1); !ok || d != 30*time.Minute {
+		t.Errorf("TTLRemaining(1) = (%v, %v); want exactly 30m, true", d, ok)
+	}
+
+	clock.Advance(31 * time.Minute) // entry 1 is 61 minutes old, entry 2 31 minutes
+	if n := h.PurgeExpired(); n != 1 {
+		t.Errorf("PurgeExpired removed %d entries; want only the first", n)
+	}
+	if h.Contains(1) || !h.Contains(2) {
+		t.Error("PurgeExpired removed the wrong entry")
+	}
+}
+
+func TestCleanupRunsOnClockTicker(t *testing.T) {
+	clock := newFakeClock()
+	var mu sync.Mutex
+	var expired []int
+	h := fcache.NewHandle(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{
+		TTL:             time.Hour,
+		CleanupInterval: time.Minute,
+		Clock:           clock,
+	}, &fcache.Hooks{
+		OnRemove: func(hc fcache.HookContext) error {
+			mu.Lock()
+			expired = append(expired, hc.Value.(int))
+			mu.Unlock()
+			return nil
+		},
+	})
+
+	h.Call(1)
+	if !waitFor(func() bool { return clock.Tickers() == 1 }) {
+		t.Fatal("the cleanup goroutine did not start a ticker on the clock")
+	}
+	removed := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(expired)
+	}
+
+	clock.Advance(time.Minute) // a sweep runs, but nothing has expired yet
+	time.Sleep(10 * time.Millisecond)
+	if n := removed(); n != 0 {
+		t.Fatalf("cleanup removed %d entries before the TTL; want 0", n)
+	}
+
+	clock.Advance(time.Hour)
+	if !waitFor(func() bool { return removed() == 1 }) {
+		t.Fatal("advancing the clock past the TTL did not trigger a cleanup sweep")
+	}
+	// the sweep emptied the cache, so the cleanup goroutine stops and releases its ticker
+	if !waitFor(func() bool { return clock.Tickers() == 0 }) {
+		t.Errorf("%d tickers still running after cleanup emptied the cache; want 0", clock.Tickers())
+	}
 }
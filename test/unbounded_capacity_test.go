@@ -0,0 +1,59 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+func TestUnboundedCapacityEvictsByBudgetOnly(t *testing.T) {
+	const n = 1500 // more than the default capacity of 1000
+	h := fcache.NewHandle(func(key int) (int, error) { return key, nil }, &fcache.Config{
+		Capacity:              fcache.UnboundedCapacity,
+		MaxBytes:              1 << 20,
+		CostFunc:              func(int) int64 { return 8 },
+		MaxBytesCheckInterval: 1, // sweep on every write
+	}, nil)
+
+	for i := 0; i < n; i++ {
+		h.Call(i)
+	}
+	if got := h.Stats().Entries; got != n {
+		t.Errorf("entries = %d; want all %d, none evicted by count", got, n)
+	}
+	if ev := h.Metrics().Evictions; ev != 0 {
+		t.Errorf("evictions = %d; want 0", ev)
+	}
+	if got := h.Config().Capacity; got != fcache.UnboundedCapacity {
+		t.Errorf("Config().Capacity = %d; want UnboundedCapacity", got)
+	}
+
+	// The byte budget still bounds the cache: 8 bytes per entry, 1000 entries
+	small := fcache.NewHandle(func(key int) (int, error) { return key, nil }, &fcache.Config{
+		Capacity:              fcache.UnboundedCapacity,
+		MaxBytes:              8000,
+		CostFunc:              func(int) int64 { return 8 },
+		MaxBytesCheckInterval: 1,
+	}, nil)
+	for i := 0; i < n; i++ {
+		small.Call(i)
+	}
+	if got := small.Stats().Entries; got != 1000 {
+		t.Errorf("entries under an 8000-byte budget = %d; want 1000", got)
+	}
+}
+
+func TestSetCapacityUnbounded(t *testing.T) {
+	h := fcache.NewHandle(func(key int) (int, error) { return key, nil }, &fcache.Config{Capacity: 2}, nil)
+	h.SetCapacity(fcache.UnboundedCapacity)
+	for i := 0; i < 10; i++ {
+		h.Call(i)
+	}
+	if got := h.Stats().Entries; got != 10 {
+		t.Errorf("entries = %d; want 10", got)
+	}
+	h.SetCapacity(3)
+	if got := h.Stats().Entries; got != 3 {
+		t.Errorf("entries after SetCapacity(3) = %d; want 3", got)
+	}
+}
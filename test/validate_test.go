@@ -0,0 +1,84 @@
+package test
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestValidateRejectsConflicts(t *testing.T) {
+	for field, cfg := range map[string]fcache.Config{
+		"TTL":                   {NoExpire: true, TTL: time.Minute},
+		"HardTTL":               {NoExpire: true, HardTTL: time.Minute},
+		"SoftTTL":               {NoExpire: true, SoftTTL: time.Second},
+		"SlidingTTL":            {NoExpire: true, SlidingTTL: true},
+		"TTLFunc":               {NoExpire: true, TTLFunc: func(v int) time.Duration { return time.Second }},
+		"TTLPrecedence":         {TTLPrecedence: fcache.TTLPerEntry},
+		"KeyHasher":             {VerifyKeys: true, KeyHasher: fcache.KeyHasher{New: sha256.New}},
+		"StaleTTL":              {StaleTTL: time.Minute},
+		"AsyncHookWorkers":      {AsyncHookWorkers: 8},
+		"AsyncHookQueueSize":    {AsyncHookQueueSize: 16},
+		"OnFullTimeout":         {OnFull: fcache.FullReject, OnFullTimeout: time.Second},
+		"BreakerCooldown":       {BreakerCooldown: time.Second},
+		"MemoryLimit":           {MemoryLimit: 1 << 30},
+		"MemoryCheckInterval":   {MemoryCheckInterval: time.Second},
+		"MemoryPressureReclaim": {MemoryPressureReclaim: true},
+		"ConcurrencyFailFast":   {ConcurrencyFailFast: true},
+		"CostFunc":              {CostFunc: func(v int) int64 { return 1 }},
+		"MaxBytesCheckInterval": {MaxBytesCheckInterval: time.Second},
+	} {
+		err := cfg.Validate()
+		var fe *fcache.Error
+		if !errors.Is(err, fcache.ErrInvalidConfig) || !errors.As(err, &fe) {
+			t.Errorf("%s: Validate() = %v; want ErrInvalidConfig", field, err)
+			continue
+		}
+		if fe.Fields["field"] != field {
+			t.Errorf("%s: reported field %v", field, fe.Fields["field"])
+		}
+	}
+}
+
+func TestValidateRejectsReclaimWithoutCleanup(t *testing.T) {
+	cfg := fcache.Config{MemoryPressureReclaim: true, MemoryLimit: 1 << 30, DisableBackgroundCleanup: true}
+	var fe *fcache.Error
+	if err := cfg.Validate(); !errors.As(err, &fe) || fe.Fields["field"] != "MemoryPressureReclaim" {
+		t.Errorf("Validate() = %v; want ErrInvalidConfig for MemoryPressureReclaim", err)
+	}
+}
+
+func TestValidateAcceptsCoherentConfigs(t *testing.T) {
+	var nilCfg *fcache.Config
+	for name, cfg := range map[string]*fcache.Config{
+		"nil":       nilCfg,
+		"zero":      {},
+		"no expire": {NoExpire: true, Capacity: 10},
+		"ttls":      {TTL: time.Minute, SoftTTL: time.Second, SlidingTTL: true},
+		"async":     {AsyncHooks: true, AsyncHookWorkers: 2, AsyncHookQueueSize: 8},
+		"block":     {OnFull: fcache.FullBlock, OnFullTimeout: time.Second},
+		"breaker":   {BreakerThreshold: 3, BreakerCooldown: time.Second},
+		"limit":     {MaxConcurrentExecutions: 2, ConcurrencyFailFast: true},
+		"budget":    {MaxBytes: 1 << 20, CostFunc: func(v int) int64 { return 1 }},
+		"memory":    {MemoryPressureReclaim: true, MemoryLimit: 1 << 30, MemoryCheckInterval: time.Second},
+		"stale":     {ServeStaleOnError: true, StaleTTL: time.Hour},
+	} {
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("%s: Validate() = %v; want nil", name, err)
+		}
+	}
+}
+
+func TestConstructorPanicsOnInvalidConfig(t *testing.T) {
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, fcache.ErrInvalidConfig) {
+			t.Errorf("recovered %v; want a panic with ErrInvalidConfig", err)
+		}
+	}()
+	fcache.NewCachedFunction(func(key int) (int, error) {
+		return key, nil
+	}, &fcache.Config{NoExpire: true, TTL: time.Minute}, nil)
+}
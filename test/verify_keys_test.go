@@ -0,0 +1,70 @@
+package test
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/osmike/fcache"
+	"github.com/osmike/fcache/internal/lib/keygen"
+)
+
+// collidingHasher maps every long key to the same digest, forcing hash collisions.
+var collidingHasher = fcache.KeyHasher{Encode: func([]byte) string { return "collision" }}
+
+func TestHashCollisionServesWrongValue(t *testing.T) {
+	h := fcache.NewHandle(func(doc string) (int, error) { return len(doc), nil }, &fcache.Config{
+		KeyHasher: collidingHasher,
+	}, nil)
+	short, long := strings.Repeat("a", 200), strings.Repeat("b", 300)
+	h.Call(short)
+	// Without verification the collision goes unnoticed
+	if v, _ := h.Call(long); v != 200 {
+		t.Fatalf("Call(long) = %d; want the colliding entry's 200, or the test no longer forces a collision", v)
+	}
+}
+
+func TestVerifyKeysTurnsCollisionIntoMiss(t *testing.T) {
+	var calls atomic.Int32
+	h := fcache.NewHandle(func(doc string) (int, error) {
+		calls.Add(1)
+		return len(doc), nil
+	}, &fcache.Config{VerifyKeys: true}, nil)
+	short, long := strings.Repeat("a", 200), strings.Repeat("b", 300)
+
+	if v, _ := h.Call(short); v != 200 {
+		t.Fatalf("Call(short) = %d; want 200", v)
+	}
+	if v, _ := h.Call(long); v != 300 {
+		t.Errorf("Call(long) = %d; want its own 300", v)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("calls = %d; want a miss for the second argument (2)", n)
+	}
+	h.Call(short)
+	h.Call(long)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("calls after repeats = %d; want hits for both arguments (2)", n)
+	}
+}
+
+func TestVerbatimKeysAreFullEncodings(t *testing.T) {
+	b := keygen.Builder{Verbatim: true}
+	long := strings.Repeat("x", 500)
+	if key, err := b.BuildKey(long); err != nil || key != "s:"+long {
+		t.Errorf("BuildKey(long string) = %.20q, %v; want the unhashed s:-prefixed string", key, err)
+	}
+
+	type pair struct{ A int }
+	mapKey, err := b.BuildKey(map[string]any{"A": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	structKey, err := b.BuildKey(pair{A: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapKey == structKey {
+		t.Errorf("a map and a struct with the same JSON share the verbatim key %q", mapKey)
+	}
+}
@@ -0,0 +1,123 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestWarmAndWaitPopulatesKeys(t *testing.T) {
+	var running, peak, calls atomic.Int32
+	fn := func(key int) (int, error) {
+		calls.Add(1)
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		if key < 0 {
+			return 0, errors.New("negative")
+		}
+		return key * 10, nil
+	}
+	h := fcache.NewHandleComparable(fn, &fcache.Config{
+		TTL:                     time.Minute,
+		Capacity:                100,
+		MaxConcurrentExecutions: 3,
+	}, nil)
+
+	h.Call(0) // already cached before warming
+	calls.Store(0)
+
+	args := make([]int, 0, 22)
+	for i := 0; i < 20; i++ {
+		args = append(args, i)
+	}
+	args = append(args, 5, -1) // a duplicate and a failing key
+
+	results := h.WarmAndWait(context.Background(), args)
+	if len(results) != len(args) {
+		t.Fatalf("got %d results for %d args", len(results), len(args))
+	}
+	for i, arg := range args[:21] {
+		if results[i] != nil {
+			t.Errorf("warming %d: %v", arg, results[i])
+		}
+		if !h.Contains(arg) {
+			t.Errorf("%d not cached after WarmAndWait", arg)
+		}
+	}
+	if results[21] == nil || results[21].Error() != "negative" {
+		t.Errorf("warming -1: got %v, want the function error", results[21])
+	}
+	if got := calls.Load(); got != 20 {
+		t.Errorf("function ran %d times; want 20 (cached and duplicate keys skipped)", got)
+	}
+	if got := peak.Load(); got > 3 {
+		t.Errorf("peak concurrency %d exceeds MaxConcurrentExecutions 3", got)
+	}
+}
+
+func TestWarmAndWaitRespectsCapacity(t *testing.T) {
+	h := fcache.NewHandleComparable(func(key int) (int, error) { return key, nil }, &fcache.Config{
+		TTL:      time.Minute,
+		Capacity: 3,
+	}, nil)
+
+	results := h.WarmAndWait(context.Background(), []int{1, 2, 3, 4, 5})
+	for i, key := range []int{1, 2, 3} {
+		if results[i] != nil || !h.Contains(key) {
+			t.Errorf("key %d: err %v, cached %v; want warmed", key, results[i], h.Contains(key))
+		}
+	}
+	for i, key := range []int{4, 5} {
+		if !errors.Is(results[3+i], fcache.ErrWarmCapacity) {
+			t.Errorf("key %d: err %v; want ErrWarmCapacity", key, results[3+i])
+		}
+		if h.Contains(key) {
+			t.Errorf("key %d cached beyond the capacity", key)
+		}
+	}
+}
+
+func TestWarmAndWaitContextCancel(t *testing.T) {
+	release := make(chan struct{})
+	var once sync.Once
+	defer once.Do(func() { close(release) })
+	h := fcache.NewHandleComparable(func(key int) (int, error) {
+		<-release
+		return key, nil
+	}, &fcache.Config{TTL: time.Minute, MaxConcurrentExecutions: 1}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	results := h.WarmAndWait(ctx, []int{1, 2, 3})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("WarmAndWait returned after %v; want it to stop when ctx is done", elapsed)
+	}
+	for i, err := range results {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("result %d = %v; want context.DeadlineExceeded", i, err)
+		}
+	}
+
+	// the computation running at cancellation still stores its result
+	once.Do(func() { close(release) })
+	deadline := time.Now().Add(time.Second)
+	for !h.Contains(1) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !h.Contains(1) {
+		t.Error("the running computation was not stored after cancellation")
+	}
+}
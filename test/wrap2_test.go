@@ -0,0 +1,51 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmike/fcache"
+)
+
+func TestWrap2CachesBothResults(t *testing.T) {
+	type user struct{ Name string }
+	type account struct{ Balance int }
+	errMissing := errors.New("missing")
+
+	var mu sync.Mutex
+	calls := 0
+
+	fn := func(id int) (user, account, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		if id < 0 {
+			return user{}, account{}, errMissing
+		}
+		return user{Name: fmt.Sprintf("user-%d", id)}, account{Balance: id * 100}, nil
+	}
+
+	cached := fcache.Wrap2(fn, &fcache.Config{
+		TTL:      time.Minute,
+		Capacity: 10,
+	}, &fcache.Hooks{})
+
+	for i := 0; i < 2; i++ {
+		u, a, err := cached(7)
+		if err != nil || u.Name != "user-7" || a.Balance != 700 {
+			t.Errorf("call %d = (%v, %v, %v); want (user-7, 700, nil)", i, u, a, err)
+		}
+	}
+	if _, _, err := cached(-1); !errors.Is(err, errMissing) {
+		t.Errorf("error = %v; want %v", err, errMissing)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 2 {
+		t.Errorf("underlying called %d times; want 2", calls)
+	}
+}
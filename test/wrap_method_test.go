@@ -0,0 +1,57 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+// catalog is a service whose lookup method has a pointer receiver.
+type catalog struct {
+	prefix string
+	calls  int
+}
+
+func (c *catalog) Lookup(id int) (string, error) {
+	c.calls++
+	return fmt.Sprintf("%s-%d", c.prefix, id), nil
+}
+
+func TestWrapMethodBindsReceiver(t *testing.T) {
+	svc := &catalog{prefix: "item"}
+	lookup := fcache.WrapMethod(svc, (*catalog).Lookup, nil, nil)
+
+	for i := 0; i < 3; i++ {
+		if v, err := lookup(7); err != nil || v != "item-7" {
+			t.Fatalf("lookup(7) = (%q, %v); want (\"item-7\", nil)", v, err)
+		}
+	}
+	if svc.calls != 1 {
+		t.Errorf("Lookup ran %d times; want 1", svc.calls)
+	}
+
+	other := &catalog{prefix: "other"}
+	lookupOther := fcache.WrapMethod(other, (*catalog).Lookup, nil, nil)
+	if v, _ := lookupOther(7); v != "other-7" {
+		t.Errorf("lookup on a second receiver = %q; want its own cache and receiver", v)
+	}
+}
+
+func TestPointerReceiverStringerArgument(t *testing.T) {
+	calls := 0
+	cached := fcache.NewCachedFunction(func(l label) (string, error) {
+		calls++
+		return l.name, nil
+	}, nil, nil)
+
+	if v, _ := cached(label{name: "a"}); v != "a" {
+		t.Errorf("cached(a) = %q; want a", v)
+	}
+	if v, _ := cached(label{name: "b"}); v != "b" {
+		t.Errorf("cached(b) = %q; want b, not the entry of a", v)
+	}
+	if calls != 2 {
+		t.Errorf("function ran %d times; want 2", calls)
+	}
+}
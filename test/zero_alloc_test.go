@@ -0,0 +1,35 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/osmike/fcache"
+)
+
+func TestWarmHitsDoNotAllocate(t *testing.T) {
+	if testing.CoverMode() != "" {
+		t.Skip("coverage instrumentation allocates")
+	}
+	ints := fcache.NewCachedFunction(func(key int) (string, error) {
+		return "v", nil
+	}, nil, nil)
+	flags := fcache.NewCachedFunction(func(key bool) (string, error) {
+		return "v", nil
+	}, nil, nil)
+	comparable := fcache.NewCachedFunctionComparable(func(key int) (string, error) {
+		return "v", nil
+	}, nil, nil)
+	ints(42)
+	flags(true)
+	comparable(123456)
+
+	for name, hit := range map[string]func(){
+		"small int key":  func() { ints(42) },
+		"bool key":       func() { flags(true) },
+		"comparable key": func() { comparable(123456) },
+	} {
+		if allocs := testing.AllocsPerRun(100, hit); allocs != 0 {
+			t.Errorf("%s: warm hit allocates %v times; want 0", name, allocs)
+		}
+	}
+}